@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	_ "mpc/docs"
 	"mpc/internal/api"
 	"mpc/internal/config"
@@ -9,8 +10,11 @@ import (
 	"mpc/internal/repository"
 	"mpc/internal/service"
 	"mpc/pkg/ethereum"
+	"mpc/pkg/events"
 	"mpc/pkg/logger"
+	"mpc/pkg/policy"
 	"mpc/pkg/token"
+	"mpc/pkg/tracing"
 	"mpc/pkg/tss"
 )
 
@@ -32,8 +36,17 @@ func main() {
 		logger.Error("Failed to load config", err)
 	}
 
+	// tracing
+	logger.Info("Initializing tracing")
+	shutdownTracing, err := tracing.Init(context.Background(), &cfg.Tracing)
+	if err != nil {
+		logger.Error("Failed to initialize tracing", err)
+	} else {
+		defer shutdownTracing(context.Background())
+	}
+
 	// db
-	logger.Info("Initializing database" + cfg.Eth.URL)
+	logger.Info("Initializing database")
 	dbPool, err := db.InitDB(&cfg.DB)
 	if err != nil {
 		logger.Error("Failed to initialize database", err)
@@ -51,16 +64,21 @@ func main() {
 	// token
 	tokenManager := token.NewTokenManager(redisClient)
 
-	// ethereum
-	ethClient, err := ethereum.NewEthClient(cfg.Eth.URL)
+	// tss
+	if cfg.TSS.Mode == tss.ModeFake {
+		logger.Warn("Using the in-memory fake TSS, keys are not protected")
+	}
+	tssClient, err := tss.New(&cfg.TSS, redisClient)
 	if err != nil {
-		logger.Error("Failed to initialize Ethereum client", err)
+		logger.Error("Failed to initialize TSS client", err)
+	} else {
+		defer tssClient.Close()
 	}
 
-	// tss
-	tssClient, err := tss.NewTSS(redisClient)
+	// policy
+	policyClient, err := policy.NewClient(&cfg.Policy)
 	if err != nil {
-		logger.Error("Failed to initialize TSS client", err)
+		logger.Error("Failed to initialize policy client, transactions cannot be signed", err)
 	}
 
 	// repository
@@ -69,6 +87,19 @@ func main() {
 	transactionRepo := repository.NewTransactionRepository(dbPool)
 	userRepo := repository.NewUserRepository(dbPool)
 	walletRepo := repository.NewWalletRepository(dbPool)
+	orgRepo := repository.NewOrganizationRepository(dbPool)
+	webhookRepo := repository.NewWebhookRepository(dbPool)
+
+	// ethereum, clients are dialed per chain from the chains table on first use
+	ethClients := ethereum.NewRegistry(&cfg.Eth, chainRepo)
+	defer ethClients.Close()
+
+	// nonces of concurrent transactions
+	nonceManager := ethereum.NewNonceManager(&cfg.Eth, redisClient, ethClients)
+
+	// events pushed to users, streamed by every API server
+	broker := events.NewBroker(redisClient, &cfg.Events)
+	go broker.Run(context.Background())
 
 	// service
 	oauthClient := &service.GoogleOAuthClient{
@@ -77,13 +108,17 @@ func main() {
 		RedirectURI:  cfg.OauthClient.RedirectURI,
 	}
 	assetService := service.NewAssetService(chainRepo, tokenRepo, redisClient)
-	walletService := service.NewWalletService(walletRepo, tssClient, ethClient)
+	orgService := service.NewOrganizationService(orgRepo)
+	webhookService := service.NewWebhookService(webhookRepo)
+	eventService := service.NewEventService(walletRepo, broker, webhookService)
+	addressIndex := service.NewAddressIndex(redisClient, walletRepo)
+	walletService := service.NewWalletService(walletRepo, orgRepo, tssClient, policyClient, ethClients, eventService, addressIndex)
 	userService := service.NewUserService(userRepo, walletRepo, redisClient)
 	authService := service.NewAuthService(userService, walletService, tokenManager, oauthClient)
-	transactionService := service.NewTransactionService(transactionRepo, walletService, assetService, ethClient, tssClient)
+	transactionService := service.NewTransactionService(transactionRepo, walletService, assetService, ethClients, tssClient, policyClient, nonceManager, eventService)
 
 	// router
-	router := api.NewRouter(authService, assetService, userService, transactionService, tokenManager, walletService)
+	router := api.NewRouter(authService, assetService, userService, transactionService, tokenManager, walletService, eventService, &cfg.Events, orgService, webhookService)
 
 	// run router
 	logger.Info("Running router")
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"mpc/internal/config"
+	"mpc/internal/db/redis"
+	"mpc/internal/repository"
+	"mpc/internal/service"
+	"mpc/pkg/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runBackfill records the past transfers of an address:
+//
+//	worker backfill -chain 11155111 -address 0x... -from 7000000 [-to 7100000]
+//
+// Running it again with the same range resumes an interrupted backfill.
+// Without -to, it resumes the unfinished backfill from the same block, or
+// backfills up to the chain head.
+func runBackfill(cfg *config.Config, dbPool *pgxpool.Pool, redisClient *redis.Client, addressIndex *service.AddressIndex, args []string) {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	chainID := flags.Int("chain", 0, "chain ID")
+	address := flags.String("address", "", "address to backfill")
+	from := flags.Uint64("from", 0, "first block")
+	to := flags.Uint64("to", 0, "last block, that of the unfinished backfill from -from or the chain head if 0")
+	flags.Parse(args)
+
+	if *chainID == 0 || !common.IsHexAddress(*address) {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// Interrupted backfills keep the blocks saved so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	chainRepo := repository.NewChainRepository(dbPool)
+	ethClients := ethereum.NewRegistry(&cfg.Eth, chainRepo)
+	defer ethClients.Close()
+	client, err := ethClients.Client(ctx, *chainID)
+	if err != nil {
+		log.Fatalf("Failed to get client of chain %d: %v", *chainID, err)
+	}
+
+	// Transfers with other monitored addresses are recorded too
+	if err := addressIndex.Load(ctx, *chainID); err != nil {
+		log.Fatalf("Failed to load addresses of chain %d: %v", *chainID, err)
+	}
+
+	assetService := service.NewAssetService(chainRepo, repository.NewTokenRepository(dbPool), redisClient)
+	backfiller := service.NewBackfiller(&cfg.Backfill, repository.NewBackfillRepository(dbPool), assetService, addressIndex.Chain(*chainID), client, *chainID)
+	job, err := backfiller.Run(ctx, common.HexToAddress(*address), *from, *to)
+	if err != nil {
+		log.Fatalf("Backfill stopped at block %d, run it again to resume: %v", job.NextBlock, err)
+	}
+	fmt.Printf("Backfill %s %s: blocks %d to %d, %d transactions found\n", job.ID, job.Status, job.FromBlock, job.ToBlock, job.TransactionsFound)
+}
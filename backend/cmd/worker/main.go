@@ -2,33 +2,20 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"math/big"
 	"mpc/internal/config"
 	"mpc/internal/db"
 	"mpc/internal/db/redis"
-	"mpc/internal/model"
 	"mpc/internal/repository"
+	"mpc/internal/service"
+	"mpc/pkg/ethereum"
+	"mpc/pkg/events"
 	"mpc/pkg/logger"
-	"strings"
-	"time"
-
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-)
-
-const (
-	infuraURL = "https://sepolia.infura.io/v3/b1c6cddf9a94496d9b134512bc3640be"
+	"os"
+	"sync"
 )
 
-var (
-	ctx                = context.Background()
-	redisClient        *redis.Client
-	monitoredAddresses map[common.Address]bool
-	txnRepo            *repository.TransactionRepository
-	walletRepo         *repository.WalletRepository
-)
+var ctx = context.Background()
 
 func main() {
 	logger.Info("Starting worker")
@@ -48,124 +35,77 @@ func main() {
 	}
 	defer db.CloseDB()
 
-	txnRepo = repository.NewTransactionRepository(dbPool)
-	walletRepo = repository.NewWalletRepository(dbPool)
+	txnRepo := repository.NewTransactionRepository(dbPool)
+	walletRepo := repository.NewWalletRepository(dbPool)
+	blockRepo := repository.NewBlockRepository(dbPool)
 
 	// Initialize Redis
 	logger.Info("Initializing Redis client")
-	redisClient, err = redis.NewRedisClient(&cfg.Redis)
+	redisClient, err := redis.NewRedisClient(&cfg.Redis)
 	if err != nil {
 		logger.Error("Failed to initialize Redis client", err)
 	}
 	defer redisClient.Close()
 
-	// Load addresses into Redis
-	if err := loadAddressesToRedis(); err != nil {
-		log.Fatalf("Failed to load addresses: %v", err)
-	}
-
-	go updateCachePeriodically()
-
-	// Connect to Ethereum client
-	client, err := ethclient.Dial(infuraURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to Infura: %v", err)
-	}
-	defer client.Close()
-
-	fmt.Println("Starting transaction scanner...")
-
-	for {
-		checkLatestBlock(client)
-		time.Sleep(10 * time.Second)
-	}
-}
-
-func loadAddressesToRedis() error {
-	rows, err := walletRepo.GetAllAddresses(ctx)
-	if err != nil {
-		return err
-	}
-
-	var addresses []string
-	addresses = append(addresses, rows...)
-	fmt.Printf("Loaded %d addresses\n", len(addresses))
-	if len(addresses) > 0 {
-		redisClient.Del(ctx, "monitored_addresses")
-		redisClient.SAdd(ctx, "monitored_addresses", addresses)
-	}
-	return nil
-}
-
-func getMonitoredAddressesFromRedis() (map[common.Address]bool, error) {
-	addresses, err := redisClient.SMembers(ctx, "monitored_addresses").Result()
-	if err != nil {
-		return nil, err
-	}
-
-	addressMap := make(map[common.Address]bool)
-	for _, addr := range addresses {
-		addressMap[common.HexToAddress(addr)] = true
+	// Monitored addresses, kept up to date from the wallet creations
+	addressIndex := service.NewAddressIndex(redisClient, walletRepo)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "backfill":
+			runBackfill(cfg, dbPool, redisClient, addressIndex, os.Args[2:])
+			return
+		case "reindex-addresses":
+			runReindexAddresses(dbPool, addressIndex, os.Args[2:])
+			return
+		}
 	}
-	return addressMap, nil
-}
 
-func updateCachePeriodically() {
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
+	// One client per chain, dialed from the RPC URLs in the chains table
+	chainRepo := repository.NewChainRepository(dbPool)
+	ethClients := ethereum.NewRegistry(&cfg.Eth, chainRepo)
+	defer ethClients.Close()
 
-	for {
-		<-ticker.C
-		if err := loadAddressesToRedis(); err != nil {
-			log.Printf("Error updating Redis cache: %v", err)
-		} else {
-			log.Println("Updated monitored addresses in Redis")
-		}
-	}
-}
+	// Shared with the API, dropped transactions give their nonce back
+	nonceManager := ethereum.NewNonceManager(&cfg.Eth, redisClient, ethClients)
 
-func checkLatestBlock(client *ethclient.Client) {
-	monitoredAddresses, _ = getMonitoredAddressesFromRedis()
-	block, err := client.BlockByNumber(ctx, nil)
+	chains, err := chainRepo.GetChains(ctx)
 	if err != nil {
-		log.Printf("Error getting latest block: %v", err)
-		return
+		log.Fatalf("Failed to get chains: %v", err)
 	}
+	assetService := service.NewAssetService(chainRepo, repository.NewTokenRepository(dbPool), redisClient)
+	webhookRepo := repository.NewWebhookRepository(dbPool)
+	eventService := service.NewEventService(walletRepo, events.NewBroker(redisClient, &cfg.Events), service.NewWebhookService(webhookRepo))
 
-	fmt.Printf("Scanning Block #%d...\n", block.NumberU64())
+	// Deliver the events queued for organization webhooks
+	dispatcher := service.NewWebhookDispatcher(&cfg.Webhook, webhookRepo)
+	go dispatcher.Run(ctx)
 
-	for i, tx := range block.Transactions() {
-		if tx.To() == nil {
-			continue
-		}
-		log.Printf("Processing transaction %s", tx.Hash().Hex())
-		from, err := client.TransactionSender(ctx, tx, block.Header().Hash(), uint(i))
+	var wg sync.WaitGroup
+	for _, chain := range chains {
+		client, err := ethClients.Client(ctx, chain.ChainID)
 		if err != nil {
-			log.Printf("Error getting sender: %v", err)
+			log.Printf("Skipping chain %d: %v", chain.ChainID, err)
 			continue
 		}
 
-		to := *tx.To()
-		if monitoredAddresses[from] || monitoredAddresses[to] {
-			fmt.Printf("Transaction Found! Hash: %s, From: %s, To: %s, Value: %s ETH\n",
-				tx.Hash().Hex(), from.Hex(), to.Hex(), weiToEth(tx.Value()))
-
-			// Save transaction to database
-			txn := model.Transaction{
-				TxHash:      strings.ToLower(tx.Hash().Hex()),
-				FromAddress: strings.ToLower(from.Hex()),
-				ToAddress:   strings.ToLower(to.Hex()),
-				ChainID:     11155111,
-			}
-			if _, err := txnRepo.CreateTransaction(ctx, txn); err != nil {
-				log.Printf("Error saving transaction: %v", err)
-			}
+		// Scan with the addresses of the chain indexed
+		if err := addressIndex.Load(ctx, chain.ChainID); err != nil {
+			log.Fatalf("Failed to load addresses of chain %d: %v", chain.ChainID, err)
 		}
+		go addressIndex.Run(ctx, chain.ChainID)
+
+		// Follow submitted transactions until they are confirmed
+		tracker := service.NewTransactionTracker(&cfg.Tracker, txnRepo, assetService, eventService, client, nonceManager, chain.ChainID)
+		go tracker.Run(ctx)
+
+		// Walk every block and record the transfers of monitored addresses
+		scanner := service.NewBlockScanner(&cfg.Scanner, blockRepo, assetService, eventService, addressIndex.Chain(chain.ChainID), client, chain.ChainID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scanner.Run(ctx)
+		}()
 	}
-}
-
-func weiToEth(wei *big.Int) string {
-	ethValue := new(big.Float).SetInt(wei)
-	ethValue.Quo(ethValue, big.NewFloat(1e18))
-	return ethValue.Text('f', 6)
+	wg.Wait()
 }
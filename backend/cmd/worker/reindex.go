@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"mpc/internal/repository"
+	"mpc/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runReindexAddresses indexes the monitored addresses of a chain, or of every
+// chain, from the wallets table again:
+//
+//	worker reindex-addresses [-chain 11155111]
+//
+// Only needed when the index missed wallets, the workers keep it up to date.
+func runReindexAddresses(dbPool *pgxpool.Pool, addressIndex *service.AddressIndex, args []string) {
+	flags := flag.NewFlagSet("reindex-addresses", flag.ExitOnError)
+	chainID := flags.Int("chain", 0, "chain ID, every chain if 0")
+	flags.Parse(args)
+
+	chainIDs := []int{*chainID}
+	if *chainID == 0 {
+		chains, err := repository.NewChainRepository(dbPool).GetChains(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get chains: %v", err)
+		}
+		chainIDs = chainIDs[:0]
+		for _, chain := range chains {
+			chainIDs = append(chainIDs, chain.ChainID)
+		}
+	}
+
+	for _, id := range chainIDs {
+		if _, err := addressIndex.Rebuild(ctx, id); err != nil {
+			log.Fatalf("Failed to reindex addresses of chain %d: %v", id, err)
+		}
+		fmt.Printf("Reindexed addresses of chain %d\n", id)
+	}
+}
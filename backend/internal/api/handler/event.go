@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"mpc/internal/model"
+	"mpc/internal/service"
+	"mpc/pkg/events"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventHandler struct {
+	BaseHandler
+	eventService *service.EventService
+	keepAlive    time.Duration
+}
+
+func NewEventHandler(eventService *service.EventService, keepAlive time.Duration) *EventHandler {
+	if keepAlive <= 0 {
+		keepAlive = 15 * time.Second
+	}
+	return &EventHandler{
+		BaseHandler:  NewBaseHandler(),
+		eventService: eventService,
+		keepAlive:    keepAlive,
+	}
+}
+
+// Stream godoc
+// @Summary      Stream events
+// @Description  Server-sent events about the wallets of the user: deposit.received, transaction.confirmed, transaction.failed and wallet.created. A client reconnecting with the Last-Event-ID header, or the last_event_id query, first receives the events it missed.
+// @Tags         events
+// @Produce      text/event-stream
+// @Param        Last-Event-ID header string false "ID of the last event received"
+// @Param        last_event_id query string false "ID of the last event received, when the header cannot be set"
+// @Success      200  {object}  model.Event
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Router       /stream [get]
+func (h *EventHandler) Stream(c *gin.Context) {
+	userID, err := h.GetUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctx := c.Request.Context()
+
+	// Subscribed before reading the missed events, so none falls in between
+	live, unsubscribe := h.eventService.Subscribe(userID)
+	defer unsubscribe()
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	var missed []model.Event
+	if lastID != "" {
+		missed, err = h.eventService.EventsSince(ctx, userID, lastID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range missed {
+		if err := writeEvent(c, event); err != nil {
+			return
+		}
+		lastID = event.ID
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-live:
+			// Dropped for lagging behind, the client reconnects and catches up
+			if !ok {
+				return
+			}
+			if !events.After(event.ID, lastID) {
+				continue
+			}
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeEvent writes an event in the server-sent events format
+func writeEvent(c *gin.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
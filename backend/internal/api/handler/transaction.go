@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"context"
 	"mpc/internal/model"
 	"mpc/internal/service"
+	"mpc/pkg/errors"
 	"mpc/pkg/logger"
 	"mpc/pkg/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -55,15 +58,48 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 	h.SuccessResponse(c, res)
 }
 
+// GetTransaction godoc
+// @Summary      Get transaction
+// @Description  Get a transaction of the user's wallet with its status history
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Transaction ID"
+// @Success      200  {object}  model.Response{payload=model.Transaction}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /transactions/{id} [get]
+func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	userID, err := h.GetUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidRequest)
+		return
+	}
+
+	res, err := h.txnService.GetTransaction(c.Request.Context(), userID, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, res)
+}
+
 // CreateAndSubmitTransaction godoc
 // @Summary      Create and submit transaction
-// @Description  Create and submit transaction
+// @Description  Create and submit transaction. The transaction is simulated first and refused if it would revert. With dry_run it is only simulated and a model.SimulationResult is returned.
 // @Tags         transactions
 // @Accept       json
 // @Produce      json
 // @Param        request body model.CreateAndSubmitTransactionRequest true "Transaction request"
 // @Success      200  {object}  model.Response{payload=model.Transaction}
 // @Failure      400  {object}  model.ErrorResponse
+// @Failure      422  {object}  model.ErrorResponse
 // @Router       /transactions [post]
 func (h *TransactionHandler) CreateAndSubmitTransaction(c *gin.Context) {
 	userID, err := h.GetUserID(c)
@@ -78,6 +114,16 @@ func (h *TransactionHandler) CreateAndSubmitTransaction(c *gin.Context) {
 	}
 	logger.Debug("CreateAndSubmitTransaction request")
 
+	if req.DryRun {
+		res, err := h.txnService.SimulateTransaction(c.Request.Context(), userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		h.SuccessResponse(c, res)
+		return
+	}
+
 	res, err := h.txnService.CreateAndSubmitTransaction(c.Request.Context(), userID, req)
 	if err != nil {
 		c.Error(err)
@@ -85,3 +131,107 @@ func (h *TransactionHandler) CreateAndSubmitTransaction(c *gin.Context) {
 	}
 	h.SuccessResponse(c, res)
 }
+
+// CreateContractCall godoc
+// @Summary      Create and submit contract call
+// @Description  Call a contract with raw calldata or with an ABI, method and args encoded by the server. The call is simulated first and refused if it would revert. With dry_run it is only simulated and a model.SimulationResult is returned.
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ContractCallRequest true "Contract call request"
+// @Success      200  {object}  model.Response{payload=model.Transaction}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      422  {object}  model.ErrorResponse
+// @Router       /transactions/call [post]
+func (h *TransactionHandler) CreateContractCall(c *gin.Context) {
+	userID, err := h.GetUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req model.ContractCallRequest
+	if err := utils.ValidateBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if req.DryRun {
+		res, err := h.txnService.SimulateContractCall(c.Request.Context(), userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		h.SuccessResponse(c, res)
+		return
+	}
+
+	res, err := h.txnService.CreateContractCall(c.Request.Context(), userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, res)
+}
+
+// SpeedUpTransaction godoc
+// @Summary      Speed up transaction
+// @Description  Rebroadcast a stuck transaction with the same nonce and higher fees
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Transaction ID"
+// @Param        request body model.ReplaceTransactionRequest true "Replacement request"
+// @Success      200  {object}  model.Response{payload=model.Transaction}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      409  {object}  model.ErrorResponse
+// @Router       /transactions/{id}/speedup [post]
+func (h *TransactionHandler) SpeedUpTransaction(c *gin.Context) {
+	h.replaceTransaction(c, h.txnService.SpeedUpTransaction)
+}
+
+// CancelTransaction godoc
+// @Summary      Cancel transaction
+// @Description  Replace a stuck transaction with a 0-value transfer to the wallet itself
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Transaction ID"
+// @Param        request body model.ReplaceTransactionRequest true "Replacement request"
+// @Success      200  {object}  model.Response{payload=model.Transaction}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      409  {object}  model.ErrorResponse
+// @Router       /transactions/{id}/cancel [post]
+func (h *TransactionHandler) CancelTransaction(c *gin.Context) {
+	h.replaceTransaction(c, h.txnService.CancelTransaction)
+}
+
+// Helper methods
+func (h *TransactionHandler) replaceTransaction(
+	c *gin.Context,
+	replace func(context.Context, uuid.UUID, uuid.UUID, model.ReplaceTransactionRequest) (model.Transaction, error),
+) {
+	userID, err := h.GetUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidRequest)
+		return
+	}
+
+	var req model.ReplaceTransactionRequest
+	if err := utils.ValidateBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res, err := replace(c.Request.Context(), userID, id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, res)
+}
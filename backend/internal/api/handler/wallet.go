@@ -3,8 +3,12 @@ package handler
 import (
 	"mpc/internal/model"
 	"mpc/internal/service"
+	"mpc/pkg/errors"
+	"mpc/pkg/utils"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type WalletHandler struct {
@@ -25,6 +29,7 @@ func NewWalletHandler(walletService *service.WalletService) *WalletHandler {
 // @Tags         wallets
 // @Accept       json
 // @Produce      json
+// @Param        chain_id query string false "Chain ID"
 // @Success      200  {object}  model.Response{payload=model.WalletResponse}
 // @Failure      400  {object}  model.ErrorResponse
 // @Failure      401  {object}  model.ErrorResponse
@@ -37,6 +42,7 @@ func (h *WalletHandler) GetBalance(c *gin.Context) {
 	}
 
 	var req model.GetBalanceRequest
+	req.ChainID, _ = strconv.Atoi(c.DefaultQuery("chain_id", "11155111"))
 	res, err := h.walletService.GetBalanceByAddress(c.Request.Context(), req, userID)
 	if err != nil {
 		c.Error(err)
@@ -44,3 +50,83 @@ func (h *WalletHandler) GetBalance(c *gin.Context) {
 	}
 	h.SuccessResponse(c, res)
 }
+
+// SignMessage godoc
+// @Summary      Sign message
+// @Description  Sign a message with personal_sign (EIP-191). Wallets of an organization with a signing allowlist only sign sign-in messages (EIP-4361) of allowed domains.
+// @Tags         wallets
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Wallet ID"
+// @Param        request body model.SignMessageRequest true "Message"
+// @Success      200  {object}  model.Response{payload=model.SignatureResponse}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /wallets/{id}/sign-message [post]
+func (h *WalletHandler) SignMessage(c *gin.Context) {
+	userID, err := h.GetUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidRequest)
+		return
+	}
+
+	var req model.SignMessageRequest
+	if err := utils.ValidateBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res, err := h.walletService.SignMessage(c.Request.Context(), userID, walletID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, res)
+}
+
+// SignTypedData godoc
+// @Summary      Sign typed data
+// @Description  Sign EIP-712 typed data like eth_signTypedData_v4. Wallets of an organization with a signing allowlist only sign typed data of allowed verifying contracts.
+// @Tags         wallets
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Wallet ID"
+// @Param        request body model.SignTypedDataRequest true "Typed data"
+// @Success      200  {object}  model.Response{payload=model.SignatureResponse}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /wallets/{id}/sign-typed-data [post]
+func (h *WalletHandler) SignTypedData(c *gin.Context) {
+	userID, err := h.GetUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidRequest)
+		return
+	}
+
+	var req model.SignTypedDataRequest
+	if err := utils.ValidateBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res, err := h.walletService.SignTypedData(c.Request.Context(), userID, walletID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, res)
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"mpc/internal/model"
+	"mpc/internal/service"
+	"mpc/pkg/errors"
+	"mpc/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	BaseHandler
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		BaseHandler:    NewBaseHandler(),
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary      Create webhook
+// @Description  Create a webhook receiving the events of the organization: deposit.received, transaction.confirmed, transaction.failed and wallet.created. The secret signing the deliveries is only returned here.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        organization_id path string true "Organization ID"
+// @Param        webhook body model.CreateWebhookRequest true "Webhook"
+// @Success      201  {object}  model.Response{payload=model.CreateWebhookResponse}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Router       /organizations/{organization_id}/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("organization_id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidRequest)
+		return
+	}
+
+	var req model.CreateWebhookRequest
+	if err := utils.ValidateBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), orgID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"payload": model.CreateWebhookResponse{Webhook: *webhook, Secret: webhook.Secret},
+	})
+}
+
+// GetWebhooks godoc
+// @Summary      Get webhooks
+// @Description  Get the webhooks of the organization
+// @Tags         webhooks
+// @Produce      json
+// @Param        organization_id path string true "Organization ID"
+// @Success      200  {object}  model.Response{payload=[]model.Webhook}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Router       /organizations/{organization_id}/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("organization_id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidRequest)
+		return
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(c.Request.Context(), orgID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary      Get webhook
+// @Description  Get a webhook of the organization
+// @Tags         webhooks
+// @Produce      json
+// @Param        organization_id path string true "Organization ID"
+// @Param        id path string true "Webhook ID"
+// @Success      200  {object}  model.Response{payload=model.Webhook}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /organizations/{organization_id}/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	orgID, webhookID, err := webhookParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), orgID, webhookID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary      Update webhook
+// @Description  Change the name, URL, events or status (active, disabled) of a webhook. Events are not queued for a disabled webhook, the ones already queued wait until it is active again.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        organization_id path string true "Organization ID"
+// @Param        id path string true "Webhook ID"
+// @Param        webhook body model.UpdateWebhookRequest true "Fields to change"
+// @Success      200  {object}  model.Response{payload=model.Webhook}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /organizations/{organization_id}/webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	orgID, webhookID, err := webhookParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req model.UpdateWebhookRequest
+	if err := utils.ValidateBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), orgID, webhookID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete webhook
+// @Description  Delete a webhook and its queued events
+// @Tags         webhooks
+// @Param        organization_id path string true "Organization ID"
+// @Param        id path string true "Webhook ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /organizations/{organization_id}/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	orgID, webhookID, err := webhookParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), orgID, webhookID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetWebhookEvents godoc
+// @Summary      Get webhook events
+// @Description  Get the events queued for a webhook with their delivery status: pending, delivered or dead
+// @Tags         webhooks
+// @Produce      json
+// @Param        organization_id path string true "Organization ID"
+// @Param        id path string true "Webhook ID"
+// @Param        status query string false "Delivery status"
+// @Param        page query int false "Page"
+// @Param        page_size query int false "Page size"
+// @Success      200  {object}  model.Response{payload=model.WebhookEventListResponse}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /organizations/{organization_id}/webhooks/{id}/events [get]
+func (h *WebhookHandler) GetWebhookEvents(c *gin.Context) {
+	orgID, webhookID, err := webhookParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	res, err := h.webhookService.GetWebhookEvents(c.Request.Context(), orgID, webhookID, c.Query("status"), page, pageSize)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, res)
+}
+
+// RedeliverWebhookEvent godoc
+// @Summary      Redeliver webhook event
+// @Description  Queue an event of a webhook again, typically a dead one, with a fresh set of attempts
+// @Tags         webhooks
+// @Produce      json
+// @Param        organization_id path string true "Organization ID"
+// @Param        id path string true "Webhook ID"
+// @Param        event_id path string true "Webhook event ID"
+// @Success      200  {object}  model.Response{payload=model.WebhookEvent}
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Router       /organizations/{organization_id}/webhooks/{id}/events/{event_id}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhookEvent(c *gin.Context) {
+	orgID, webhookID, err := webhookParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	eventID, err := uuid.Parse(c.Param("event_id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidRequest)
+		return
+	}
+
+	event, err := h.webhookService.RedeliverEvent(c.Request.Context(), orgID, webhookID, eventID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.SuccessResponse(c, event)
+}
+
+// webhookParams parses the organization and webhook IDs of the path
+func webhookParams(c *gin.Context) (orgID uuid.UUID, webhookID uuid.UUID, err error) {
+	orgID, err = uuid.Parse(c.Param("organization_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.ErrInvalidRequest
+	}
+	webhookID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.ErrInvalidRequest
+	}
+	return orgID, webhookID, nil
+}
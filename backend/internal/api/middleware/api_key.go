@@ -141,6 +141,24 @@ func (m *APIKeyMiddleware) RequirePermission(permission string) gin.HandlerFunc
 	}
 }
 
+// RequireOrganization middleware to check that the API key belongs to the
+// organization in the path parameter param
+func (m *APIKeyMiddleware) RequireOrganization(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, err := GetOrganizationID(c)
+		pathOrgID, pathErr := uuid.Parse(c.Param(param))
+		if err != nil || pathErr != nil || pathOrgID != orgID {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "API key does not belong to the organization",
+				"code":  "ORGANIZATION_MISMATCH",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // GetOrganizationID helper to get organization ID from context
 func GetOrganizationID(c *gin.Context) (uuid.UUID, error) {
 	orgID, exists := c.Get("organization_id")
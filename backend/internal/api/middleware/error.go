@@ -22,10 +22,14 @@ func ErrorHandler() gin.HandlerFunc {
 
 			// Check if the error is an AppError
 			if appErr, ok := err.(*errors.AppError); ok {
-				c.JSON(appErr.Status, gin.H{
+				body := gin.H{
 					"error":      appErr.Message,
 					"error_code": appErr.Code,
-				})
+				}
+				if appErr.Details != nil {
+					body["details"] = appErr.Details
+				}
+				c.JSON(appErr.Status, body)
 				return
 			}
 
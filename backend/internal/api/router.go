@@ -3,11 +3,13 @@ package api
 import (
 	"mpc/internal/api/handler"
 	"mpc/internal/api/middleware"
+	"mpc/internal/config"
 	"mpc/internal/service"
 	"mpc/pkg/token"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -20,6 +22,10 @@ func NewRouter(
 	txnService *service.TransactionService,
 	tokenManager *token.TokenManager,
 	walletService *service.WalletService,
+	eventService *service.EventService,
+	eventsCfg *config.EventsConfig,
+	orgService *service.OrganizationService,
+	webhookService *service.WebhookService,
 ) *gin.Engine {
 	// Disable default logger
 	gin.SetMode(gin.ReleaseMode)
@@ -27,6 +33,7 @@ func NewRouter(
 	router := gin.New()
 	// Apply CORS middlewareelopment
 	router.Use(middleware.Cors())
+	router.Use(otelgin.Middleware("mpc-api"))
 
 	// Use our custom logger and recovery middleware
 	router.Use(middleware.Logger())
@@ -39,6 +46,9 @@ func NewRouter(
 	userHandler := handler.NewUserHandler(userService)
 	txnHandler := handler.NewTransactionHandler(txnService)
 	walletHandler := handler.NewWalletHandler(walletService)
+	eventHandler := handler.NewEventHandler(eventService, eventsCfg.KeepAlive)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	apiKeyMiddleware := middleware.NewAPIKeyMiddleware(orgService)
 
 	v1 := router.Group("/api/v1")
 	{
@@ -69,13 +79,33 @@ func NewRouter(
 		transactions.Use(middleware.AuthMiddleware(tokenManager))
 		{
 			transactions.GET("", txnHandler.GetTransactions)
+			transactions.GET("/:id", txnHandler.GetTransaction)
+			transactions.POST("/:id/speedup", txnHandler.SpeedUpTransaction)
+			transactions.POST("/:id/cancel", txnHandler.CancelTransaction)
 			transactions.POST("/", txnHandler.CreateAndSubmitTransaction)
+			transactions.POST("/call", txnHandler.CreateContractCall)
 		}
 
 		wallet := v1.Group("/wallets")
 		wallet.Use(middleware.AuthMiddleware(tokenManager))
 		{
 			wallet.GET("/balance", walletHandler.GetBalance)
+			wallet.POST("/:id/sign-message", walletHandler.SignMessage)
+			wallet.POST("/:id/sign-typed-data", walletHandler.SignTypedData)
+		}
+
+		v1.GET("/stream", middleware.AuthMiddleware(tokenManager), eventHandler.Stream)
+
+		webhooks := v1.Group("/organizations/:organization_id/webhooks")
+		webhooks.Use(apiKeyMiddleware.APIKeyAuth(), apiKeyMiddleware.RequireOrganization("organization_id"))
+		{
+			webhooks.GET("", webhookHandler.GetWebhooks)
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.GET("/:id", webhookHandler.GetWebhook)
+			webhooks.PATCH("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.GET("/:id/events", webhookHandler.GetWebhookEvents)
+			webhooks.POST("/:id/events/:event_id/redeliver", webhookHandler.RedeliverWebhookEvent)
 		}
 
 		// Redirect to swagger docs
@@ -0,0 +1,8 @@
+package config
+
+// BackfillConfig configures the backfill of the history of an address
+type BackfillConfig struct {
+	BatchSize   int `env:"BACKFILL_BATCH_SIZE" envDefault:"100"` // blocks per batch, also the range of one logs query
+	Concurrency int `env:"BACKFILL_CONCURRENCY" envDefault:"4"`  // batches scanned in parallel
+	RateLimit   int `env:"BACKFILL_RATE_LIMIT" envDefault:"20"`  // RPC requests per second
+}
@@ -13,6 +13,14 @@ type Config struct {
 	Redis       RedisConfig
 	Eth         EthConfig
 	OauthClient GoogleOAuthClient
+	Tracing     TracingConfig
+	Policy      PolicyConfig
+	TSS         TSSConfig
+	Tracker     TrackerConfig
+	Scanner     ScannerConfig
+	Events      EventsConfig
+	Webhook     WebhookConfig
+	Backfill    BackfillConfig
 	CORS        struct {
 		AllowOrigins     []string `envconfig:"CORS_ALLOW_ORIGINS" default:"*"`
 		AllowCredentials bool     `envconfig:"CORS_ALLOW_CREDENTIALS" default:"true"`
@@ -1,5 +1,26 @@
 package config
 
+import "time"
+
 type EthConfig struct {
-	URL string `env:"ETH_URL" envDefault:"wss://sepolia.infura.io/ws/v3/6c89fb7fa351451f939eea9da6bee755"`
+	// RPC endpoints come from the chains table, an endpoint is skipped while
+	// it fails or lags more than MaxBlockLag blocks behind the best one
+	RPCTimeout     time.Duration `env:"ETH_RPC_TIMEOUT" envDefault:"5s"`
+	HealthInterval time.Duration `env:"ETH_RPC_HEALTH_INTERVAL" envDefault:"15s"`
+	MaxBlockLag    uint64        `env:"ETH_RPC_MAX_BLOCK_LAG" envDefault:"5"`
+
+	// EIP-1559 fees, the priority fee of each speed tier is a percentile of
+	// the priority fees paid in the last FeeHistoryBlocks blocks
+	FeeHistoryBlocks   uint64  `env:"ETH_FEE_HISTORY_BLOCKS" envDefault:"20"`
+	SlowPercentile     float64 `env:"ETH_FEE_SLOW_PERCENTILE" envDefault:"10"`
+	StandardPercentile float64 `env:"ETH_FEE_STANDARD_PERCENTILE" envDefault:"50"`
+	FastPercentile     float64 `env:"ETH_FEE_FAST_PERCENTILE" envDefault:"90"`
+	BaseFeeMultiplier  uint64  `env:"ETH_BASE_FEE_MULTIPLIER" envDefault:"2"` // headroom for base fee increases, maxFeePerGas = base fee * multiplier + priority fee
+	DefaultSpeed       string  `env:"ETH_FEE_DEFAULT_SPEED" envDefault:"standard"`
+
+	GasLimitBuffer uint64 `env:"ETH_GAS_LIMIT_BUFFER" envDefault:"20"` // percent added to estimated gas of contract calls
+
+	ReplacementFeeBump uint64 `env:"ETH_REPLACEMENT_FEE_BUMP" envDefault:"12"` // percent a speed-up or cancel pays above the stuck transaction, nodes require at least 10
+
+	NonceReservationTTL time.Duration `env:"ETH_NONCE_RESERVATION_TTL" envDefault:"10m"` // a reserved nonce not sent by then is reused
 }
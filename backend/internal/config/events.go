@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+// EventsConfig configures the events pushed to users, kept per user in Redis
+// so clients can resume a stream
+type EventsConfig struct {
+	MaxLen    int64         `env:"EVENTS_MAX_LEN" envDefault:"1000"`   // latest events kept per user
+	Retention time.Duration `env:"EVENTS_RETENTION" envDefault:"168h"` // events of a user are dropped after this long without new ones
+	KeepAlive time.Duration `env:"EVENTS_KEEP_ALIVE" envDefault:"15s"` // interval of comments keeping idle streams open
+}
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+type PolicyConfig struct {
+	URL       string        `env:"POLICY_URL" envDefault:"http://localhost:8090"` // policy service approving sign requests
+	AuthToken string        `env:"POLICY_AUTH_TOKEN"`                             // bearer token of the policy service, if it requires one
+	Timeout   time.Duration `env:"POLICY_TIMEOUT" envDefault:"10s"`
+}
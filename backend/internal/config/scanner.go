@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+// ScannerConfig configures the worker scanning blocks for transactions of monitored addresses
+type ScannerConfig struct {
+	PollInterval time.Duration `env:"SCANNER_POLL_INTERVAL" envDefault:"10s"`
+	BatchSize    int           `env:"SCANNER_BATCH_SIZE" envDefault:"100"`   // blocks scanned per poll when catching up
+	ReorgWindow  int           `env:"SCANNER_REORG_WINDOW" envDefault:"128"` // latest block hashes kept to detect reorgs
+}
@@ -0,0 +1,9 @@
+package config
+
+type TracingConfig struct {
+	Exporter     string `env:"TRACING_EXPORTER" envDefault:"none"` // none, otlp, stdout or file
+	OTLPEndpoint string `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4317"`
+	OTLPInsecure bool   `env:"TRACING_OTLP_INSECURE" envDefault:"true"`
+	FilePath     string `env:"TRACING_FILE" envDefault:"traces.json"`
+	ServiceName  string `env:"TRACING_SERVICE_NAME" envDefault:"mpc-api"`
+}
@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+// TrackerConfig configures the worker following submitted transactions until they are confirmed
+type TrackerConfig struct {
+	PollInterval time.Duration `env:"TRACKER_POLL_INTERVAL" envDefault:"15s"`
+	BatchSize    int           `env:"TRACKER_BATCH_SIZE" envDefault:"100"`   // transactions checked per poll
+	DropTimeout  time.Duration `env:"TRACKER_DROP_TIMEOUT" envDefault:"30m"` // a broadcast transaction unknown to the node for this long is dropped
+}
@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+type TSSConfig struct {
+	Mode          string        `env:"TSS_MODE" envDefault:"grpc"`                                  // grpc, or fake for in-memory keys
+	FakeSeed      string        `env:"TSS_FAKE_SEED"`                                               // seed of the fake keys
+	Endpoints     []string      `env:"TSS_ENDPOINTS" envDefault:"localhost:50051" envSeparator:","` // replicas of the coordinator, node 1, tried in order
+	Parties       []uint32      `env:"TSS_PARTIES" envDefault:"1,2,3" envSeparator:","`             // parties of newly generated keys
+	Threshold     uint32        `env:"TSS_THRESHOLD" envDefault:"2"`                                // threshold of newly generated keys
+	ResultTimeout time.Duration `env:"TSS_RESULT_TIMEOUT" envDefault:"5m"`
+
+	// mTLS towards the coordinators, plaintext when TSS_TLS_CA is empty
+	TLSCA         string `env:"TSS_TLS_CA"`
+	TLSCert       string `env:"TSS_TLS_CERT"`
+	TLSKey        string `env:"TSS_TLS_KEY"`
+	TLSServerName string `env:"TSS_TLS_SERVER_NAME"`
+
+	// Retries of NotifyAction calls the coordinator did not process
+	ConnectTimeout time.Duration `env:"TSS_CONNECT_TIMEOUT" envDefault:"5s"`
+	MaxRetries     int           `env:"TSS_MAX_RETRIES" envDefault:"3"`
+	BackoffMin     time.Duration `env:"TSS_BACKOFF_MIN" envDefault:"200ms"`
+	BackoffMax     time.Duration `env:"TSS_BACKOFF_MAX" envDefault:"5s"`
+
+	// Circuit breaker per coordinator
+	BreakerFailures int           `env:"TSS_BREAKER_FAILURES" envDefault:"5"`
+	BreakerCooldown time.Duration `env:"TSS_BREAKER_COOLDOWN" envDefault:"30s"`
+}
@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+// WebhookConfig configures the worker delivering events to organization webhooks
+type WebhookConfig struct {
+	PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"5s"`
+	BatchSize    int           `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"`   // events claimed per poll
+	Concurrency  int           `env:"WEBHOOK_CONCURRENCY" envDefault:"10"`  // deliveries in flight
+	Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`     // of one delivery request
+	MaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"10"` // an event failing this many times is dead
+	BackoffMin   time.Duration `env:"WEBHOOK_BACKOFF_MIN" envDefault:"30s"` // wait after the first failure, doubled after each one
+	BackoffMax   time.Duration `env:"WEBHOOK_BACKOFF_MAX" envDefault:"6h"`
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Key metadata of the TSS key behind each wallet, so signing uses the
+-- parties and threshold the key was generated with
+ALTER TABLE "wallets" ADD COLUMN "key_id" VARCHAR(64);
+ALTER TABLE "wallets" ADD COLUMN "parties" INT[] NOT NULL DEFAULT '{1,2,3}';
+ALTER TABLE "wallets" ADD COLUMN "threshold" INT NOT NULL DEFAULT 2;
+
+-- Existing keys were generated with the user ID as keygen session
+UPDATE "wallets" SET "key_id" = "user_id"::TEXT WHERE "key_id" IS NULL;
+ALTER TABLE "wallets" ALTER COLUMN "key_id" SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE "wallets" DROP COLUMN "threshold";
+ALTER TABLE "wallets" DROP COLUMN "parties";
+ALTER TABLE "wallets" DROP COLUMN "key_id";
@@ -0,0 +1,52 @@
+-- +goose Up
+-- Lifecycle of a transaction: created -> signing -> signed -> broadcast ->
+-- pending -> confirmed, or failed/dropped/replaced on the way
+ALTER TABLE "transactions" ADD COLUMN "status" VARCHAR(20) NOT NULL DEFAULT 'broadcast';
+ALTER TABLE "transactions" ALTER COLUMN "status" SET DEFAULT 'created';
+ALTER TABLE "transactions" ALTER COLUMN "tx_hash" SET DEFAULT '';
+ALTER TABLE "transactions" ADD COLUMN "symbol" VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE "transactions" ADD COLUMN "amount" VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE "transactions" ADD COLUMN "nonce" BIGINT;
+ALTER TABLE "transactions" ADD COLUMN "gas_limit" BIGINT;
+ALTER TABLE "transactions" ADD COLUMN "gas_used" BIGINT;
+ALTER TABLE "transactions" ADD COLUMN "effective_gas_price" VARCHAR(78);
+ALTER TABLE "transactions" ADD COLUMN "block_number" BIGINT;
+ALTER TABLE "transactions" ADD COLUMN "block_hash" VARCHAR(66);
+ALTER TABLE "transactions" ADD COLUMN "confirmations" INT NOT NULL DEFAULT 0;
+ALTER TABLE "transactions" ADD COLUMN "failure_reason" TEXT;
+
+CREATE INDEX "idx_transactions_status" ON "transactions" ("status");
+
+-- One row per status transition
+CREATE TABLE "transaction_events" (
+  "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "transaction_id" UUID NOT NULL REFERENCES "transactions"("id") ON DELETE CASCADE,
+  "status" VARCHAR(20) NOT NULL,
+  "reason" TEXT,
+  "created_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX "idx_transaction_events_transaction_id" ON "transaction_events" ("transaction_id", "created_at");
+
+-- Blocks on top of the one including a transaction before it is confirmed
+ALTER TABLE "chains" ADD COLUMN "confirmations" INT NOT NULL DEFAULT 12;
+UPDATE "chains" SET "confirmations" = 3 WHERE "chain_id" = 11155111;
+
+-- +goose Down
+ALTER TABLE "chains" DROP COLUMN "confirmations";
+
+DROP TABLE IF EXISTS "transaction_events";
+
+DROP INDEX IF EXISTS "idx_transactions_status";
+ALTER TABLE "transactions" DROP COLUMN "failure_reason";
+ALTER TABLE "transactions" DROP COLUMN "confirmations";
+ALTER TABLE "transactions" DROP COLUMN "block_hash";
+ALTER TABLE "transactions" DROP COLUMN "block_number";
+ALTER TABLE "transactions" DROP COLUMN "effective_gas_price";
+ALTER TABLE "transactions" DROP COLUMN "gas_used";
+ALTER TABLE "transactions" DROP COLUMN "gas_limit";
+ALTER TABLE "transactions" DROP COLUMN "nonce";
+ALTER TABLE "transactions" DROP COLUMN "amount";
+ALTER TABLE "transactions" DROP COLUMN "symbol";
+ALTER TABLE "transactions" ALTER COLUMN "tx_hash" DROP DEFAULT;
+ALTER TABLE "transactions" DROP COLUMN "status";
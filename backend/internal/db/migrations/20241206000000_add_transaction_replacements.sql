@@ -0,0 +1,14 @@
+-- +goose Up
+-- Fees of the broadcast transaction, a replacement must pay more
+ALTER TABLE "transactions" ADD COLUMN "max_fee_per_gas" VARCHAR(78);
+ALTER TABLE "transactions" ADD COLUMN "max_priority_fee_per_gas" VARCHAR(78);
+
+-- Speed-up and cancel reuse the nonce of a stuck transaction
+ALTER TABLE "transactions" ADD COLUMN "replaces_id" UUID REFERENCES "transactions"("id");
+ALTER TABLE "transactions" ADD COLUMN "replaced_by_id" UUID REFERENCES "transactions"("id");
+
+-- +goose Down
+ALTER TABLE "transactions" DROP COLUMN "replaced_by_id";
+ALTER TABLE "transactions" DROP COLUMN "replaces_id";
+ALTER TABLE "transactions" DROP COLUMN "max_priority_fee_per_gas";
+ALTER TABLE "transactions" DROP COLUMN "max_fee_per_gas";
@@ -0,0 +1,6 @@
+-- +goose Up
+-- RPC endpoints used in order when rpc_url is unhealthy
+ALTER TABLE "chains" ADD COLUMN "fallback_rpc_urls" TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE "chains" DROP COLUMN "fallback_rpc_urls";
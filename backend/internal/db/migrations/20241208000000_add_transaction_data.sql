@@ -0,0 +1,6 @@
+-- +goose Up
+-- Calldata of contract calls, replayed when the transaction is sped up
+ALTER TABLE "transactions" ADD COLUMN "data" TEXT;
+
+-- +goose Down
+ALTER TABLE "transactions" DROP COLUMN "data";
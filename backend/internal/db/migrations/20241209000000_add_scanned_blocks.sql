@@ -0,0 +1,22 @@
+-- +goose Up
+-- Blocks the worker scanned, the latest one of a chain is where the next scan
+-- starts. Hashes of recent blocks are kept to detect reorgs.
+CREATE TABLE "scanned_blocks" (
+  "chain_id" INT NOT NULL,
+  "block_number" BIGINT NOT NULL,
+  "block_hash" VARCHAR(66) NOT NULL,
+  "parent_hash" VARCHAR(66) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("chain_id", "block_number")
+);
+
+-- Transactions found by the scanner are removed when their block is reorged out
+ALTER TABLE "transactions" ADD COLUMN "source" VARCHAR(20) NOT NULL DEFAULT 'api';
+
+CREATE INDEX "idx_transactions_chain_block" ON "transactions" ("chain_id", "block_number");
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_transactions_chain_block";
+ALTER TABLE "transactions" DROP COLUMN "source";
+
+DROP TABLE IF EXISTS "scanned_blocks";
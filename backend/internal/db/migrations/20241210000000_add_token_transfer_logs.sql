@@ -0,0 +1,12 @@
+-- +goose Up
+-- ERC-20 transfers found in Transfer logs: the token contract, the log within
+-- the transaction and the amount in base units, amount being normalized by
+-- the decimals of the token
+ALTER TABLE "transactions" ADD COLUMN "contract_address" VARCHAR(42);
+ALTER TABLE "transactions" ADD COLUMN "log_index" INT;
+ALTER TABLE "transactions" ADD COLUMN "raw_amount" VARCHAR(78);
+
+-- +goose Down
+ALTER TABLE "transactions" DROP COLUMN "raw_amount";
+ALTER TABLE "transactions" DROP COLUMN "log_index";
+ALTER TABLE "transactions" DROP COLUMN "contract_address";
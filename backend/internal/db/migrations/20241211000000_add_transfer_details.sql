@@ -0,0 +1,33 @@
+-- +goose Up
+-- Transfer details: direction relative to the monitored wallet, fee paid in
+-- wei and time of the block including the transaction
+ALTER TABLE "transactions" ADD COLUMN "direction" VARCHAR(10);
+ALTER TABLE "transactions" ADD COLUMN "fee" VARCHAR(78);
+ALTER TABLE "transactions" ADD COLUMN "block_time" TIMESTAMP;
+
+UPDATE "transactions" SET "direction" = 'out' WHERE "source" = 'api';
+
+-- A transfer is recorded once, scanned again it is skipped. Transactions not
+-- signed yet have no hash.
+--
+-- This DELETES DATA: of the rows recording the same transfer only one is
+-- kept, the row created through the API in preference to the rows of the
+-- scanner, then the oldest. The deleted rows are not restored by the Down
+-- migration.
+DELETE FROM "transactions" WHERE "id" IN (
+  SELECT "id" FROM (
+    SELECT "id", ROW_NUMBER() OVER (
+      PARTITION BY "chain_id", "tx_hash", COALESCE("log_index", -1)
+      ORDER BY CASE WHEN "source" = 'api' THEN 0 ELSE 1 END, "created_at", "id"
+    ) AS "n"
+    FROM "transactions" WHERE "tx_hash" <> ''
+  ) AS "duplicates" WHERE "n" > 1
+);
+CREATE UNIQUE INDEX "idx_transactions_chain_hash_log" ON "transactions" ("chain_id", "tx_hash", COALESCE("log_index", -1)) WHERE "tx_hash" <> '';
+
+-- +goose Down
+-- The duplicate transfers deleted by Up are lost
+DROP INDEX IF EXISTS "idx_transactions_chain_hash_log";
+ALTER TABLE "transactions" DROP COLUMN "block_time";
+ALTER TABLE "transactions" DROP COLUMN "fee";
+ALTER TABLE "transactions" DROP COLUMN "direction";
@@ -0,0 +1,42 @@
+-- +goose Up
+-- Webhooks of organizations, events is the list of subscribed event types
+CREATE TABLE "webhooks" (
+  "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "organization_id" UUID NOT NULL REFERENCES "organizations"("id"),
+  "name" VARCHAR(255) NOT NULL,
+  "url" TEXT NOT NULL,
+  "events" JSONB NOT NULL DEFAULT '[]',
+  "secret" VARCHAR(255) NOT NULL,
+  "status" VARCHAR(20) NOT NULL DEFAULT 'active',
+  "created_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- Delivery queue: an event is pending until a webhook accepts it, and dead
+-- once it ran out of attempts
+CREATE TABLE "webhook_events" (
+  "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "webhook_id" UUID NOT NULL REFERENCES "webhooks"("id") ON DELETE CASCADE,
+  "event" VARCHAR(50) NOT NULL,
+  "data" JSONB NOT NULL,
+  "status" VARCHAR(20) NOT NULL DEFAULT 'pending',
+  "attempts" INT NOT NULL DEFAULT 0,
+  "next_attempt_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "last_error" TEXT,
+  "response_status" INT,
+  "delivered_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE INDEX idx_webhooks_organization_id ON "webhooks"("organization_id");
+CREATE INDEX idx_webhook_events_webhook_id ON "webhook_events"("webhook_id", "created_at");
+CREATE INDEX idx_webhook_events_due ON "webhook_events"("next_attempt_at") WHERE "status" = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_events_due;
+DROP INDEX IF EXISTS idx_webhook_events_webhook_id;
+DROP INDEX IF EXISTS idx_webhooks_organization_id;
+
+DROP TABLE IF EXISTS "webhook_events";
+DROP TABLE IF EXISTS "webhooks";
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Backfills of the history of an address over a block range. next_block is
+-- where an interrupted backfill resumes, the blocks before it are saved.
+CREATE TABLE "backfill_jobs" (
+  "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "chain_id" INT NOT NULL,
+  "address" VARCHAR(42) NOT NULL,
+  "from_block" BIGINT NOT NULL,
+  "to_block" BIGINT NOT NULL,
+  "next_block" BIGINT NOT NULL,
+  "status" VARCHAR(20) NOT NULL DEFAULT 'running',
+  "transactions_found" INT NOT NULL DEFAULT 0,
+  "error" TEXT,
+  "created_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE UNIQUE INDEX "idx_backfill_jobs_range" ON "backfill_jobs" ("chain_id", "address", "from_block", "to_block");
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_backfill_jobs_range";
+
+DROP TABLE IF EXISTS "backfill_jobs";
@@ -0,0 +1,7 @@
+-- +goose Up
+-- The address index of the workers reads the wallets created since its last
+-- reconciliation
+CREATE INDEX "idx_wallets_created_at" ON "wallets" ("created_at", "id");
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_wallets_created_at";
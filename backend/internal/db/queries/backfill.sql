@@ -0,0 +1,24 @@
+-- name: StartBackfillJob :one
+INSERT INTO backfill_jobs (chain_id, address, from_block, to_block, next_block, status, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $3, 'running', $5, $5)
+ON CONFLICT (chain_id, address, from_block, to_block) DO UPDATE SET
+    status = CASE WHEN backfill_jobs.status = 'completed' THEN 'completed' ELSE 'running' END,
+    error = NULL,
+    updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: UpdateBackfillProgress :exec
+UPDATE backfill_jobs
+SET next_block = $2, transactions_found = transactions_found + $3, updated_at = $4
+WHERE id = $1;
+
+-- name: UpdateBackfillStatus :exec
+UPDATE backfill_jobs
+SET status = $2, error = $3, updated_at = $4
+WHERE id = $1;
+
+-- name: GetUnfinishedBackfillJob :one
+SELECT * FROM backfill_jobs
+WHERE chain_id = $1 AND address = $2 AND from_block = $3 AND status <> 'completed'
+ORDER BY created_at DESC
+LIMIT 1;
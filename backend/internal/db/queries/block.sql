@@ -0,0 +1,29 @@
+-- name: GetLastScannedBlock :one
+SELECT * FROM scanned_blocks
+WHERE chain_id = $1
+ORDER BY block_number DESC
+LIMIT 1;
+
+-- name: GetScannedBlocks :many
+SELECT * FROM scanned_blocks
+WHERE chain_id = $1
+ORDER BY block_number DESC
+LIMIT $2;
+
+-- name: CreateScannedBlock :exec
+INSERT INTO scanned_blocks (chain_id, block_number, block_hash, parent_hash, created_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (chain_id, block_number) DO UPDATE SET
+    block_hash = EXCLUDED.block_hash,
+    parent_hash = EXCLUDED.parent_hash,
+    created_at = EXCLUDED.created_at;
+
+-- name: DeleteScannedBlocksAfter :exec
+DELETE FROM scanned_blocks WHERE chain_id = $1 AND block_number > $2;
+
+-- name: DeleteScannedBlocksBefore :exec
+DELETE FROM scanned_blocks WHERE chain_id = $1 AND block_number < $2;
+
+-- name: DeleteScannedTransactionsAfter :execrows
+DELETE FROM transactions
+WHERE chain_id = $1 AND source = 'scanner' AND block_number > $2;
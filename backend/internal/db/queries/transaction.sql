@@ -1,6 +1,11 @@
 -- name: CreateTransaction :one
-INSERT INTO transactions (chain_id, from_address, to_address, tx_hash, created_at, updated_at) 
-VALUES ($1, $2, $3, $4, $5, $6) 
+INSERT INTO transactions (
+    chain_id, from_address, to_address, tx_hash, status, symbol, amount, nonce, replaces_id, data, source,
+    block_number, block_hash, contract_address, log_index, raw_amount, direction,
+    gas_limit, gas_used, effective_gas_price, fee, block_time, failure_reason, created_at, updated_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
+ON CONFLICT DO NOTHING
 RETURNING *;
 
 -- name: GetTransactionsByWalletAddress :many
@@ -18,4 +23,42 @@ SELECT * FROM transactions WHERE id = $1;
 SELECT COUNT(*) 
 FROM transactions 
 WHERE (from_address = $1 OR to_address = $1)
-  AND ($2::int IS NULL OR chain_id = $2);
\ No newline at end of file
+  AND ($2::int IS NULL OR chain_id = $2);
+
+-- name: GetTransactionsByStatus :many
+SELECT * FROM transactions
+WHERE chain_id = @chain_id AND status = ANY(@statuses::text[])
+ORDER BY updated_at
+LIMIT @max_results;
+
+-- name: UpdateTransactionStatus :one
+UPDATE transactions SET
+    status = @status,
+    tx_hash = COALESCE(sqlc.narg('tx_hash'), tx_hash),
+    nonce = COALESCE(sqlc.narg('nonce'), nonce),
+    gas_limit = COALESCE(sqlc.narg('gas_limit'), gas_limit),
+    max_fee_per_gas = COALESCE(sqlc.narg('max_fee_per_gas'), max_fee_per_gas),
+    max_priority_fee_per_gas = COALESCE(sqlc.narg('max_priority_fee_per_gas'), max_priority_fee_per_gas),
+    gas_used = CASE WHEN @clear_receipt::bool THEN NULL ELSE COALESCE(sqlc.narg('gas_used'), gas_used) END,
+    effective_gas_price = CASE WHEN @clear_receipt::bool THEN NULL ELSE COALESCE(sqlc.narg('effective_gas_price'), effective_gas_price) END,
+    fee = CASE WHEN @clear_receipt::bool THEN NULL ELSE COALESCE(sqlc.narg('fee'), fee) END,
+    block_number = CASE WHEN @clear_receipt::bool THEN NULL ELSE COALESCE(sqlc.narg('block_number'), block_number) END,
+    block_hash = CASE WHEN @clear_receipt::bool THEN NULL ELSE COALESCE(sqlc.narg('block_hash'), block_hash) END,
+    confirmations = COALESCE(sqlc.narg('confirmations'), confirmations),
+    failure_reason = COALESCE(sqlc.narg('failure_reason'), failure_reason),
+    updated_at = @updated_at
+WHERE id = @id AND status = @from_status
+RETURNING *;
+
+-- name: SetTransactionReplacedBy :exec
+UPDATE transactions SET replaced_by_id = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: CreateTransactionEvent :exec
+INSERT INTO transaction_events (transaction_id, status, reason, created_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetTransactionEvents :many
+SELECT * FROM transaction_events
+WHERE transaction_id = $1
+ORDER BY created_at;
@@ -5,10 +5,17 @@ INSERT INTO wallets (
     encrypted_private_key,
     name,
     status,
+    key_id,
+    parties,
+    threshold,
+    organization_id,
     created_at,
     updated_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8,
+    -- wallets belong to the organization of their user
+    (SELECT organization_id FROM users WHERE id = $1),
+    $9, $10
 ) RETURNING *;
 
 -- name: GetWalletsByUserID :many
@@ -32,5 +39,13 @@ UPDATE wallets SET
     updated_at = $6
 WHERE id = $1 RETURNING *;
 
--- name: GetAllAddresses :many
-SELECT address FROM wallets;
\ No newline at end of file
+-- name: GetWalletAddresses :many
+SELECT id, address, organization_id FROM wallets
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+-- name: GetWalletAddressesCreatedSince :many
+SELECT id, address, organization_id, created_at FROM wallets
+WHERE (created_at, id) > (@after_created_at::timestamp, @after_id::uuid)
+ORDER BY created_at, id
+LIMIT @max_results;
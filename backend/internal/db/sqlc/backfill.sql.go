@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: backfill.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getUnfinishedBackfillJob = `-- name: GetUnfinishedBackfillJob :one
+SELECT id, chain_id, address, from_block, to_block, next_block, status, transactions_found, error, created_at, updated_at FROM backfill_jobs
+WHERE chain_id = $1 AND address = $2 AND from_block = $3 AND status <> 'completed'
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetUnfinishedBackfillJobParams struct {
+	ChainID   int32
+	Address   string
+	FromBlock int64
+}
+
+func (q *Queries) GetUnfinishedBackfillJob(ctx context.Context, arg GetUnfinishedBackfillJobParams) (BackfillJob, error) {
+	row := q.db.QueryRow(ctx, getUnfinishedBackfillJob, arg.ChainID, arg.Address, arg.FromBlock)
+	var i BackfillJob
+	err := row.Scan(
+		&i.ID,
+		&i.ChainID,
+		&i.Address,
+		&i.FromBlock,
+		&i.ToBlock,
+		&i.NextBlock,
+		&i.Status,
+		&i.TransactionsFound,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const startBackfillJob = `-- name: StartBackfillJob :one
+INSERT INTO backfill_jobs (chain_id, address, from_block, to_block, next_block, status, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $3, 'running', $5, $5)
+ON CONFLICT (chain_id, address, from_block, to_block) DO UPDATE SET
+    status = CASE WHEN backfill_jobs.status = 'completed' THEN 'completed' ELSE 'running' END,
+    error = NULL,
+    updated_at = EXCLUDED.updated_at
+RETURNING id, chain_id, address, from_block, to_block, next_block, status, transactions_found, error, created_at, updated_at
+`
+
+type StartBackfillJobParams struct {
+	ChainID   int32
+	Address   string
+	FromBlock int64
+	ToBlock   int64
+	CreatedAt pgtype.Timestamp
+}
+
+func (q *Queries) StartBackfillJob(ctx context.Context, arg StartBackfillJobParams) (BackfillJob, error) {
+	row := q.db.QueryRow(ctx, startBackfillJob,
+		arg.ChainID,
+		arg.Address,
+		arg.FromBlock,
+		arg.ToBlock,
+		arg.CreatedAt,
+	)
+	var i BackfillJob
+	err := row.Scan(
+		&i.ID,
+		&i.ChainID,
+		&i.Address,
+		&i.FromBlock,
+		&i.ToBlock,
+		&i.NextBlock,
+		&i.Status,
+		&i.TransactionsFound,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateBackfillProgress = `-- name: UpdateBackfillProgress :exec
+UPDATE backfill_jobs
+SET next_block = $2, transactions_found = transactions_found + $3, updated_at = $4
+WHERE id = $1
+`
+
+type UpdateBackfillProgressParams struct {
+	ID                pgtype.UUID
+	NextBlock         int64
+	TransactionsFound int32
+	UpdatedAt         pgtype.Timestamp
+}
+
+func (q *Queries) UpdateBackfillProgress(ctx context.Context, arg UpdateBackfillProgressParams) error {
+	_, err := q.db.Exec(ctx, updateBackfillProgress,
+		arg.ID,
+		arg.NextBlock,
+		arg.TransactionsFound,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const updateBackfillStatus = `-- name: UpdateBackfillStatus :exec
+UPDATE backfill_jobs
+SET status = $2, error = $3, updated_at = $4
+WHERE id = $1
+`
+
+type UpdateBackfillStatusParams struct {
+	ID        pgtype.UUID
+	Status    string
+	Error     pgtype.Text
+	UpdatedAt pgtype.Timestamp
+}
+
+func (q *Queries) UpdateBackfillStatus(ctx context.Context, arg UpdateBackfillStatusParams) error {
+	_, err := q.db.Exec(ctx, updateBackfillStatus,
+		arg.ID,
+		arg.Status,
+		arg.Error,
+		arg.UpdatedAt,
+	)
+	return err
+}
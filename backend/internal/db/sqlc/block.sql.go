@@ -0,0 +1,144 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: block.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createScannedBlock = `-- name: CreateScannedBlock :exec
+INSERT INTO scanned_blocks (chain_id, block_number, block_hash, parent_hash, created_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (chain_id, block_number) DO UPDATE SET
+    block_hash = EXCLUDED.block_hash,
+    parent_hash = EXCLUDED.parent_hash,
+    created_at = EXCLUDED.created_at
+`
+
+type CreateScannedBlockParams struct {
+	ChainID     int32
+	BlockNumber int64
+	BlockHash   string
+	ParentHash  string
+	CreatedAt   pgtype.Timestamp
+}
+
+func (q *Queries) CreateScannedBlock(ctx context.Context, arg CreateScannedBlockParams) error {
+	_, err := q.db.Exec(ctx, createScannedBlock,
+		arg.ChainID,
+		arg.BlockNumber,
+		arg.BlockHash,
+		arg.ParentHash,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const deleteScannedBlocksAfter = `-- name: DeleteScannedBlocksAfter :exec
+DELETE FROM scanned_blocks WHERE chain_id = $1 AND block_number > $2
+`
+
+type DeleteScannedBlocksAfterParams struct {
+	ChainID     int32
+	BlockNumber int64
+}
+
+func (q *Queries) DeleteScannedBlocksAfter(ctx context.Context, arg DeleteScannedBlocksAfterParams) error {
+	_, err := q.db.Exec(ctx, deleteScannedBlocksAfter, arg.ChainID, arg.BlockNumber)
+	return err
+}
+
+const deleteScannedBlocksBefore = `-- name: DeleteScannedBlocksBefore :exec
+DELETE FROM scanned_blocks WHERE chain_id = $1 AND block_number < $2
+`
+
+type DeleteScannedBlocksBeforeParams struct {
+	ChainID     int32
+	BlockNumber int64
+}
+
+func (q *Queries) DeleteScannedBlocksBefore(ctx context.Context, arg DeleteScannedBlocksBeforeParams) error {
+	_, err := q.db.Exec(ctx, deleteScannedBlocksBefore, arg.ChainID, arg.BlockNumber)
+	return err
+}
+
+const deleteScannedTransactionsAfter = `-- name: DeleteScannedTransactionsAfter :execrows
+DELETE FROM transactions
+WHERE chain_id = $1 AND source = 'scanner' AND block_number > $2
+`
+
+type DeleteScannedTransactionsAfterParams struct {
+	ChainID     int32
+	BlockNumber pgtype.Int8
+}
+
+func (q *Queries) DeleteScannedTransactionsAfter(ctx context.Context, arg DeleteScannedTransactionsAfterParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteScannedTransactionsAfter, arg.ChainID, arg.BlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getLastScannedBlock = `-- name: GetLastScannedBlock :one
+SELECT chain_id, block_number, block_hash, parent_hash, created_at FROM scanned_blocks
+WHERE chain_id = $1
+ORDER BY block_number DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastScannedBlock(ctx context.Context, chainID int32) (ScannedBlock, error) {
+	row := q.db.QueryRow(ctx, getLastScannedBlock, chainID)
+	var i ScannedBlock
+	err := row.Scan(
+		&i.ChainID,
+		&i.BlockNumber,
+		&i.BlockHash,
+		&i.ParentHash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getScannedBlocks = `-- name: GetScannedBlocks :many
+SELECT chain_id, block_number, block_hash, parent_hash, created_at FROM scanned_blocks
+WHERE chain_id = $1
+ORDER BY block_number DESC
+LIMIT $2
+`
+
+type GetScannedBlocksParams struct {
+	ChainID int32
+	Limit   int32
+}
+
+func (q *Queries) GetScannedBlocks(ctx context.Context, arg GetScannedBlocksParams) ([]ScannedBlock, error) {
+	rows, err := q.db.Query(ctx, getScannedBlocks, arg.ChainID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScannedBlock
+	for rows.Next() {
+		var i ScannedBlock
+		if err := rows.Scan(
+			&i.ChainID,
+			&i.BlockNumber,
+			&i.BlockHash,
+			&i.ParentHash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -12,7 +12,7 @@ import (
 )
 
 const getChainByChainID = `-- name: GetChainByChainID :one
-SELECT id, name, chain_id, rpc_url, native_currency, explorer_url, status, created_at, updated_at FROM chains WHERE chain_id = $1
+SELECT id, name, chain_id, rpc_url, native_currency, explorer_url, status, created_at, updated_at, confirmations, fallback_rpc_urls FROM chains WHERE chain_id = $1
 `
 
 func (q *Queries) GetChainByChainID(ctx context.Context, chainID int32) (Chain, error) {
@@ -28,12 +28,14 @@ func (q *Queries) GetChainByChainID(ctx context.Context, chainID int32) (Chain,
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Confirmations,
+		&i.FallbackRpcUrls,
 	)
 	return i, err
 }
 
 const getChainByID = `-- name: GetChainByID :one
-SELECT id, name, chain_id, rpc_url, native_currency, explorer_url, status, created_at, updated_at FROM chains WHERE id = $1
+SELECT id, name, chain_id, rpc_url, native_currency, explorer_url, status, created_at, updated_at, confirmations, fallback_rpc_urls FROM chains WHERE id = $1
 `
 
 func (q *Queries) GetChainByID(ctx context.Context, id pgtype.UUID) (Chain, error) {
@@ -49,12 +51,14 @@ func (q *Queries) GetChainByID(ctx context.Context, id pgtype.UUID) (Chain, erro
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Confirmations,
+		&i.FallbackRpcUrls,
 	)
 	return i, err
 }
 
 const getChains = `-- name: GetChains :many
-SELECT id, name, chain_id, rpc_url, native_currency, explorer_url, status, created_at, updated_at FROM chains
+SELECT id, name, chain_id, rpc_url, native_currency, explorer_url, status, created_at, updated_at, confirmations, fallback_rpc_urls FROM chains
 `
 
 func (q *Queries) GetChains(ctx context.Context) ([]Chain, error) {
@@ -76,6 +80,8 @@ func (q *Queries) GetChains(ctx context.Context) ([]Chain, error) {
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Confirmations,
+			&i.FallbackRpcUrls,
 		); err != nil {
 			return nil, err
 		}
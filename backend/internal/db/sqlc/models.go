@@ -8,16 +8,40 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BackfillJob struct {
+	ID                pgtype.UUID
+	ChainID           int32
+	Address           string
+	FromBlock         int64
+	ToBlock           int64
+	NextBlock         int64
+	Status            string
+	TransactionsFound int32
+	Error             pgtype.Text
+	CreatedAt         pgtype.Timestamp
+	UpdatedAt         pgtype.Timestamp
+}
+
 type Chain struct {
-	ID             pgtype.UUID
-	Name           string
-	ChainID        int32
-	RpcUrl         string
-	NativeCurrency string
-	ExplorerUrl    pgtype.Text
-	Status         string
-	CreatedAt      pgtype.Timestamp
-	UpdatedAt      pgtype.Timestamp
+	ID              pgtype.UUID
+	Name            string
+	ChainID         int32
+	RpcUrl          string
+	NativeCurrency  string
+	ExplorerUrl     pgtype.Text
+	Status          string
+	CreatedAt       pgtype.Timestamp
+	UpdatedAt       pgtype.Timestamp
+	Confirmations   int32
+	FallbackRpcUrls []string
+}
+
+type ScannedBlock struct {
+	ChainID     int32
+	BlockNumber int64
+	BlockHash   string
+	ParentHash  string
+	CreatedAt   pgtype.Timestamp
 }
 
 type Token struct {
@@ -35,13 +59,45 @@ type Token struct {
 }
 
 type Transaction struct {
-	ID          pgtype.UUID
-	ChainID     int32
-	FromAddress string
-	ToAddress   string
-	TxHash      string
-	CreatedAt   pgtype.Timestamp
-	UpdatedAt   pgtype.Timestamp
+	ID                   pgtype.UUID
+	ChainID              int32
+	FromAddress          string
+	ToAddress            string
+	TxHash               string
+	CreatedAt            pgtype.Timestamp
+	UpdatedAt            pgtype.Timestamp
+	OrganizationID       pgtype.UUID
+	Status               string
+	Symbol               string
+	Amount               string
+	Nonce                pgtype.Int8
+	GasLimit             pgtype.Int8
+	GasUsed              pgtype.Int8
+	EffectiveGasPrice    pgtype.Text
+	BlockNumber          pgtype.Int8
+	BlockHash            pgtype.Text
+	Confirmations        int32
+	FailureReason        pgtype.Text
+	MaxFeePerGas         pgtype.Text
+	MaxPriorityFeePerGas pgtype.Text
+	ReplacesID           pgtype.UUID
+	ReplacedByID         pgtype.UUID
+	Data                 pgtype.Text
+	Source               string
+	ContractAddress      pgtype.Text
+	LogIndex             pgtype.Int4
+	RawAmount            pgtype.Text
+	Direction            pgtype.Text
+	Fee                  pgtype.Text
+	BlockTime            pgtype.Timestamp
+}
+
+type TransactionEvent struct {
+	ID            pgtype.UUID
+	TransactionID pgtype.UUID
+	Status        string
+	Reason        pgtype.Text
+	CreatedAt     pgtype.Timestamp
 }
 
 type User struct {
@@ -62,4 +118,8 @@ type Wallet struct {
 	Status              string
 	CreatedAt           pgtype.Timestamp
 	UpdatedAt           pgtype.Timestamp
+	OrganizationID      pgtype.UUID
+	KeyID               string
+	Parties             []int32
+	Threshold           int32
 }
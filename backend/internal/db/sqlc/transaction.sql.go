@@ -12,18 +12,42 @@ import (
 )
 
 const createTransaction = `-- name: CreateTransaction :one
-INSERT INTO transactions (chain_id, from_address, to_address, tx_hash, created_at, updated_at) 
-VALUES ($1, $2, $3, $4, $5, $6) 
-RETURNING id, chain_id, from_address, to_address, tx_hash, created_at, updated_at
+INSERT INTO transactions (
+    chain_id, from_address, to_address, tx_hash, status, symbol, amount, nonce, replaces_id, data, source,
+    block_number, block_hash, contract_address, log_index, raw_amount, direction,
+    gas_limit, gas_used, effective_gas_price, fee, block_time, failure_reason, created_at, updated_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
+ON CONFLICT DO NOTHING
+RETURNING id, chain_id, from_address, to_address, tx_hash, created_at, updated_at, organization_id, status, symbol, amount, nonce, gas_limit, gas_used, effective_gas_price, block_number, block_hash, confirmations, failure_reason, max_fee_per_gas, max_priority_fee_per_gas, replaces_id, replaced_by_id, data, source, contract_address, log_index, raw_amount, direction, fee, block_time
 `
 
 type CreateTransactionParams struct {
-	ChainID     int32
-	FromAddress string
-	ToAddress   string
-	TxHash      string
-	CreatedAt   pgtype.Timestamp
-	UpdatedAt   pgtype.Timestamp
+	ChainID           int32
+	FromAddress       string
+	ToAddress         string
+	TxHash            string
+	Status            string
+	Symbol            string
+	Amount            string
+	Nonce             pgtype.Int8
+	ReplacesID        pgtype.UUID
+	Data              pgtype.Text
+	Source            string
+	BlockNumber       pgtype.Int8
+	BlockHash         pgtype.Text
+	ContractAddress   pgtype.Text
+	LogIndex          pgtype.Int4
+	RawAmount         pgtype.Text
+	Direction         pgtype.Text
+	GasLimit          pgtype.Int8
+	GasUsed           pgtype.Int8
+	EffectiveGasPrice pgtype.Text
+	Fee               pgtype.Text
+	BlockTime         pgtype.Timestamp
+	FailureReason     pgtype.Text
+	CreatedAt         pgtype.Timestamp
+	UpdatedAt         pgtype.Timestamp
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
@@ -32,6 +56,25 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		arg.FromAddress,
 		arg.ToAddress,
 		arg.TxHash,
+		arg.Status,
+		arg.Symbol,
+		arg.Amount,
+		arg.Nonce,
+		arg.ReplacesID,
+		arg.Data,
+		arg.Source,
+		arg.BlockNumber,
+		arg.BlockHash,
+		arg.ContractAddress,
+		arg.LogIndex,
+		arg.RawAmount,
+		arg.Direction,
+		arg.GasLimit,
+		arg.GasUsed,
+		arg.EffectiveGasPrice,
+		arg.Fee,
+		arg.BlockTime,
+		arg.FailureReason,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -44,12 +87,58 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.TxHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.Status,
+		&i.Symbol,
+		&i.Amount,
+		&i.Nonce,
+		&i.GasLimit,
+		&i.GasUsed,
+		&i.EffectiveGasPrice,
+		&i.BlockNumber,
+		&i.BlockHash,
+		&i.Confirmations,
+		&i.FailureReason,
+		&i.MaxFeePerGas,
+		&i.MaxPriorityFeePerGas,
+		&i.ReplacesID,
+		&i.ReplacedByID,
+		&i.Data,
+		&i.Source,
+		&i.ContractAddress,
+		&i.LogIndex,
+		&i.RawAmount,
+		&i.Direction,
+		&i.Fee,
+		&i.BlockTime,
 	)
 	return i, err
 }
 
+const createTransactionEvent = `-- name: CreateTransactionEvent :exec
+INSERT INTO transaction_events (transaction_id, status, reason, created_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateTransactionEventParams struct {
+	TransactionID pgtype.UUID
+	Status        string
+	Reason        pgtype.Text
+	CreatedAt     pgtype.Timestamp
+}
+
+func (q *Queries) CreateTransactionEvent(ctx context.Context, arg CreateTransactionEventParams) error {
+	_, err := q.db.Exec(ctx, createTransactionEvent,
+		arg.TransactionID,
+		arg.Status,
+		arg.Reason,
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const getTransactionByID = `-- name: GetTransactionByID :one
-SELECT id, chain_id, from_address, to_address, tx_hash, created_at, updated_at FROM transactions WHERE id = $1
+SELECT id, chain_id, from_address, to_address, tx_hash, created_at, updated_at, organization_id, status, symbol, amount, nonce, gas_limit, gas_used, effective_gas_price, block_number, block_hash, confirmations, failure_reason, max_fee_per_gas, max_priority_fee_per_gas, replaces_id, replaced_by_id, data, source, contract_address, log_index, raw_amount, direction, fee, block_time FROM transactions WHERE id = $1
 `
 
 func (q *Queries) GetTransactionByID(ctx context.Context, id pgtype.UUID) (Transaction, error) {
@@ -63,6 +152,30 @@ func (q *Queries) GetTransactionByID(ctx context.Context, id pgtype.UUID) (Trans
 		&i.TxHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.Status,
+		&i.Symbol,
+		&i.Amount,
+		&i.Nonce,
+		&i.GasLimit,
+		&i.GasUsed,
+		&i.EffectiveGasPrice,
+		&i.BlockNumber,
+		&i.BlockHash,
+		&i.Confirmations,
+		&i.FailureReason,
+		&i.MaxFeePerGas,
+		&i.MaxPriorityFeePerGas,
+		&i.ReplacesID,
+		&i.ReplacedByID,
+		&i.Data,
+		&i.Source,
+		&i.ContractAddress,
+		&i.LogIndex,
+		&i.RawAmount,
+		&i.Direction,
+		&i.Fee,
+		&i.BlockTime,
 	)
 	return i, err
 }
@@ -86,8 +199,105 @@ func (q *Queries) GetTransactionCount(ctx context.Context, arg GetTransactionCou
 	return count, err
 }
 
+const getTransactionEvents = `-- name: GetTransactionEvents :many
+SELECT id, transaction_id, status, reason, created_at FROM transaction_events
+WHERE transaction_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) GetTransactionEvents(ctx context.Context, transactionID pgtype.UUID) ([]TransactionEvent, error) {
+	rows, err := q.db.Query(ctx, getTransactionEvents, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TransactionEvent
+	for rows.Next() {
+		var i TransactionEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.Status,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionsByStatus = `-- name: GetTransactionsByStatus :many
+SELECT id, chain_id, from_address, to_address, tx_hash, created_at, updated_at, organization_id, status, symbol, amount, nonce, gas_limit, gas_used, effective_gas_price, block_number, block_hash, confirmations, failure_reason, max_fee_per_gas, max_priority_fee_per_gas, replaces_id, replaced_by_id, data, source, contract_address, log_index, raw_amount, direction, fee, block_time FROM transactions
+WHERE chain_id = $1 AND status = ANY($2::text[])
+ORDER BY updated_at
+LIMIT $3
+`
+
+type GetTransactionsByStatusParams struct {
+	ChainID    int32
+	Statuses   []string
+	MaxResults int32
+}
+
+func (q *Queries) GetTransactionsByStatus(ctx context.Context, arg GetTransactionsByStatusParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, getTransactionsByStatus, arg.ChainID, arg.Statuses, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChainID,
+			&i.FromAddress,
+			&i.ToAddress,
+			&i.TxHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.OrganizationID,
+			&i.Status,
+			&i.Symbol,
+			&i.Amount,
+			&i.Nonce,
+			&i.GasLimit,
+			&i.GasUsed,
+			&i.EffectiveGasPrice,
+			&i.BlockNumber,
+			&i.BlockHash,
+			&i.Confirmations,
+			&i.FailureReason,
+			&i.MaxFeePerGas,
+			&i.MaxPriorityFeePerGas,
+			&i.ReplacesID,
+			&i.ReplacedByID,
+			&i.Data,
+			&i.Source,
+			&i.ContractAddress,
+			&i.LogIndex,
+			&i.RawAmount,
+			&i.Direction,
+			&i.Fee,
+			&i.BlockTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTransactionsByWalletAddress = `-- name: GetTransactionsByWalletAddress :many
-SELECT id, chain_id, from_address, to_address, tx_hash, created_at, updated_at FROM transactions 
+SELECT id, chain_id, from_address, to_address, tx_hash, created_at, updated_at, organization_id, status, symbol, amount, nonce, gas_limit, gas_used, effective_gas_price, block_number, block_hash, confirmations, failure_reason, max_fee_per_gas, max_priority_fee_per_gas, replaces_id, replaced_by_id, data, source, contract_address, log_index, raw_amount, direction, fee, block_time FROM transactions 
 WHERE (from_address = $1 OR to_address = $1) 
 AND ($2::int IS NULL OR chain_id = $2)
 ORDER BY created_at DESC
@@ -124,6 +334,30 @@ func (q *Queries) GetTransactionsByWalletAddress(ctx context.Context, arg GetTra
 			&i.TxHash,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizationID,
+			&i.Status,
+			&i.Symbol,
+			&i.Amount,
+			&i.Nonce,
+			&i.GasLimit,
+			&i.GasUsed,
+			&i.EffectiveGasPrice,
+			&i.BlockNumber,
+			&i.BlockHash,
+			&i.Confirmations,
+			&i.FailureReason,
+			&i.MaxFeePerGas,
+			&i.MaxPriorityFeePerGas,
+			&i.ReplacesID,
+			&i.ReplacedByID,
+			&i.Data,
+			&i.Source,
+			&i.ContractAddress,
+			&i.LogIndex,
+			&i.RawAmount,
+			&i.Direction,
+			&i.Fee,
+			&i.BlockTime,
 		); err != nil {
 			return nil, err
 		}
@@ -134,3 +368,116 @@ func (q *Queries) GetTransactionsByWalletAddress(ctx context.Context, arg GetTra
 	}
 	return items, nil
 }
+
+const setTransactionReplacedBy = `-- name: SetTransactionReplacedBy :exec
+UPDATE transactions SET replaced_by_id = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetTransactionReplacedByParams struct {
+	ID           pgtype.UUID
+	ReplacedByID pgtype.UUID
+	UpdatedAt    pgtype.Timestamp
+}
+
+func (q *Queries) SetTransactionReplacedBy(ctx context.Context, arg SetTransactionReplacedByParams) error {
+	_, err := q.db.Exec(ctx, setTransactionReplacedBy, arg.ID, arg.ReplacedByID, arg.UpdatedAt)
+	return err
+}
+
+const updateTransactionStatus = `-- name: UpdateTransactionStatus :one
+UPDATE transactions SET
+    status = $1,
+    tx_hash = COALESCE($2, tx_hash),
+    nonce = COALESCE($3, nonce),
+    gas_limit = COALESCE($4, gas_limit),
+    max_fee_per_gas = COALESCE($5, max_fee_per_gas),
+    max_priority_fee_per_gas = COALESCE($6, max_priority_fee_per_gas),
+    gas_used = CASE WHEN $7::bool THEN NULL ELSE COALESCE($8, gas_used) END,
+    effective_gas_price = CASE WHEN $7::bool THEN NULL ELSE COALESCE($9, effective_gas_price) END,
+    fee = CASE WHEN $7::bool THEN NULL ELSE COALESCE($10, fee) END,
+    block_number = CASE WHEN $7::bool THEN NULL ELSE COALESCE($11, block_number) END,
+    block_hash = CASE WHEN $7::bool THEN NULL ELSE COALESCE($12, block_hash) END,
+    confirmations = COALESCE($13, confirmations),
+    failure_reason = COALESCE($14, failure_reason),
+    updated_at = $15
+WHERE id = $16 AND status = $17
+RETURNING id, chain_id, from_address, to_address, tx_hash, created_at, updated_at, organization_id, status, symbol, amount, nonce, gas_limit, gas_used, effective_gas_price, block_number, block_hash, confirmations, failure_reason, max_fee_per_gas, max_priority_fee_per_gas, replaces_id, replaced_by_id, data, source, contract_address, log_index, raw_amount, direction, fee, block_time
+`
+
+type UpdateTransactionStatusParams struct {
+	Status               string
+	TxHash               pgtype.Text
+	Nonce                pgtype.Int8
+	GasLimit             pgtype.Int8
+	MaxFeePerGas         pgtype.Text
+	MaxPriorityFeePerGas pgtype.Text
+	ClearReceipt         bool
+	GasUsed              pgtype.Int8
+	EffectiveGasPrice    pgtype.Text
+	Fee                  pgtype.Text
+	BlockNumber          pgtype.Int8
+	BlockHash            pgtype.Text
+	Confirmations        pgtype.Int4
+	FailureReason        pgtype.Text
+	UpdatedAt            pgtype.Timestamp
+	ID                   pgtype.UUID
+	FromStatus           string
+}
+
+func (q *Queries) UpdateTransactionStatus(ctx context.Context, arg UpdateTransactionStatusParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, updateTransactionStatus,
+		arg.Status,
+		arg.TxHash,
+		arg.Nonce,
+		arg.GasLimit,
+		arg.MaxFeePerGas,
+		arg.MaxPriorityFeePerGas,
+		arg.ClearReceipt,
+		arg.GasUsed,
+		arg.EffectiveGasPrice,
+		arg.Fee,
+		arg.BlockNumber,
+		arg.BlockHash,
+		arg.Confirmations,
+		arg.FailureReason,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.FromStatus,
+	)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.ChainID,
+		&i.FromAddress,
+		&i.ToAddress,
+		&i.TxHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.Status,
+		&i.Symbol,
+		&i.Amount,
+		&i.Nonce,
+		&i.GasLimit,
+		&i.GasUsed,
+		&i.EffectiveGasPrice,
+		&i.BlockNumber,
+		&i.BlockHash,
+		&i.Confirmations,
+		&i.FailureReason,
+		&i.MaxFeePerGas,
+		&i.MaxPriorityFeePerGas,
+		&i.ReplacesID,
+		&i.ReplacedByID,
+		&i.Data,
+		&i.Source,
+		&i.ContractAddress,
+		&i.LogIndex,
+		&i.RawAmount,
+		&i.Direction,
+		&i.Fee,
+		&i.BlockTime,
+	)
+	return i, err
+}
@@ -18,11 +18,18 @@ INSERT INTO wallets (
     encrypted_private_key,
     name,
     status,
+    key_id,
+    parties,
+    threshold,
+    organization_id,
     created_at,
     updated_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, user_id, address, encrypted_private_key, name, status, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, $8,
+    -- wallets belong to the organization of their user
+    (SELECT organization_id FROM users WHERE id = $1),
+    $9, $10
+) RETURNING id, user_id, address, encrypted_private_key, name, status, created_at, updated_at, organization_id, key_id, parties, threshold
 `
 
 type CreateWalletParams struct {
@@ -31,6 +38,9 @@ type CreateWalletParams struct {
 	EncryptedPrivateKey []byte
 	Name                pgtype.Text
 	Status              string
+	KeyID               string
+	Parties             []int32
+	Threshold           int32
 	CreatedAt           pgtype.Timestamp
 	UpdatedAt           pgtype.Timestamp
 }
@@ -42,6 +52,9 @@ func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wal
 		arg.EncryptedPrivateKey,
 		arg.Name,
 		arg.Status,
+		arg.KeyID,
+		arg.Parties,
+		arg.Threshold,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -55,27 +68,90 @@ func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wal
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.KeyID,
+		&i.Parties,
+		&i.Threshold,
 	)
 	return i, err
 }
 
-const getAllAddresses = `-- name: GetAllAddresses :many
-SELECT address FROM wallets
+const getWalletAddresses = `-- name: GetWalletAddresses :many
+SELECT id, address, organization_id FROM wallets
+WHERE id > $1
+ORDER BY id
+LIMIT $2
 `
 
-func (q *Queries) GetAllAddresses(ctx context.Context) ([]string, error) {
-	rows, err := q.db.Query(ctx, getAllAddresses)
+type GetWalletAddressesParams struct {
+	ID    pgtype.UUID
+	Limit int32
+}
+
+type GetWalletAddressesRow struct {
+	ID             pgtype.UUID
+	Address        string
+	OrganizationID pgtype.UUID
+}
+
+func (q *Queries) GetWalletAddresses(ctx context.Context, arg GetWalletAddressesParams) ([]GetWalletAddressesRow, error) {
+	rows, err := q.db.Query(ctx, getWalletAddresses, arg.ID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetWalletAddressesRow
 	for rows.Next() {
-		var address string
-		if err := rows.Scan(&address); err != nil {
+		var i GetWalletAddressesRow
+		if err := rows.Scan(&i.ID, &i.Address, &i.OrganizationID); err != nil {
 			return nil, err
 		}
-		items = append(items, address)
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWalletAddressesCreatedSince = `-- name: GetWalletAddressesCreatedSince :many
+SELECT id, address, organization_id, created_at FROM wallets
+WHERE (created_at, id) > ($1::timestamp, $2::uuid)
+ORDER BY created_at, id
+LIMIT $3
+`
+
+type GetWalletAddressesCreatedSinceParams struct {
+	AfterCreatedAt pgtype.Timestamp
+	AfterID        pgtype.UUID
+	MaxResults     int32
+}
+
+type GetWalletAddressesCreatedSinceRow struct {
+	ID             pgtype.UUID
+	Address        string
+	OrganizationID pgtype.UUID
+	CreatedAt      pgtype.Timestamp
+}
+
+func (q *Queries) GetWalletAddressesCreatedSince(ctx context.Context, arg GetWalletAddressesCreatedSinceParams) ([]GetWalletAddressesCreatedSinceRow, error) {
+	rows, err := q.db.Query(ctx, getWalletAddressesCreatedSince, arg.AfterCreatedAt, arg.AfterID, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWalletAddressesCreatedSinceRow
+	for rows.Next() {
+		var i GetWalletAddressesCreatedSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Address,
+			&i.OrganizationID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -84,7 +160,7 @@ func (q *Queries) GetAllAddresses(ctx context.Context) ([]string, error) {
 }
 
 const getWalletByAddress = `-- name: GetWalletByAddress :one
-SELECT id, user_id, address, encrypted_private_key, name, status, created_at, updated_at FROM wallets
+SELECT id, user_id, address, encrypted_private_key, name, status, created_at, updated_at, organization_id, key_id, parties, threshold FROM wallets
 WHERE address = $1 LIMIT 1
 `
 
@@ -100,12 +176,16 @@ func (q *Queries) GetWalletByAddress(ctx context.Context, address string) (Walle
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.KeyID,
+		&i.Parties,
+		&i.Threshold,
 	)
 	return i, err
 }
 
 const getWalletByID = `-- name: GetWalletByID :one
-SELECT id, user_id, address, encrypted_private_key, name, status, created_at, updated_at FROM wallets
+SELECT id, user_id, address, encrypted_private_key, name, status, created_at, updated_at, organization_id, key_id, parties, threshold FROM wallets
 WHERE id = $1 LIMIT 1
 `
 
@@ -121,12 +201,16 @@ func (q *Queries) GetWalletByID(ctx context.Context, id pgtype.UUID) (Wallet, er
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.KeyID,
+		&i.Parties,
+		&i.Threshold,
 	)
 	return i, err
 }
 
 const getWalletsByUserID = `-- name: GetWalletsByUserID :many
-SELECT id, user_id, address, encrypted_private_key, name, status, created_at, updated_at FROM wallets
+SELECT id, user_id, address, encrypted_private_key, name, status, created_at, updated_at, organization_id, key_id, parties, threshold FROM wallets
 WHERE user_id = $1
 `
 
@@ -148,6 +232,10 @@ func (q *Queries) GetWalletsByUserID(ctx context.Context, userID pgtype.UUID) ([
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizationID,
+			&i.KeyID,
+			&i.Parties,
+			&i.Threshold,
 		); err != nil {
 			return nil, err
 		}
@@ -166,7 +254,7 @@ UPDATE wallets SET
     name = $4,
     status = $5,
     updated_at = $6
-WHERE id = $1 RETURNING id, user_id, address, encrypted_private_key, name, status, created_at, updated_at
+WHERE id = $1 RETURNING id, user_id, address, encrypted_private_key, name, status, created_at, updated_at, organization_id, key_id, parties, threshold
 `
 
 type UpdateWalletParams struct {
@@ -197,6 +285,10 @@ func (q *Queries) UpdateWallet(ctx context.Context, arg UpdateWalletParams) (Wal
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationID,
+		&i.KeyID,
+		&i.Parties,
+		&i.Threshold,
 	)
 	return i, err
 }
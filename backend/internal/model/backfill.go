@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BackfillStatus string
+
+const (
+	BackfillRunning   BackfillStatus = "running"
+	BackfillCompleted BackfillStatus = "completed"
+	BackfillFailed    BackfillStatus = "failed" // stopped by an error, resumes when started again
+)
+
+// BackfillJob records the transfers of an address over a past block range.
+// The blocks before NextBlock are saved, an interrupted job resumes there.
+type BackfillJob struct {
+	ID                uuid.UUID      `json:"id"`
+	ChainID           int            `json:"chain_id"`
+	Address           string         `json:"address"`
+	FromBlock         uint64         `json:"from_block"`
+	ToBlock           uint64         `json:"to_block"`
+	NextBlock         uint64         `json:"next_block"`
+	Status            BackfillStatus `json:"status"`
+	TransactionsFound int            `json:"transactions_found"`
+	Error             string         `json:"error,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+// Progress returns the share of the block range already backfilled, from 0 to 1
+func (j BackfillJob) Progress() float64 {
+	if j.NextBlock > j.ToBlock {
+		return 1
+	}
+	if j.NextBlock <= j.FromBlock {
+		return 0
+	}
+	return float64(j.NextBlock-j.FromBlock) / float64(j.ToBlock-j.FromBlock+1)
+}
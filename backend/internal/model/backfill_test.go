@@ -0,0 +1,22 @@
+package model
+
+import "testing"
+
+func TestBackfillJobProgress(t *testing.T) {
+	tests := []struct {
+		next uint64
+		want float64
+	}{
+		{100, 0},
+		{150, 0.5},
+		{199, 0.99},
+		{200, 1},
+		{250, 1},
+	}
+	for _, tt := range tests {
+		job := BackfillJob{FromBlock: 100, ToBlock: 199, NextBlock: tt.next}
+		if got := job.Progress(); got != tt.want {
+			t.Errorf("Progress() at block %d = %v, want %v", tt.next, got, tt.want)
+		}
+	}
+}
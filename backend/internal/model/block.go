@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ScannedBlock is a block the worker scanned for transactions of monitored addresses
+type ScannedBlock struct {
+	ChainID    int       `json:"chain_id"`
+	Number     uint64    `json:"block_number"`
+	Hash       string    `json:"block_hash"`
+	ParentHash string    `json:"parent_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -7,15 +7,22 @@ import (
 )
 
 type Chain struct {
-	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	ChainID        int       `json:"chain_id"`
-	RPCURL         string    `json:"rpc_url"`
-	ExplorerURL    string    `json:"explorer_url"`
-	NativeCurrency string    `json:"native_currency"`
-	Status         string    `json:"status"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	ChainID         int       `json:"chain_id"`
+	RPCURL          string    `json:"rpc_url"`
+	ExplorerURL     string    `json:"explorer_url"`
+	NativeCurrency  string    `json:"native_currency"`
+	Status          string    `json:"status"`
+	Confirmations   int       `json:"confirmations"`
+	FallbackRPCURLs []string  `json:"fallback_rpc_urls"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// RPCURLs returns the RPC endpoints of the chain in order of preference
+func (c Chain) RPCURLs() []string {
+	return append([]string{c.RPCURL}, c.FallbackRPCURLs...)
 }
 
 type ChainResponse struct {
@@ -25,4 +32,5 @@ type ChainResponse struct {
 	RPCURL         string    `json:"rpc_url" example:"https://ethereum-sepolia-rpc.publicnode.com"`
 	ExplorerURL    string    `json:"explorer_url" example:"https://sepolia.etherscan.io"`
 	NativeCurrency string    `json:"native_currency" example:"ETH"`
+	Confirmations  int       `json:"confirmations" example:"3"` // blocks on top of a transaction before it is confirmed
 }
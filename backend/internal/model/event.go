@@ -0,0 +1,26 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types, named like the webhook events
+const (
+	EventDepositReceived      = "deposit.received"      // transfer to a wallet found on chain
+	EventTransactionConfirmed = "transaction.confirmed" // transaction of a wallet confirmed
+	EventTransactionFailed    = "transaction.failed"    // transaction of a wallet failed
+	EventWalletCreated        = "wallet.created"
+)
+
+// Event is a notification about the wallets of a user. IDs increase with
+// every event of the user, a client resumes after the last ID it received.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"` // transaction or wallet
+	CreatedAt time.Time       `json:"created_at"`
+}
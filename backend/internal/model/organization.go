@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +22,49 @@ type Organization struct {
 	UpdatedAt              time.Time              `json:"updated_at"`
 }
 
+// SigningAllowlist limits the off-chain messages the wallets of an
+// organization sign, it is stored as the signing_allowlist setting
+type SigningAllowlist struct {
+	Domains   []string `json:"domains"`   // dApp domains of sign-in messages
+	Contracts []string `json:"contracts"` // verifying contracts of typed data
+}
+
+// SigningAllowlist returns the signing allowlist of the organization, false
+// if none is set and any message may be signed
+func (o *Organization) SigningAllowlist() (SigningAllowlist, bool) {
+	setting, ok := o.Settings["signing_allowlist"]
+	if !ok || setting == nil {
+		return SigningAllowlist{}, false
+	}
+
+	// Settings are untyped JSON, an unreadable allowlist allows nothing
+	var allowlist SigningAllowlist
+	if b, err := json.Marshal(setting); err == nil {
+		_ = json.Unmarshal(b, &allowlist)
+	}
+	return allowlist, true
+}
+
+// AllowsDomain reports whether sign-in messages of domain may be signed
+func (a SigningAllowlist) AllowsDomain(domain string) bool {
+	for _, d := range a.Domains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsContract reports whether typed data of a verifying contract may be signed
+func (a SigningAllowlist) AllowsContract(address string) bool {
+	for _, c := range a.Contracts {
+		if strings.EqualFold(c, address) {
+			return true
+		}
+	}
+	return false
+}
+
 type OrganizationMember struct {
 	ID             uuid.UUID `json:"id"`
 	OrganizationID uuid.UUID `json:"organization_id"`
@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestSigningAllowlist(t *testing.T) {
+	org := Organization{}
+	if _, restricted := org.SigningAllowlist(); restricted {
+		t.Error("organization without settings is restricted")
+	}
+
+	// Settings as read from the JSONB column
+	org.Settings = map[string]interface{}{
+		"signing_allowlist": map[string]interface{}{
+			"domains":   []interface{}{"app.example.com"},
+			"contracts": []interface{}{"0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},
+		},
+	}
+	allowlist, restricted := org.SigningAllowlist()
+	if !restricted {
+		t.Fatal("organization with an allowlist is not restricted")
+	}
+	if !allowlist.AllowsDomain("APP.example.com") || allowlist.AllowsDomain("evil.example.com") {
+		t.Errorf("AllowsDomain does not match the allowlist %v", allowlist.Domains)
+	}
+	if !allowlist.AllowsContract("0xcccccccccccccccccccccccccccccccccccccccc") || allowlist.AllowsContract("") {
+		t.Errorf("AllowsContract does not match the allowlist %v", allowlist.Contracts)
+	}
+
+	// An unreadable allowlist allows nothing
+	org.Settings["signing_allowlist"] = "everything"
+	allowlist, restricted = org.SigningAllowlist()
+	if !restricted || allowlist.AllowsDomain("app.example.com") {
+		t.Error("unreadable allowlist allows signing")
+	}
+}
@@ -5,6 +5,7 @@ type Response struct {
 }
 
 type ErrorResponse struct {
-	Error     string `json:"error"`
-	ErrorCode string `json:"error_code"`
+	Error     string      `json:"error"`
+	ErrorCode string      `json:"error_code"`
+	Details   interface{} `json:"details,omitempty"`
 }
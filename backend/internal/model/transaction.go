@@ -1,19 +1,132 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// TransactionStatus is a step in the lifecycle of a transaction
+type TransactionStatus string
+
+const (
+	TxStatusCreated   TransactionStatus = "created"   // recorded, not built yet
+	TxStatusSigning   TransactionStatus = "signing"   // built, waiting for the TSS signature
+	TxStatusSigned    TransactionStatus = "signed"    // signed, not broadcast yet
+	TxStatusBroadcast TransactionStatus = "broadcast" // sent to the node, not mined yet
+	TxStatusPending   TransactionStatus = "pending"   // mined, waiting for confirmations
+	TxStatusConfirmed TransactionStatus = "confirmed" // mined and confirmed
+	TxStatusFailed    TransactionStatus = "failed"    // not sent or reverted
+	TxStatusDropped   TransactionStatus = "dropped"   // gone from the mempool
+	TxStatusReplaced  TransactionStatus = "replaced"  // another transaction used its nonce
+)
+
+// TransactionSource tells how a transaction got recorded
+type TransactionSource string
+
+const (
+	TxSourceAPI     TransactionSource = "api"     // sent through the API
+	TxSourceScanner TransactionSource = "scanner" // found in a block by the worker
+)
+
+// TransactionDirection tells how a transfer moves funds of the monitored wallets
+type TransactionDirection string
+
+const (
+	TxDirectionIn       TransactionDirection = "in"       // to a monitored wallet
+	TxDirectionOut      TransactionDirection = "out"      // from a monitored wallet
+	TxDirectionInternal TransactionDirection = "internal" // between monitored wallets
+)
+
+// txTransitions lists the statuses each status can move to
+var txTransitions = map[TransactionStatus][]TransactionStatus{
+	TxStatusCreated:   {TxStatusSigning, TxStatusFailed},
+	TxStatusSigning:   {TxStatusSigned, TxStatusFailed},
+	TxStatusSigned:    {TxStatusBroadcast, TxStatusFailed},
+	TxStatusBroadcast: {TxStatusPending, TxStatusConfirmed, TxStatusFailed, TxStatusDropped, TxStatusReplaced},
+	TxStatusPending:   {TxStatusBroadcast, TxStatusConfirmed, TxStatusFailed, TxStatusReplaced},
+	TxStatusDropped:   {TxStatusPending, TxStatusConfirmed, TxStatusFailed, TxStatusReplaced},
+}
+
+// CanTransition reports whether a transaction in status s can move to status to.
+// A status that is not final can be updated in place, e.g. while confirmations add up.
+func (s TransactionStatus) CanTransition(to TransactionStatus) bool {
+	if s == to {
+		return !s.IsFinal()
+	}
+	for _, next := range txTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal reports whether the status can no longer change
+func (s TransactionStatus) IsFinal() bool {
+	return len(txTransitions[s]) == 0
+}
+
 type Transaction struct {
-	ID          uuid.UUID `json:"id"`
-	FromAddress string    `json:"from_address"`
-	ToAddress   string    `json:"to_address"`
-	ChainID     int       `json:"chain_id"`
-	TxHash      string    `json:"tx_hash"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                   uuid.UUID            `json:"id"`
+	FromAddress          string               `json:"from_address"`
+	ToAddress            string               `json:"to_address"`
+	ChainID              int                  `json:"chain_id"`
+	TxHash               string               `json:"tx_hash"`
+	Status               TransactionStatus    `json:"status"`
+	Symbol               string               `json:"symbol,omitempty"`
+	Amount               string               `json:"amount,omitempty"`
+	Nonce                *uint64              `json:"nonce,omitempty"`
+	GasLimit             uint64               `json:"gas_limit,omitempty"`
+	MaxFeePerGas         string               `json:"max_fee_per_gas,omitempty"`          // wei
+	MaxPriorityFeePerGas string               `json:"max_priority_fee_per_gas,omitempty"` // wei
+	GasUsed              uint64               `json:"gas_used,omitempty"`
+	EffectiveGasPrice    string               `json:"effective_gas_price,omitempty"` // wei
+	BlockNumber          uint64               `json:"block_number,omitempty"`
+	BlockHash            string               `json:"block_hash,omitempty"`
+	Confirmations        int                  `json:"confirmations"`
+	FailureReason        string               `json:"failure_reason,omitempty"`
+	ReplacesID           *uuid.UUID           `json:"replaces_id,omitempty"`    // stuck transaction this one speeds up or cancels
+	ReplacedByID         *uuid.UUID           `json:"replaced_by_id,omitempty"` // latest speed-up or cancel of this transaction
+	Data                 string               `json:"data,omitempty"`           // calldata of contract calls, hex encoded
+	Source               TransactionSource    `json:"source"`
+	ContractAddress      string               `json:"contract_address,omitempty"` // token contract of ERC-20 transfers
+	LogIndex             *uint                `json:"log_index,omitempty"`        // Transfer log of ERC-20 transfers found by the scanner
+	RawAmount            string               `json:"raw_amount,omitempty"`       // amount in base units
+	Direction            TransactionDirection `json:"direction,omitempty"`
+	Fee                  string               `json:"fee,omitempty"`        // wei paid for gas by the sender
+	BlockTime            *time.Time           `json:"block_time,omitempty"` // time of the block including the transaction
+	Events               []TransactionEvent   `json:"events,omitempty"`
+	CreatedAt            time.Time            `json:"created_at"`
+	UpdatedAt            time.Time            `json:"updated_at"`
+}
+
+// TransactionEvent records when a transaction entered a status
+type TransactionEvent struct {
+	Status    TransactionStatus `json:"status"`
+	Reason    string            `json:"reason,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// TransactionUpdate holds a new status and the fields that change with it.
+// Zero values leave the stored fields unchanged.
+type TransactionUpdate struct {
+	Status               TransactionStatus
+	Reason               string // recorded with the event, and as failure reason for failed transactions
+	TxHash               string
+	Nonce                *uint64
+	GasLimit             uint64
+	MaxFeePerGas         string
+	MaxPriorityFeePerGas string
+	GasUsed              uint64
+	EffectiveGasPrice    string
+	Fee                  string
+	BlockNumber          uint64
+	BlockHash            string
+	Confirmations        *int
+	ReplacedByID         *uuid.UUID
+	ClearReceipt         bool // forget block and gas used, e.g. after a reorg
 }
 
 type TransactionFilter struct {
@@ -43,5 +156,48 @@ type CreateAndSubmitTransactionRequest struct {
 	ChainID     int    `json:"chain_id" validate:"required"`
 	Symbol      string `json:"symbol" validate:"required"`
 	Amount      string `json:"amount" validate:"required"`
-	ShareData   string `json:"share_data" validate:"required"`
+	ShareData   string `json:"share_data" validate:"required_unless=DryRun true"`
+	Speed       string `json:"speed" validate:"omitempty,oneof=slow standard fast"` // fee tier, the configured default if empty
+	DryRun      bool   `json:"dry_run"`                                             // only simulate, nothing is signed or sent
+}
+
+// ContractCallRequest calls a contract from the wallet. The calldata is
+// either raw Data or encoded from an ABI with Method and Args.
+type ContractCallRequest struct {
+	FromAddress string            `json:"from_address" validate:"required"`
+	ToAddress   string            `json:"to_address" validate:"required"` // contract address
+	ChainID     int               `json:"chain_id" validate:"required"`
+	Value       string            `json:"value" example:"0"`                                                              // native amount sent with the call, 0 if empty
+	Data        string            `json:"data" validate:"required_without=ABI,excluded_with=ABI" example:"0x095ea7b3..."` // raw calldata, hex encoded
+	ABI         json.RawMessage   `json:"abi" validate:"required_without=Data" swaggertype:"object"`                      // ABI of the contract, or the fragment of the method
+	Method      string            `json:"method" validate:"required_with=ABI" example:"approve"`
+	Args        []json.RawMessage `json:"args" swaggertype:"array,object"` // JSON values, integers may be decimal strings
+	GasLimit    uint64            `json:"gas_limit"`                       // estimated if 0
+	ShareData   string            `json:"share_data" validate:"required_unless=DryRun true"`
+	Speed       string            `json:"speed" validate:"omitempty,oneof=slow standard fast"` // fee tier, the configured default if empty
+	DryRun      bool              `json:"dry_run"`                                             // only simulate, nothing is signed or sent
+}
+
+// SimulationResult is the outcome of a dry run against the pending block
+type SimulationResult struct {
+	Success              bool           `json:"success"`
+	GasLimit             uint64         `json:"gas_limit,omitempty"`
+	GasUsed              uint64         `json:"gas_used,omitempty"`
+	MaxFeePerGas         string         `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string         `json:"max_priority_fee_per_gas,omitempty"`
+	MaxFee               string         `json:"max_fee,omitempty"` // gas limit times max fee per gas, in wei
+	Revert               *RevertDetails `json:"revert,omitempty"`
+}
+
+// RevertDetails describes why a transaction reverts
+type RevertDetails struct {
+	Reason   string `json:"reason,omitempty" example:"ERC20InsufficientBalance(0x..., 0, 1000000)"`
+	Selector string `json:"selector,omitempty" example:"0xe450d38c"` // selector of a custom error
+	Data     string `json:"data,omitempty"`                          // raw revert data, hex encoded
+}
+
+// ReplaceTransactionRequest speeds up or cancels a stuck transaction
+type ReplaceTransactionRequest struct {
+	ShareData string `json:"share_data" validate:"required"`
+	Speed     string `json:"speed" validate:"omitempty,oneof=slow standard fast"` // fee tier, fees are raised above the stuck transaction's anyway
 }
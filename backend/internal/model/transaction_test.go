@@ -0,0 +1,29 @@
+package model
+
+import "testing"
+
+func TestTransactionStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to TransactionStatus
+		want     bool
+	}{
+		{TxStatusCreated, TxStatusSigning, true},
+		{TxStatusCreated, TxStatusBroadcast, false},
+		{TxStatusSigned, TxStatusBroadcast, true},
+		{TxStatusBroadcast, TxStatusConfirmed, true},
+		{TxStatusBroadcast, TxStatusBroadcast, true},
+		{TxStatusPending, TxStatusPending, true},
+		{TxStatusPending, TxStatusBroadcast, true}, // reorged out
+		{TxStatusDropped, TxStatusPending, true},
+		{TxStatusDropped, TxStatusBroadcast, false},
+		{TxStatusConfirmed, TxStatusConfirmed, false},
+		{TxStatusConfirmed, TxStatusFailed, false},
+		{TxStatusReplaced, TxStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransition(tt.to); got != tt.want {
+			t.Errorf("%s.CanTransition(%s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -1,20 +1,25 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Wallet struct {
-	ID                  uuid.UUID `json:"id"`
-	UserID              uuid.UUID `json:"user_id"`
-	Address             string    `json:"address"`
-	EncryptedPrivateKey string    `json:"encrypted_private_key"`
-	Name                string    `json:"name"`
-	Status              string    `json:"status"`
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
+	ID                  uuid.UUID  `json:"id"`
+	UserID              uuid.UUID  `json:"user_id"`
+	Address             string     `json:"address"`
+	EncryptedPrivateKey string     `json:"encrypted_private_key"`
+	Name                string     `json:"name"`
+	Status              string     `json:"status"`
+	KeyID               string     `json:"key_id"`    // keygen session of the TSS key
+	Parties             []uint32   `json:"parties"`   // TSS nodes holding a share
+	Threshold           uint32     `json:"threshold"` // TSS threshold of the key
+	OrganizationID      *uuid.UUID `json:"organization_id,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type WalletResponse struct {
@@ -24,8 +29,28 @@ type WalletResponse struct {
 	Name    string    `json:"name" example:"My Wallet"`
 }
 
+// SignMessageRequest signs a message with personal_sign (EIP-191)
+type SignMessageRequest struct {
+	Message   string `json:"message" validate:"required" example:"app.example.com wants you to sign in with your Ethereum account:..."` // text, or 0x-prefixed hex of the bytes
+	ShareData string `json:"share_data" validate:"required"`
+}
+
+// SignTypedDataRequest signs EIP-712 typed data like eth_signTypedData_v4
+type SignTypedDataRequest struct {
+	TypedData json.RawMessage `json:"typed_data" validate:"required" swaggertype:"object"` // types, primaryType, domain and message
+	ShareData string          `json:"share_data" validate:"required"`
+}
+
+// SignatureResponse is a 65-byte recoverable signature, v being 27 or 28
+type SignatureResponse struct {
+	Address   string `json:"address" example:"0x0000000000000000000000000000000000000000"`
+	Hash      string `json:"hash"` // signed hash, hex encoded
+	Signature string `json:"signature"`
+}
+
 type GetBalanceRequest struct {
 	Address string `json:"address" validate:"required"`
+	ChainID int    `json:"chain_id"`
 }
 
 type GetBalanceResponse struct {
@@ -1,32 +1,59 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Webhook statuses, events are only queued for active webhooks
+const (
+	WebhookStatusActive   = "active"
+	WebhookStatusDisabled = "disabled"
+)
+
+// Delivery statuses of a webhook event
+const (
+	WebhookEventPending   = "pending"   // waiting for its next attempt
+	WebhookEventDelivered = "delivered" // accepted by the webhook with a 2xx response
+	WebhookEventDead      = "dead"      // out of attempts, only redelivered by hand
+)
+
 type Webhook struct {
 	ID             uuid.UUID `json:"id"`
 	OrganizationID uuid.UUID `json:"organization_id"`
 	Name           string    `json:"name"`
 	URL            string    `json:"url"`
 	Events         []string  `json:"events"`
-	Secret         string    `json:"secret"`
+	Secret         string    `json:"-"` // only returned once, by CreateWebhookResponse
 	Status         string    `json:"status"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// WebhookEvent is the delivery of an event to a webhook
 type WebhookEvent struct {
-	ID        uuid.UUID              `json:"id"`
-	WebhookID uuid.UUID              `json:"webhook_id"`
-	Event     string                 `json:"event"`
-	Data      map[string]interface{} `json:"data"`
-	Status    string                 `json:"status"`
-	Attempts  int                    `json:"attempts"`
-	CreatedAt time.Time              `json:"created_at"`
-	UpdatedAt time.Time              `json:"updated_at"`
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Data           json.RawMessage `json:"data" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      *string         `json:"last_error,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"` // HTTP status of the last attempt
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// WebhookPayload is the body posted to a webhook
+type WebhookPayload struct {
+	ID        uuid.UUID       `json:"id"` // webhook event ID, the same on every attempt
+	Event     string          `json:"event"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
 type CreateWebhookRequest struct {
@@ -35,6 +62,25 @@ type CreateWebhookRequest struct {
 	Events []string `json:"events" validate:"required,min=1"`
 }
 
+type UpdateWebhookRequest struct {
+	Name   *string  `json:"name,omitempty"`
+	URL    *string  `json:"url,omitempty" validate:"omitempty,url"`
+	Events []string `json:"events,omitempty" validate:"omitempty,min=1"`
+	Status *string  `json:"status,omitempty" validate:"omitempty,oneof=active disabled"`
+}
+
 type WebhookResponse struct {
 	Webhook Webhook `json:"webhook"`
 }
+
+// CreateWebhookResponse is the only response carrying the secret of a webhook
+type CreateWebhookResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type WebhookEventListResponse struct {
+	Events   []WebhookEvent `json:"events"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
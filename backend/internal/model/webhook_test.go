@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWebhookSecretOnlyOnCreate(t *testing.T) {
+	wh := Webhook{Name: "deposits", Secret: "whsec_test"}
+
+	b, err := json.Marshal(wh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "whsec_test") {
+		t.Errorf("webhook exposes its secret: %s", b)
+	}
+
+	b, err = json.Marshal(CreateWebhookResponse{Webhook: wh, Secret: wh.Secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created map[string]interface{}
+	if err := json.Unmarshal(b, &created); err != nil {
+		t.Fatal(err)
+	}
+	if created["secret"] != "whsec_test" || created["name"] != "deposits" {
+		t.Errorf("create response = %s, want the webhook and its secret", b)
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "mpc/internal/db/sqlc"
+	"mpc/internal/model"
+	"mpc/pkg/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BackfillRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+func NewBackfillRepository(pool *pgxpool.Pool) *BackfillRepository {
+	return &BackfillRepository{pool: pool, queries: db.New(pool)}
+}
+
+// StartBackfillJob creates the backfill of an address over a block range, or
+// resumes the existing one of the same range. A completed job stays completed.
+func (r *BackfillRepository) StartBackfillJob(ctx context.Context, chainID int, address string, fromBlock, toBlock uint64) (model.BackfillJob, error) {
+	job, err := r.queries.StartBackfillJob(ctx, db.StartBackfillJobParams{
+		ChainID:   int32(chainID),
+		Address:   address,
+		FromBlock: int64(fromBlock),
+		ToBlock:   int64(toBlock),
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return model.BackfillJob{}, fmt.Errorf("failed to start backfill job: %w", err)
+	}
+	return toBackfillJobModel(job), nil
+}
+
+// GetUnfinishedBackfillJob gets the latest job of an address from fromBlock
+// that is not completed, ok is false if there is none
+func (r *BackfillRepository) GetUnfinishedBackfillJob(ctx context.Context, chainID int, address string, fromBlock uint64) (job model.BackfillJob, ok bool, err error) {
+	sqlcJob, err := r.queries.GetUnfinishedBackfillJob(ctx, db.GetUnfinishedBackfillJobParams{
+		ChainID:   int32(chainID),
+		Address:   address,
+		FromBlock: int64(fromBlock),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.BackfillJob{}, false, nil
+	}
+	if err != nil {
+		return model.BackfillJob{}, false, fmt.Errorf("failed to get unfinished backfill job: %w", err)
+	}
+	return toBackfillJobModel(sqlcJob), true, nil
+}
+
+// SaveBackfilledBlocks records the transactions found in the blocks of a job
+// up to nextBlock and moves the job to nextBlock, so the blocks are either
+// saved with all their transactions or scanned again on resume. Transactions
+// already recorded are skipped, the created ones are returned.
+func (r *BackfillRepository) SaveBackfilledBlocks(ctx context.Context, job model.BackfillJob, nextBlock uint64, transactions []model.Transaction) ([]model.Transaction, error) {
+	var created []model.Transaction
+	err := inTx(ctx, r.pool, r.queries, func(q *db.Queries) error {
+		created = created[:0]
+		for _, transaction := range transactions {
+			txn, err := createTransaction(ctx, q, transaction)
+			if errors.Is(err, ErrDuplicateTransaction) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			created = append(created, txn)
+		}
+		return q.UpdateBackfillProgress(ctx, db.UpdateBackfillProgressParams{
+			ID:                utils.ToPgUUID(job.ID),
+			NextBlock:         int64(nextBlock),
+			TransactionsFound: int32(len(created)),
+			UpdatedAt:         pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save backfilled blocks: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateBackfillStatus sets the status of a job, with the error that stopped it
+func (r *BackfillRepository) UpdateBackfillStatus(ctx context.Context, job model.BackfillJob, status model.BackfillStatus, jobErr string) error {
+	err := r.queries.UpdateBackfillStatus(ctx, db.UpdateBackfillStatusParams{
+		ID:        utils.ToPgUUID(job.ID),
+		Status:    string(status),
+		Error:     toOptionalPgText(jobErr),
+		UpdatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update backfill status: %w", err)
+	}
+	return nil
+}
+
+func toBackfillJobModel(sqlcJob db.BackfillJob) model.BackfillJob {
+	return model.BackfillJob{
+		ID:                utils.ToUUID(sqlcJob.ID),
+		ChainID:           int(sqlcJob.ChainID),
+		Address:           sqlcJob.Address,
+		FromBlock:         uint64(sqlcJob.FromBlock),
+		ToBlock:           uint64(sqlcJob.ToBlock),
+		NextBlock:         uint64(sqlcJob.NextBlock),
+		Status:            model.BackfillStatus(sqlcJob.Status),
+		TransactionsFound: int(sqlcJob.TransactionsFound),
+		Error:             utils.ToText(sqlcJob.Error),
+		CreatedAt:         sqlcJob.CreatedAt.Time,
+		UpdatedAt:         sqlcJob.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "mpc/internal/db/sqlc"
+	"mpc/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNoScannedBlock is returned when no block of a chain was scanned yet
+var ErrNoScannedBlock = errors.New("no scanned block")
+
+type BlockRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+func NewBlockRepository(pool *pgxpool.Pool) *BlockRepository {
+	return &BlockRepository{pool: pool, queries: db.New(pool)}
+}
+
+// GetLastScannedBlock retrieves the latest scanned block of a chain, ErrNoScannedBlock if there is none
+func (r *BlockRepository) GetLastScannedBlock(ctx context.Context, chainID int) (model.ScannedBlock, error) {
+	block, err := r.queries.GetLastScannedBlock(ctx, int32(chainID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ScannedBlock{}, ErrNoScannedBlock
+	}
+	if err != nil {
+		return model.ScannedBlock{}, fmt.Errorf("failed to get last scanned block: %w", err)
+	}
+	return toScannedBlockModel(block), nil
+}
+
+// GetScannedBlocks retrieves the latest scanned blocks of a chain, newest first
+func (r *BlockRepository) GetScannedBlocks(ctx context.Context, chainID int, limit int) ([]model.ScannedBlock, error) {
+	blocks, err := r.queries.GetScannedBlocks(ctx, db.GetScannedBlocksParams{
+		ChainID: int32(chainID),
+		Limit:   int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scanned blocks: %w", err)
+	}
+
+	result := make([]model.ScannedBlock, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, toScannedBlockModel(block))
+	}
+	return result, nil
+}
+
+// SaveScannedBlock records a scanned block with the transactions found in it,
+// so a block is either scanned with all its transactions or not at all.
+// Transactions already recorded are skipped, the created ones are returned.
+func (r *BlockRepository) SaveScannedBlock(ctx context.Context, block model.ScannedBlock, transactions []model.Transaction) ([]model.Transaction, error) {
+	var created []model.Transaction
+	err := inTx(ctx, r.pool, r.queries, func(q *db.Queries) error {
+		created = created[:0]
+		for _, transaction := range transactions {
+			txn, err := createTransaction(ctx, q, transaction)
+			if errors.Is(err, ErrDuplicateTransaction) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			created = append(created, txn)
+		}
+		return q.CreateScannedBlock(ctx, db.CreateScannedBlockParams{
+			ChainID:     int32(block.ChainID),
+			BlockNumber: int64(block.Number),
+			BlockHash:   block.Hash,
+			ParentHash:  block.ParentHash,
+			CreatedAt:   pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save scanned block: %w", err)
+	}
+	return created, nil
+}
+
+// RollbackScannedBlocks forgets the blocks of a chain after block number and
+// removes the transactions the scanner found in them. It returns the number of
+// removed transactions.
+func (r *BlockRepository) RollbackScannedBlocks(ctx context.Context, chainID int, number uint64) (int, error) {
+	var removed int64
+	err := inTx(ctx, r.pool, r.queries, func(q *db.Queries) error {
+		var err error
+		removed, err = q.DeleteScannedTransactionsAfter(ctx, db.DeleteScannedTransactionsAfterParams{
+			ChainID:     int32(chainID),
+			BlockNumber: pgtype.Int8{Int64: int64(number), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeleteScannedBlocksAfter(ctx, db.DeleteScannedBlocksAfterParams{
+			ChainID:     int32(chainID),
+			BlockNumber: int64(number),
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll back scanned blocks: %w", err)
+	}
+	return int(removed), nil
+}
+
+// PruneScannedBlocks forgets the blocks of a chain before block number
+func (r *BlockRepository) PruneScannedBlocks(ctx context.Context, chainID int, number uint64) error {
+	err := r.queries.DeleteScannedBlocksBefore(ctx, db.DeleteScannedBlocksBeforeParams{
+		ChainID:     int32(chainID),
+		BlockNumber: int64(number),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prune scanned blocks: %w", err)
+	}
+	return nil
+}
+
+// toScannedBlockModel converts a sqlc scanned block to a model scanned block
+func toScannedBlockModel(sqlcBlock db.ScannedBlock) model.ScannedBlock {
+	return model.ScannedBlock{
+		ChainID:    int(sqlcBlock.ChainID),
+		Number:     uint64(sqlcBlock.BlockNumber),
+		Hash:       sqlcBlock.BlockHash,
+		ParentHash: sqlcBlock.ParentHash,
+		CreatedAt:  sqlcBlock.CreatedAt.Time,
+	}
+}
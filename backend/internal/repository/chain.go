@@ -57,14 +57,16 @@ func (r *ChainRepository) GetChains(ctx context.Context) ([]model.Chain, error)
 // toChainModel converts a sqlc chain to a model chain
 func toChainModel(sqlcChain db.Chain) model.Chain {
 	return model.Chain{
-		ID:             utils.ToUUID(sqlcChain.ID),
-		Name:           sqlcChain.Name,
-		ChainID:        int(sqlcChain.ChainID),
-		RPCURL:         sqlcChain.RpcUrl,
-		ExplorerURL:    sqlcChain.ExplorerUrl.String,
-		NativeCurrency: sqlcChain.NativeCurrency,
-		Status:         sqlcChain.Status,
-		CreatedAt:      sqlcChain.CreatedAt.Time,
-		UpdatedAt:      sqlcChain.UpdatedAt.Time,
+		ID:              utils.ToUUID(sqlcChain.ID),
+		Name:            sqlcChain.Name,
+		ChainID:         int(sqlcChain.ChainID),
+		RPCURL:          sqlcChain.RpcUrl,
+		ExplorerURL:     sqlcChain.ExplorerUrl.String,
+		NativeCurrency:  sqlcChain.NativeCurrency,
+		Status:          sqlcChain.Status,
+		Confirmations:   int(sqlcChain.Confirmations),
+		FallbackRPCURLs: sqlcChain.FallbackRpcUrls,
+		CreatedAt:       sqlcChain.CreatedAt.Time,
+		UpdatedAt:       sqlcChain.UpdatedAt.Time,
 	}
 }
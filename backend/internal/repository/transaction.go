@@ -2,39 +2,251 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	db "mpc/internal/db/sqlc"
 	"mpc/internal/model"
 	"mpc/pkg/utils"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrTransactionStatusChanged is returned when a transaction left the status
+// an update expected, e.g. because the tracker moved it in the meantime
+var ErrTransactionStatusChanged = errors.New("transaction status changed")
+
+// ErrDuplicateTransaction is returned when a transfer of the same chain,
+// hash and log is already recorded
+var ErrDuplicateTransaction = errors.New("duplicate transaction")
+
 type TransactionRepository struct {
+	pool    *pgxpool.Pool
 	queries *db.Queries
 }
 
 func NewTransactionRepository(pool *pgxpool.Pool) *TransactionRepository {
-	return &TransactionRepository{queries: db.New(pool)}
+	return &TransactionRepository{pool: pool, queries: db.New(pool)}
 }
 
-// CreateTransaction creates a new transaction
+// CreateTransaction creates a new transaction and records its first status,
+// created unless the transaction has one
 func (r *TransactionRepository) CreateTransaction(ctx context.Context, transaction model.Transaction) (model.Transaction, error) {
-	tx, err := r.queries.CreateTransaction(ctx, db.CreateTransactionParams{
-		ChainID:     int32(transaction.ChainID),
-		FromAddress: transaction.FromAddress,
-		ToAddress:   transaction.ToAddress,
-		TxHash:      transaction.TxHash,
-		CreatedAt:   utils.CurrentPgTimestamp(),
-		UpdatedAt:   utils.CurrentPgTimestamp(),
+	var created model.Transaction
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		var err error
+		created, err = createTransaction(ctx, q, transaction)
+		return err
 	})
 	if err != nil {
 		return model.Transaction{}, fmt.Errorf("failed to create transaction: %w", err)
 	}
+	return created, nil
+}
+
+// createTransaction inserts a transaction and the event of its first status
+func createTransaction(ctx context.Context, q *db.Queries, transaction model.Transaction) (model.Transaction, error) {
+	status := transaction.Status
+	if status == "" {
+		status = model.TxStatusCreated
+	}
+	source := transaction.Source
+	if source == "" {
+		source = model.TxSourceAPI
+	}
+	// Transactions sent through the API leave the wallet
+	direction := transaction.Direction
+	if direction == "" && source == model.TxSourceAPI {
+		direction = model.TxDirectionOut
+	}
+	var blockTime pgtype.Timestamp
+	if transaction.BlockTime != nil {
+		blockTime = pgtype.Timestamp{Time: *transaction.BlockTime, Valid: true}
+	}
+
+	now := pgtype.Timestamp{Time: time.Now(), Valid: true}
+	tx, err := q.CreateTransaction(ctx, db.CreateTransactionParams{
+		ChainID:           int32(transaction.ChainID),
+		FromAddress:       transaction.FromAddress,
+		ToAddress:         transaction.ToAddress,
+		TxHash:            transaction.TxHash,
+		Status:            string(status),
+		Symbol:            transaction.Symbol,
+		Amount:            transaction.Amount,
+		Nonce:             toPgInt8(transaction.Nonce),
+		ReplacesID:        utils.ToNullablePgUUID(transaction.ReplacesID, ""),
+		Data:              toOptionalPgText(transaction.Data),
+		Source:            string(source),
+		BlockNumber:       toOptionalPgInt8(transaction.BlockNumber),
+		BlockHash:         toOptionalPgText(transaction.BlockHash),
+		ContractAddress:   toOptionalPgText(transaction.ContractAddress),
+		LogIndex:          toPgInt4(transaction.LogIndex),
+		RawAmount:         toOptionalPgText(transaction.RawAmount),
+		Direction:         toOptionalPgText(string(direction)),
+		GasLimit:          toOptionalPgInt8(transaction.GasLimit),
+		GasUsed:           toOptionalPgInt8(transaction.GasUsed),
+		EffectiveGasPrice: toOptionalPgText(transaction.EffectiveGasPrice),
+		Fee:               toOptionalPgText(transaction.Fee),
+		BlockTime:         blockTime,
+		FailureReason:     toOptionalPgText(transaction.FailureReason),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Transaction{}, ErrDuplicateTransaction
+	}
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	err = q.CreateTransactionEvent(ctx, db.CreateTransactionEventParams{
+		TransactionID: tx.ID,
+		Status:        tx.Status,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return model.Transaction{}, err
+	}
 	return toTransactionModel(tx), nil
 }
 
+// UpdateTransactionStatus moves a transaction from status from to update.Status
+// and records the transition. It fails with ErrTransactionStatusChanged if the
+// transaction is no longer in status from.
+func (r *TransactionRepository) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, from model.TransactionStatus, update model.TransactionUpdate) (model.Transaction, error) {
+	if !from.CanTransition(update.Status) {
+		return model.Transaction{}, fmt.Errorf("invalid transaction status transition from %s to %s", from, update.Status)
+	}
+
+	params := db.UpdateTransactionStatusParams{
+		Status:               string(update.Status),
+		TxHash:               toOptionalPgText(update.TxHash),
+		Nonce:                toPgInt8(update.Nonce),
+		GasLimit:             toOptionalPgInt8(update.GasLimit),
+		MaxFeePerGas:         toOptionalPgText(update.MaxFeePerGas),
+		MaxPriorityFeePerGas: toOptionalPgText(update.MaxPriorityFeePerGas),
+		ClearReceipt:         update.ClearReceipt,
+		GasUsed:              toOptionalPgInt8(update.GasUsed),
+		EffectiveGasPrice:    toOptionalPgText(update.EffectiveGasPrice),
+		Fee:                  toOptionalPgText(update.Fee),
+		BlockNumber:          toOptionalPgInt8(update.BlockNumber),
+		BlockHash:            toOptionalPgText(update.BlockHash),
+		ID:                   utils.ToPgUUID(id),
+		FromStatus:           string(from),
+	}
+	if update.Confirmations != nil {
+		params.Confirmations = pgtype.Int4{Int32: int32(*update.Confirmations), Valid: true}
+	}
+	if update.Status == model.TxStatusFailed {
+		params.FailureReason = toOptionalPgText(update.Reason)
+	}
+
+	var updated model.Transaction
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		now := pgtype.Timestamp{Time: time.Now(), Valid: true}
+		params.UpdatedAt = now
+		tx, err := q.UpdateTransactionStatus(ctx, params)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTransactionStatusChanged
+		}
+		if err != nil {
+			return err
+		}
+		updated = toTransactionModel(tx)
+
+		// Only status changes are events, not confirmations adding up
+		if from == update.Status {
+			return nil
+		}
+		return q.CreateTransactionEvent(ctx, db.CreateTransactionEventParams{
+			TransactionID: tx.ID,
+			Status:        tx.Status,
+			Reason:        toOptionalPgText(update.Reason),
+			CreatedAt:     now,
+		})
+	})
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	return updated, nil
+}
+
+// SetTransactionReplacedBy links a transaction to the transaction replacing it
+func (r *TransactionRepository) SetTransactionReplacedBy(ctx context.Context, id uuid.UUID, replacedBy uuid.UUID) error {
+	err := r.queries.SetTransactionReplacedBy(ctx, db.SetTransactionReplacedByParams{
+		ID:           utils.ToPgUUID(id),
+		ReplacedByID: utils.ToPgUUID(replacedBy),
+		UpdatedAt:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set replacing transaction: %w", err)
+	}
+	return nil
+}
+
+// GetTransactionsByStatus retrieves transactions of a chain in any of the given statuses,
+// least recently updated first
+func (r *TransactionRepository) GetTransactionsByStatus(ctx context.Context, chainID int, statuses []model.TransactionStatus, limit int) ([]model.Transaction, error) {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+
+	transactions, err := r.queries.GetTransactionsByStatus(ctx, db.GetTransactionsByStatusParams{
+		ChainID:    int32(chainID),
+		Statuses:   values,
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions by status: %w", err)
+	}
+
+	var result []model.Transaction
+	for _, tx := range transactions {
+		result = append(result, toTransactionModel(tx))
+	}
+	return result, nil
+}
+
+// GetTransactionEvents retrieves the status history of a transaction, oldest first
+func (r *TransactionRepository) GetTransactionEvents(ctx context.Context, id uuid.UUID) ([]model.TransactionEvent, error) {
+	events, err := r.queries.GetTransactionEvents(ctx, utils.ToPgUUID(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction events: %w", err)
+	}
+
+	result := make([]model.TransactionEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, model.TransactionEvent{
+			Status:    model.TransactionStatus(event.Status),
+			Reason:    utils.ToText(event.Reason),
+			CreatedAt: event.CreatedAt.Time,
+		})
+	}
+	return result, nil
+}
+
+// inTx runs fn with queries bound to a database transaction, committed if fn succeeds
+func (r *TransactionRepository) inTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	return inTx(ctx, r.pool, r.queries, fn)
+}
+
+// inTx runs fn with queries bound to a transaction of pool, committed if fn succeeds
+func inTx(ctx context.Context, pool *pgxpool.Pool, queries *db.Queries, fn func(q *db.Queries) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 // GetTransactionsByWalletAddress retrieves transactions by wallet ID
 func (r *TransactionRepository) GetTransactionsByWalletAddress(ctx context.Context, walletAddress string, chainID int, limit int, offset int) ([]model.Transaction, error) {
 	transactions, err := r.queries.GetTransactionsByWalletAddress(ctx, db.GetTransactionsByWalletAddressParams{
@@ -78,12 +290,97 @@ func (r *TransactionRepository) GetTransactionCount(ctx context.Context, walletA
 // toTransactionModel converts a sqlc transaction to a model transaction
 func toTransactionModel(sqlcTransaction db.Transaction) model.Transaction {
 	return model.Transaction{
-		ID:          utils.ToUUID(sqlcTransaction.ID),
-		ChainID:     int(sqlcTransaction.ChainID),
-		FromAddress: sqlcTransaction.FromAddress,
-		ToAddress:   sqlcTransaction.ToAddress,
-		TxHash:      sqlcTransaction.TxHash,
-		CreatedAt:   sqlcTransaction.CreatedAt.Time,
-		UpdatedAt:   sqlcTransaction.UpdatedAt.Time,
+		ID:                   utils.ToUUID(sqlcTransaction.ID),
+		ChainID:              int(sqlcTransaction.ChainID),
+		FromAddress:          sqlcTransaction.FromAddress,
+		ToAddress:            sqlcTransaction.ToAddress,
+		TxHash:               sqlcTransaction.TxHash,
+		Status:               model.TransactionStatus(sqlcTransaction.Status),
+		Symbol:               sqlcTransaction.Symbol,
+		Amount:               sqlcTransaction.Amount,
+		Nonce:                fromPgInt8(sqlcTransaction.Nonce),
+		GasLimit:             uint64(sqlcTransaction.GasLimit.Int64),
+		MaxFeePerGas:         utils.ToText(sqlcTransaction.MaxFeePerGas),
+		MaxPriorityFeePerGas: utils.ToText(sqlcTransaction.MaxPriorityFeePerGas),
+		GasUsed:              uint64(sqlcTransaction.GasUsed.Int64),
+		EffectiveGasPrice:    utils.ToText(sqlcTransaction.EffectiveGasPrice),
+		BlockNumber:          uint64(sqlcTransaction.BlockNumber.Int64),
+		BlockHash:            utils.ToText(sqlcTransaction.BlockHash),
+		Confirmations:        int(sqlcTransaction.Confirmations),
+		FailureReason:        utils.ToText(sqlcTransaction.FailureReason),
+		ReplacesID:           fromPgUUID(sqlcTransaction.ReplacesID),
+		ReplacedByID:         fromPgUUID(sqlcTransaction.ReplacedByID),
+		Data:                 utils.ToText(sqlcTransaction.Data),
+		Source:               model.TransactionSource(sqlcTransaction.Source),
+		ContractAddress:      utils.ToText(sqlcTransaction.ContractAddress),
+		LogIndex:             fromPgInt4(sqlcTransaction.LogIndex),
+		RawAmount:            utils.ToText(sqlcTransaction.RawAmount),
+		Direction:            model.TransactionDirection(utils.ToText(sqlcTransaction.Direction)),
+		Fee:                  utils.ToText(sqlcTransaction.Fee),
+		BlockTime:            fromPgTimestamp(sqlcTransaction.BlockTime),
+		CreatedAt:            sqlcTransaction.CreatedAt.Time,
+		UpdatedAt:            sqlcTransaction.UpdatedAt.Time,
+	}
+}
+
+// toPgInt8 converts an optional value to a nullable BIGINT
+func toPgInt8(v *uint64) pgtype.Int8 {
+	if v == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: int64(*v), Valid: true}
+}
+
+// toPgInt4 converts an optional value to a nullable INT
+func toPgInt4(v *uint) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(*v), Valid: true}
+}
+
+// toOptionalPgInt8 converts a value to a nullable BIGINT, zero being null
+func toOptionalPgInt8(v uint64) pgtype.Int8 {
+	return pgtype.Int8{Int64: int64(v), Valid: v != 0}
+}
+
+// toOptionalPgText converts a string to nullable text, empty being null
+func toOptionalPgText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// fromPgInt8 converts a nullable BIGINT to an optional value
+func fromPgInt8(v pgtype.Int8) *uint64 {
+	if !v.Valid {
+		return nil
+	}
+	n := uint64(v.Int64)
+	return &n
+}
+
+// fromPgInt4 converts a nullable INT to an optional value
+func fromPgInt4(v pgtype.Int4) *uint {
+	if !v.Valid {
+		return nil
+	}
+	n := uint(v.Int32)
+	return &n
+}
+
+// fromPgTimestamp converts a nullable TIMESTAMP to an optional time
+func fromPgTimestamp(v pgtype.Timestamp) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
+
+// fromPgUUID converts a nullable UUID to an optional one
+func fromPgUUID(v pgtype.UUID) *uuid.UUID {
+	if !v.Valid {
+		return nil
 	}
+	id := utils.ToUUID(v)
+	return &id
 }
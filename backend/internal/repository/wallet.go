@@ -3,31 +3,37 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	db "mpc/internal/db/sqlc"
 	"mpc/internal/model"
 	"mpc/pkg/utils"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type WalletRepository struct {
 	queries *db.Queries
 }
 
-func NewWalletRepository(pool *pgxpool.Pool) *WalletRepository {
-	return &WalletRepository{queries: db.New(pool)}
+// NewWalletRepository creates a repository of the wallets of conn, a pool
+// in the services
+func NewWalletRepository(conn db.DBTX) *WalletRepository {
+	return &WalletRepository{queries: db.New(conn)}
 }
 
-// CreateWallet creates a new wallet
-func (r *WalletRepository) CreateWallet(ctx context.Context, userID uuid.UUID, address string, encryptedPrivateKey []byte, name string) (model.Wallet, error) {
+// CreateWallet creates a new wallet backed by the TSS key keyID
+func (r *WalletRepository) CreateWallet(ctx context.Context, userID uuid.UUID, address string, encryptedPrivateKey []byte, name string, keyID string, parties []uint32, threshold uint32) (model.Wallet, error) {
 	wallet, err := r.queries.CreateWallet(ctx, db.CreateWalletParams{
 		UserID:              utils.ToPgUUID(userID),
 		Address:             address,
 		EncryptedPrivateKey: encryptedPrivateKey,
 		Name:                utils.ToPgText(name),
 		Status:              "active",
+		KeyID:               keyID,
+		Parties:             utils.ToInt32s(parties),
+		Threshold:           int32(threshold),
 		CreatedAt:           utils.CurrentPgTimestamp(),
 		UpdatedAt:           utils.CurrentPgTimestamp(),
 	})
@@ -68,13 +74,51 @@ func (r *WalletRepository) GetWalletByAddress(ctx context.Context, address strin
 	return toWalletModel(wallet), nil
 }
 
-// GetAllAddresses retrieves all addresses
-func (r *WalletRepository) GetAllAddresses(ctx context.Context) ([]string, error) {
-	addresses, err := r.queries.GetAllAddresses(ctx)
+// GetWalletAddresses retrieves the address and organization of up to limit
+// wallets after the wallet afterID, ordered by ID, to page through all wallets
+func (r *WalletRepository) GetWalletAddresses(ctx context.Context, afterID uuid.UUID, limit int) ([]model.Wallet, error) {
+	rows, err := r.queries.GetWalletAddresses(ctx, db.GetWalletAddressesParams{
+		ID:    utils.ToPgUUID(afterID),
+		Limit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet addresses: %w", err)
+	}
+
+	wallets := make([]model.Wallet, 0, len(rows))
+	for _, row := range rows {
+		wallets = append(wallets, model.Wallet{
+			ID:             utils.ToUUID(row.ID),
+			Address:        row.Address,
+			OrganizationID: fromPgUUID(row.OrganizationID),
+		})
+	}
+	return wallets, nil
+}
+
+// GetWalletAddressesCreatedSince pages through the addresses of the wallets
+// created after the wallet created at afterCreatedAt with ID afterID, oldest
+// first
+func (r *WalletRepository) GetWalletAddressesCreatedSince(ctx context.Context, afterCreatedAt time.Time, afterID uuid.UUID, limit int) ([]model.Wallet, error) {
+	rows, err := r.queries.GetWalletAddressesCreatedSince(ctx, db.GetWalletAddressesCreatedSinceParams{
+		AfterCreatedAt: pgtype.Timestamp{Time: afterCreatedAt, Valid: true},
+		AfterID:        utils.ToPgUUID(afterID),
+		MaxResults:     int32(limit),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all addresses: %w", err)
+		return nil, fmt.Errorf("failed to get wallet addresses: %w", err)
+	}
+
+	wallets := make([]model.Wallet, 0, len(rows))
+	for _, row := range rows {
+		wallets = append(wallets, model.Wallet{
+			ID:             utils.ToUUID(row.ID),
+			Address:        row.Address,
+			OrganizationID: fromPgUUID(row.OrganizationID),
+			CreatedAt:      row.CreatedAt.Time,
+		})
 	}
-	return addresses, nil
+	return wallets, nil
 }
 
 // toWalletModel converts a sqlc wallet to a model wallet
@@ -86,6 +130,10 @@ func toWalletModel(sqlcWallet db.Wallet) model.Wallet {
 		EncryptedPrivateKey: string(sqlcWallet.EncryptedPrivateKey),
 		Name:                utils.ToText(sqlcWallet.Name),
 		Status:              sqlcWallet.Status,
+		KeyID:               sqlcWallet.KeyID,
+		Parties:             utils.ToUint32s(sqlcWallet.Parties),
+		Threshold:           uint32(sqlcWallet.Threshold),
+		OrganizationID:      fromPgUUID(sqlcWallet.OrganizationID),
 		CreatedAt:           sqlcWallet.CreatedAt.Time,
 		UpdatedAt:           sqlcWallet.UpdatedAt.Time,
 	}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"mpc/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const webhookColumns = `id, organization_id, name, url, events, secret, status, created_at, updated_at`
+
+const webhookEventColumns = `id, webhook_id, event, data, status, attempts, next_attempt_at, last_error,
+		response_status, delivered_at, created_at, updated_at`
+
+type WebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// WebhookDelivery is a claimed webhook event with the webhook to post it to
+type WebhookDelivery struct {
+	Event  model.WebhookEvent
+	URL    string
+	Secret string
+}
+
+// CreateWebhook creates a new webhook
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, organization_id, name, url, events, secret, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		webhook.ID, webhook.OrganizationID, webhook.Name, webhook.URL, webhook.Events,
+		webhook.Secret, webhook.Status, webhook.CreatedAt, webhook.UpdatedAt,
+	)
+	return err
+}
+
+// GetWebhook retrieves a webhook of an organization, pgx.ErrNoRows if there is none
+func (r *WebhookRepository) GetWebhook(ctx context.Context, orgID, webhookID uuid.UUID) (*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND organization_id = $2`
+
+	webhook, err := scanWebhook(r.db.QueryRow(ctx, query, webhookID, orgID))
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetWebhooksByOrganization gets all webhooks of an organization
+func (r *WebhookRepository) GetWebhooksByOrganization(ctx context.Context, orgID uuid.UUID) ([]*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE organization_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*model.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// UpdateWebhook updates the name, URL, events and status of a webhook
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET name = $3, url = $4, events = $5, status = $6, updated_at = $7
+		WHERE id = $1 AND organization_id = $2
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		webhook.ID, webhook.OrganizationID, webhook.Name, webhook.URL, webhook.Events,
+		webhook.Status, webhook.UpdatedAt,
+	)
+	return err
+}
+
+// DeleteWebhook deletes a webhook of an organization with its events, false if there is none
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, orgID, webhookID uuid.UUID) (bool, error) {
+	query := `DELETE FROM webhooks WHERE id = $1 AND organization_id = $2`
+
+	tag, err := r.db.Exec(ctx, query, webhookID, orgID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EnqueueEvent queues an event for every active webhook of the organization
+// subscribed to it and returns the number of webhooks
+func (r *WebhookRepository) EnqueueEvent(ctx context.Context, orgID uuid.UUID, event string, data json.RawMessage) (int64, error) {
+	query := `
+		INSERT INTO webhook_events (webhook_id, event, data)
+		SELECT id, $2::text, $3 FROM webhooks
+		WHERE organization_id = $1 AND status = 'active' AND events @> jsonb_build_array($2::text)
+	`
+
+	tag, err := r.db.Exec(ctx, query, orgID, event, data)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDueEvents claims up to limit pending events of active webhooks whose
+// next attempt is due, counting the attempt. A claimed event is due again
+// after lease, so it is retried if the worker stops before recording the
+// outcome. Concurrent workers never claim the same event.
+func (r *WebhookRepository) ClaimDueEvents(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_events e
+		SET attempts = e.attempts + 1,
+		    next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2),
+		    updated_at = CURRENT_TIMESTAMP
+		FROM webhooks w
+		WHERE w.id = e.webhook_id AND e.id IN (
+			SELECT d.id FROM webhook_events d
+			JOIN webhooks dw ON dw.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= CURRENT_TIMESTAMP AND dw.status = 'active'
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING e.id, e.webhook_id, e.event, e.data, e.status, e.attempts, e.next_attempt_at, e.last_error,
+		          e.response_status, e.delivered_at, e.created_at, e.updated_at, w.url, w.secret
+	`
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		e := &d.Event
+		err := rows.Scan(
+			&e.ID, &e.WebhookID, &e.Event, &e.Data, &e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError,
+			&e.ResponseStatus, &e.DeliveredAt, &e.CreatedAt, &e.UpdatedAt, &d.URL, &d.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkEventDelivered records that the webhook accepted an event
+func (r *WebhookRepository) MarkEventDelivered(ctx context.Context, eventID uuid.UUID, responseStatus int) error {
+	query := `
+		UPDATE webhook_events
+		SET status = 'delivered', response_status = $2, last_error = NULL,
+		    delivered_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, eventID, responseStatus)
+	return err
+}
+
+// MarkEventFailed records a failed attempt of an event. The event is retried
+// after retryIn, or dead if dead is set.
+func (r *WebhookRepository) MarkEventFailed(ctx context.Context, eventID uuid.UUID, responseStatus *int, lastError string, retryIn time.Duration, dead bool) error {
+	status := model.WebhookEventPending
+	if dead {
+		status = model.WebhookEventDead
+	}
+	query := `
+		UPDATE webhook_events
+		SET status = $2, response_status = $3, last_error = $4,
+		    next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $5), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, eventID, status, responseStatus, lastError, retryIn.Seconds())
+	return err
+}
+
+// GetWebhookEvents gets the events of a webhook, newest first, of any status if status is empty
+func (r *WebhookRepository) GetWebhookEvents(ctx context.Context, webhookID uuid.UUID, status string, limit, offset int) ([]model.WebhookEvent, error) {
+	query := `
+		SELECT ` + webhookEventColumns + `
+		FROM webhook_events
+		WHERE webhook_id = $1 AND ($2::text = '' OR status = $2::text)
+		ORDER BY created_at DESC LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.Query(ctx, query, webhookID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []model.WebhookEvent{}
+	for rows.Next() {
+		event, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// RedeliverEvent queues an event of a webhook again with a fresh set of
+// attempts, whatever its status. pgx.ErrNoRows if the webhook has no such event.
+func (r *WebhookRepository) RedeliverEvent(ctx context.Context, webhookID, eventID uuid.UUID) (model.WebhookEvent, error) {
+	query := `
+		UPDATE webhook_events
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND webhook_id = $2
+		RETURNING ` + webhookEventColumns
+
+	return scanWebhookEvent(r.db.QueryRow(ctx, query, eventID, webhookID))
+}
+
+func scanWebhook(row pgx.Row) (model.Webhook, error) {
+	var w model.Webhook
+	err := row.Scan(
+		&w.ID, &w.OrganizationID, &w.Name, &w.URL, &w.Events, &w.Secret, &w.Status, &w.CreatedAt, &w.UpdatedAt,
+	)
+	return w, err
+}
+
+func scanWebhookEvent(row pgx.Row) (model.WebhookEvent, error) {
+	var e model.WebhookEvent
+	err := row.Scan(
+		&e.ID, &e.WebhookID, &e.Event, &e.Data, &e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError,
+		&e.ResponseStatus, &e.DeliveredAt, &e.CreatedAt, &e.UpdatedAt,
+	)
+	return e, err
+}
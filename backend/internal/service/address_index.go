@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mpc/internal/db/redis"
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// addressStream announces the addresses of new wallets to the workers
+	addressStream = "monitored_addresses:added"
+	// addressStreamMaxLen bounds the stream, addresses trimmed before a worker
+	// read them are only indexed by a rebuild
+	addressStreamMaxLen = 1000000
+	// rebuildPageSize is the number of wallets read per query when rebuilding
+	rebuildPageSize = 5000
+	// reconcileInterval is how often the wallets created since the last
+	// reconciliation are indexed from the table, whether announced or not
+	reconcileInterval = time.Minute
+	// reconcileOverlap is read again by each reconciliation, for the wallets
+	// committed after a later one was already read
+	reconcileOverlap = time.Minute
+	// noOrganization stands for the organization of wallets without one
+	noOrganization = "none"
+)
+
+// AddressIndex is the set of monitored addresses of each chain, kept in
+// Redis. New wallets announce their address on a stream that the workers
+// consume as it comes, so an address is monitored right after its wallet is
+// created, and the index is only rebuilt from the wallets table when a chain
+// has none yet. The wallets created since the last reconciliation are also
+// read from the table every minute, so an address whose announcement failed
+// is monitored anyway.
+//
+// The addresses of a chain are sharded by their first byte into 256 hashes
+// mapping each address to its organization, monitored_addresses:<chain>:<byte>,
+// so a lookup only reads the hashes of the addresses looked up. The addresses
+// of an organization are also kept in a set of their own,
+// monitored_addresses:<chain>:org:<organization>.
+type AddressIndex struct {
+	redisClient *redis.Client
+	walletRepo  *repository.WalletRepository
+}
+
+func NewAddressIndex(redisClient *redis.Client, walletRepo *repository.WalletRepository) *AddressIndex {
+	return &AddressIndex{
+		redisClient: redisClient,
+		walletRepo:  walletRepo,
+	}
+}
+
+// bucketKey is the hash holding address among the addresses of a chain
+func bucketKey(chainID int, address string) string {
+	bucket, err := strconv.ParseUint(strings.TrimPrefix(address, "0x")[:2], 16, 8)
+	if err != nil {
+		bucket = 0
+	}
+	return fmt.Sprintf("monitored_addresses:%d:%d", chainID, bucket)
+}
+
+// organizationKey is the set of the addresses of an organization on a chain
+func organizationKey(chainID int, organization string) string {
+	return fmt.Sprintf("monitored_addresses:%d:org:%s", chainID, organization)
+}
+
+// cursorKey holds the last stream entry applied to the index of a chain
+func cursorKey(chainID int) string {
+	return fmt.Sprintf("monitored_addresses:%d:cursor", chainID)
+}
+
+// reconciledKey holds the time, in Unix milliseconds, up to which the wallets
+// table was last reconciled with the index of a chain
+func reconciledKey(chainID int) string {
+	return fmt.Sprintf("monitored_addresses:%d:reconciled", chainID)
+}
+
+// AddressAdded announces the address of a new wallet to the workers
+func (i *AddressIndex) AddressAdded(ctx context.Context, wallet model.Wallet) error {
+	organization := noOrganization
+	if wallet.OrganizationID != nil {
+		organization = wallet.OrganizationID.String()
+	}
+	return i.redisClient.XAdd(ctx, &goredis.XAddArgs{
+		Stream: addressStream,
+		MaxLen: addressStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"address":         strings.ToLower(wallet.Address),
+			"organization_id": organization,
+		},
+	}).Err()
+}
+
+// Chain returns the monitored addresses of a chain
+func (i *AddressIndex) Chain(chainID int) AddressSet {
+	return chainAddresses{index: i, chainID: chainID}
+}
+
+// Load indexes the addresses of a chain from the wallets table if the chain
+// has no index yet
+func (i *AddressIndex) Load(ctx context.Context, chainID int) error {
+	cursor, err := i.cursor(ctx, chainID)
+	if err != nil || cursor != "" {
+		return err
+	}
+	_, err = i.Rebuild(ctx, chainID)
+	return err
+}
+
+// Run keeps the index of a chain up to date with the added addresses until
+// ctx is done. A chain without an index is first indexed from the wallets table.
+func (i *AddressIndex) Run(ctx context.Context, chainID int) {
+	var cursor string
+	for {
+		var err error
+		cursor, err = i.cursor(ctx, chainID)
+		if err == nil && cursor == "" {
+			cursor, err = i.Rebuild(ctx, chainID)
+		}
+		if err == nil {
+			break
+		}
+		logger.Error("failed to index monitored addresses", err, zap.Int("chain_id", chainID))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	var reconciled time.Time
+	for ctx.Err() == nil {
+		if time.Since(reconciled) >= reconcileInterval {
+			if err := i.Reconcile(ctx, chainID); err != nil {
+				logger.Error("failed to reconcile monitored addresses", err, zap.Int("chain_id", chainID))
+			}
+			reconciled = time.Now()
+		}
+
+		streams, err := i.redisClient.XRead(ctx, &goredis.XReadArgs{
+			Streams: []string{addressStream, cursor},
+			Count:   1000,
+			Block:   5 * time.Second,
+		}).Result()
+		if stderrors.Is(err, goredis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("failed to read added addresses", err, zap.Int("chain_id", chainID))
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			if len(stream.Messages) == 0 {
+				continue
+			}
+			wallets := make([]model.Wallet, 0, len(stream.Messages))
+			for _, msg := range stream.Messages {
+				wallets = append(wallets, addedWallet(msg))
+			}
+			last := stream.Messages[len(stream.Messages)-1].ID
+			if err := i.add(ctx, chainID, wallets, last); err != nil {
+				logger.Error("failed to index added addresses", err, zap.Int("chain_id", chainID))
+				continue
+			}
+			cursor = last
+			logger.Info("monitoring added addresses", zap.Int("chain_id", chainID), zap.Int("count", len(wallets)))
+		}
+	}
+}
+
+// Rebuild indexes the addresses of all wallets for a chain and returns the
+// stream entry the index is up to date with. Addresses are only added, the
+// index of a chain is never emptied.
+func (i *AddressIndex) Rebuild(ctx context.Context, chainID int) (string, error) {
+	// Addresses added while reading the table are applied again from the stream
+	cursor := "0-0"
+	last, err := i.redisClient.XRevRangeN(ctx, addressStream, "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to read added addresses: %w", err)
+	}
+	if len(last) > 0 {
+		cursor = last[0].ID
+	}
+
+	started := time.Now()
+	indexed := 0
+	afterID := uuid.Nil
+	for {
+		wallets, err := i.walletRepo.GetWalletAddresses(ctx, afterID, rebuildPageSize)
+		if err != nil {
+			return "", err
+		}
+		if len(wallets) == 0 {
+			break
+		}
+		if err := i.add(ctx, chainID, wallets, ""); err != nil {
+			return "", err
+		}
+		indexed += len(wallets)
+		afterID = wallets[len(wallets)-1].ID
+	}
+
+	if err := i.redisClient.Set(ctx, cursorKey(chainID), cursor, 0).Err(); err != nil {
+		return "", fmt.Errorf("failed to save address cursor: %w", err)
+	}
+	if err := i.setReconciled(ctx, chainID, started); err != nil {
+		return "", err
+	}
+	logger.Info("indexed monitored addresses", zap.Int("chain_id", chainID), zap.Int("count", indexed))
+	return cursor, nil
+}
+
+// Reconcile indexes the addresses of the wallets created since the last
+// reconciliation of a chain, or since the first wallet if there was none.
+// Addresses already indexed are added again, which changes nothing.
+func (i *AddressIndex) Reconcile(ctx context.Context, chainID int) error {
+	started := time.Now()
+	since := time.Time{}
+	ms, err := i.redisClient.Get(ctx, reconciledKey(chainID)).Int64()
+	if err != nil && !stderrors.Is(err, goredis.Nil) {
+		return fmt.Errorf("failed to read reconciliation time: %w", err)
+	}
+	if err == nil {
+		since = time.UnixMilli(ms).Add(-reconcileOverlap)
+	}
+
+	indexed := 0
+	afterID := uuid.Nil
+	for {
+		wallets, err := i.walletRepo.GetWalletAddressesCreatedSince(ctx, since, afterID, rebuildPageSize)
+		if err != nil {
+			return err
+		}
+		if len(wallets) == 0 {
+			break
+		}
+		if err := i.add(ctx, chainID, wallets, ""); err != nil {
+			return err
+		}
+		indexed += len(wallets)
+		last := wallets[len(wallets)-1]
+		since, afterID = last.CreatedAt, last.ID
+	}
+
+	if err := i.setReconciled(ctx, chainID, started); err != nil {
+		return err
+	}
+	if indexed > 0 {
+		logger.Info("reconciled monitored addresses", zap.Int("chain_id", chainID), zap.Int("count", indexed))
+	}
+	return nil
+}
+
+// setReconciled records that the wallets created before t are indexed
+func (i *AddressIndex) setReconciled(ctx context.Context, chainID int, t time.Time) error {
+	if err := i.redisClient.Set(ctx, reconciledKey(chainID), t.UnixMilli(), 0).Err(); err != nil {
+		return fmt.Errorf("failed to save reconciliation time: %w", err)
+	}
+	return nil
+}
+
+// cursor returns the last stream entry applied to the index of a chain, empty
+// if the chain has no index
+func (i *AddressIndex) cursor(ctx context.Context, chainID int) (string, error) {
+	cursor, err := i.redisClient.Get(ctx, cursorKey(chainID)).Result()
+	if stderrors.Is(err, goredis.Nil) {
+		return "", nil
+	}
+	return cursor, err
+}
+
+// add indexes the addresses of wallets for a chain, moving the cursor of the
+// chain to cursor if set. Adding an address twice changes nothing.
+func (i *AddressIndex) add(ctx context.Context, chainID int, wallets []model.Wallet, cursor string) error {
+	pipe := i.redisClient.TxPipeline()
+	for _, wallet := range wallets {
+		address := strings.ToLower(wallet.Address)
+		if !common.IsHexAddress(address) {
+			continue
+		}
+		organization := noOrganization
+		if wallet.OrganizationID != nil {
+			organization = wallet.OrganizationID.String()
+		}
+		pipe.HSet(ctx, bucketKey(chainID, address), address, organization)
+		pipe.SAdd(ctx, organizationKey(chainID, organization), address)
+	}
+	if cursor != "" {
+		pipe.Set(ctx, cursorKey(chainID), cursor, 0)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// addedWallet reads an added address of the stream
+func addedWallet(msg goredis.XMessage) model.Wallet {
+	var wallet model.Wallet
+	wallet.Address, _ = msg.Values["address"].(string)
+	if organization, ok := msg.Values["organization_id"].(string); ok {
+		if id, err := uuid.Parse(organization); err == nil {
+			wallet.OrganizationID = &id
+		}
+	}
+	return wallet
+}
+
+// chainAddresses are the monitored addresses of a chain
+type chainAddresses struct {
+	index   *AddressIndex
+	chainID int
+}
+
+func (s chainAddresses) Contains(ctx context.Context, addresses []common.Address) (map[common.Address]bool, error) {
+	pipe := s.index.redisClient.Pipeline()
+	cmds := make([]*goredis.BoolCmd, len(addresses))
+	for i, addr := range addresses {
+		address := strings.ToLower(addr.Hex())
+		cmds[i] = pipe.HExists(ctx, bucketKey(s.chainID, address), address)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !stderrors.Is(err, goredis.Nil) {
+		return nil, err
+	}
+
+	monitored := make(map[common.Address]bool)
+	for i, cmd := range cmds {
+		if cmd.Val() {
+			monitored[addresses[i]] = true
+		}
+	}
+	return monitored, nil
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"mpc/internal/config"
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/ethereum"
+	"mpc/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// Backfiller records the past native and ERC-20 transfers of an address,
+// which the block scanner missed because they were mined before the address
+// was monitored. Blocks are scanned in parallel batches with the RPC requests
+// rate limited, and the transfers are recorded like the ones of the scanner,
+// so backfilling a range twice adds nothing. No events are published for
+// them.
+type Backfiller struct {
+	backfillRepo *repository.BackfillRepository
+	assetService *AssetService
+	addresses    AddressSet
+	ethClient    *ethereum.EthClient
+	cfg          config.BackfillConfig
+	chainID      int
+}
+
+func NewBackfiller(
+	cfg *config.BackfillConfig,
+	backfillRepo *repository.BackfillRepository,
+	assetService *AssetService,
+	addresses AddressSet,
+	ethClient *ethereum.EthClient,
+	chainID int,
+) *Backfiller {
+	b := &Backfiller{
+		backfillRepo: backfillRepo,
+		assetService: assetService,
+		addresses:    addresses,
+		ethClient:    ethClient,
+		cfg:          *cfg,
+		chainID:      chainID,
+	}
+	if b.cfg.BatchSize <= 0 {
+		b.cfg.BatchSize = 100
+	}
+	if b.cfg.Concurrency <= 0 {
+		b.cfg.Concurrency = 4
+	}
+	if b.cfg.RateLimit <= 0 {
+		b.cfg.RateLimit = 20
+	}
+	return b
+}
+
+// backfillRun is the state of one backfill of an address
+type backfillRun struct {
+	job           model.BackfillJob
+	account       common.Address
+	scanner       *BlockScanner // decodes transfers like the live scanner
+	tokens        map[common.Address]model.TokenResponse
+	contracts     []common.Address
+	head          uint64
+	confirmations uint64
+	limiter       *rateLimiter
+}
+
+// Run backfills the transfers of address in the blocks from fromBlock to
+// toBlock. A backfill of the same range that was interrupted resumes after
+// the last saved blocks, a completed one is returned as is. A toBlock of 0
+// resumes the unfinished backfill from fromBlock or goes to the chain head.
+func (b *Backfiller) Run(ctx context.Context, address common.Address, fromBlock, toBlock uint64) (model.BackfillJob, error) {
+	limiter := newRateLimiter(b.cfg.RateLimit)
+	defer limiter.stop()
+
+	if err := limiter.wait(ctx, 1); err != nil {
+		return model.BackfillJob{}, err
+	}
+	head, err := b.ethClient.BlockNumber(ctx)
+	if err != nil {
+		return model.BackfillJob{}, err
+	}
+	if toBlock == 0 {
+		toBlock, err = b.resumedToBlock(ctx, address, fromBlock, head)
+		if err != nil {
+			return model.BackfillJob{}, err
+		}
+	}
+	if fromBlock > toBlock || toBlock > head {
+		return model.BackfillJob{}, fmt.Errorf("invalid block range %d to %d, the chain head is %d", fromBlock, toBlock, head)
+	}
+
+	chain, err := b.assetService.GetChainByChainID(ctx, b.chainID)
+	if err != nil {
+		return model.BackfillJob{}, fmt.Errorf("failed to get chain: %w", err)
+	}
+	run := &backfillRun{
+		account: address,
+		scanner: &BlockScanner{
+			assetService: b.assetService,
+			addresses:    withAddress{AddressSet: b.addresses, address: address},
+			ethClient:    b.ethClient,
+			chainID:      b.chainID,
+		},
+		head:          head,
+		confirmations: uint64(max(chain.Confirmations, 1)),
+		limiter:       limiter,
+	}
+	run.tokens, err = run.scanner.tokens(ctx)
+	if err != nil {
+		return model.BackfillJob{}, err
+	}
+	for contract := range run.tokens {
+		run.contracts = append(run.contracts, contract)
+	}
+
+	run.job, err = b.backfillRepo.StartBackfillJob(ctx, b.chainID, strings.ToLower(address.Hex()), fromBlock, toBlock)
+	if err != nil {
+		return model.BackfillJob{}, err
+	}
+	if run.job.Status == model.BackfillCompleted {
+		return run.job, nil
+	}
+	logger.Info("backfill started", b.fields(run.job)...)
+
+	// Batches of a window are scanned in parallel, the window is saved at once
+	window := uint64(b.cfg.BatchSize * b.cfg.Concurrency)
+	for run.job.NextBlock <= run.job.ToBlock {
+		end := min(run.job.NextBlock+window-1, run.job.ToBlock)
+		txns, err := b.scanWindow(ctx, run, run.job.NextBlock, end)
+		if err == nil {
+			var created []model.Transaction
+			created, err = b.backfillRepo.SaveBackfilledBlocks(ctx, run.job, end+1, txns)
+			run.job.TransactionsFound += len(created)
+		}
+		if err != nil {
+			return b.fail(run.job, err)
+		}
+		run.job.NextBlock = end + 1
+		logger.Info("backfill progress", b.fields(run.job)...)
+	}
+
+	if err := b.backfillRepo.UpdateBackfillStatus(ctx, run.job, model.BackfillCompleted, ""); err != nil {
+		return run.job, err
+	}
+	run.job.Status = model.BackfillCompleted
+	logger.Info("backfill completed", b.fields(run.job)...)
+	return run.job, nil
+}
+
+// resumedToBlock returns the last block of a backfill started without one:
+// the one of the unfinished job from fromBlock, resumed instead of starting
+// over up to the new head, or the chain head
+func (b *Backfiller) resumedToBlock(ctx context.Context, address common.Address, fromBlock, head uint64) (uint64, error) {
+	job, ok, err := b.backfillRepo.GetUnfinishedBackfillJob(ctx, b.chainID, strings.ToLower(address.Hex()), fromBlock)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return head, nil
+	}
+	logger.Info("resuming backfill", b.fields(job)...)
+	return job.ToBlock, nil
+}
+
+// fail records the error that stopped a backfill, it resumes when run again
+func (b *Backfiller) fail(job model.BackfillJob, err error) (model.BackfillJob, error) {
+	// The context may be the one that was cancelled
+	if updateErr := b.backfillRepo.UpdateBackfillStatus(context.Background(), job, model.BackfillFailed, err.Error()); updateErr != nil {
+		logger.Error("failed to update backfill status", updateErr, zap.String("job_id", job.ID.String()))
+	}
+	job.Status, job.Error = model.BackfillFailed, err.Error()
+	return job, err
+}
+
+// scanWindow scans the blocks from from to to in parallel batches and returns
+// the transfers of the account, ordered by block
+func (b *Backfiller) scanWindow(ctx context.Context, run *backfillRun, from, to uint64) ([]model.Transaction, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batchSize := uint64(b.cfg.BatchSize)
+	batches := int((to-from)/batchSize) + 1
+	results := make([][]model.Transaction, batches)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i := 0; i < batches; i++ {
+		start := from + uint64(i)*batchSize
+		end := min(start+batchSize-1, to)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			txns, err := b.scanBatch(ctx, run, start, end)
+			if err != nil {
+				// One failed batch fails the window, the others are stopped
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to scan blocks %d to %d: %w", start, end, err)
+				}
+				mu.Unlock()
+				cancel()
+				return
+			}
+			results[i] = txns
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	var txns []model.Transaction
+	for _, batch := range results {
+		txns = append(txns, batch...)
+	}
+	return txns, nil
+}
+
+// scanBatch returns the transfers of the account in the blocks from from to to
+func (b *Backfiller) scanBatch(ctx context.Context, run *backfillRun, from, to uint64) ([]model.Transaction, error) {
+	var tokenTransfers []ethereum.TokenTransfer
+	if len(run.contracts) > 0 {
+		// One logs query for the sender and one for the recipient
+		if err := run.limiter.wait(ctx, 2); err != nil {
+			return nil, err
+		}
+		var err error
+		tokenTransfers, err = b.ethClient.AccountTokenTransfers(ctx, from, to, run.contracts, run.account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	transfersByBlock := make(map[uint64][]ethereum.TokenTransfer)
+	for _, transfer := range tokenTransfers {
+		transfersByBlock[transfer.BlockNumber] = append(transfersByBlock[transfer.BlockNumber], transfer)
+	}
+
+	// Native transfers are not indexed by address, every block is read
+	var txns []model.Transaction
+	for number := from; number <= to; number++ {
+		if err := run.limiter.wait(ctx, 1); err != nil {
+			return nil, err
+		}
+		block, err := b.ethClient.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+
+		found, err := b.blockTransfers(ctx, run, block, transfersByBlock[number])
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan block %d: %w", number, err)
+		}
+		txns = append(txns, found...)
+	}
+	return txns, nil
+}
+
+// blockTransfers returns the transfers of the account in block, the token
+// transfers of the block given
+func (b *Backfiller) blockTransfers(ctx context.Context, run *backfillRun, block *types.Block, tokenTransfers []ethereum.TokenTransfer) ([]model.Transaction, error) {
+	native, err := run.scanner.nativeTransfers(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	account := strings.ToLower(run.account.Hex())
+	var candidates []model.Transaction
+	for _, txn := range native {
+		if txn.FromAddress == account || txn.ToAddress == account {
+			candidates = append(candidates, txn)
+		}
+	}
+	for _, transfer := range tokenTransfers {
+		candidates = append(candidates, run.scanner.tokenTransaction(block, transfer, run.tokens[transfer.Contract]))
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	txns, err := run.scanner.monitoredTransfers(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if err := run.limiter.wait(ctx, len(txns)); err != nil {
+		return nil, err
+	}
+	if err := run.scanner.addReceipts(ctx, txns); err != nil {
+		return nil, err
+	}
+
+	// Old enough transfers are recorded confirmed, the tracker confirms the others
+	for i := range txns {
+		if txns[i].Status != model.TxStatusFailed && run.head-txns[i].BlockNumber+1 >= run.confirmations {
+			txns[i].Status = model.TxStatusConfirmed
+		}
+	}
+	return txns, nil
+}
+
+func (b *Backfiller) fields(job model.BackfillJob) []zap.Field {
+	return []zap.Field{
+		zap.String("job_id", job.ID.String()),
+		zap.Int("chain_id", job.ChainID),
+		zap.String("address", job.Address),
+		zap.Uint64("next_block", job.NextBlock),
+		zap.Uint64("to_block", job.ToBlock),
+		zap.String("progress", fmt.Sprintf("%.1f%%", 100*job.Progress())),
+		zap.Int("transactions_found", job.TransactionsFound),
+	}
+}
+
+// withAddress is an address set with one more address, the backfilled one
+// counts as monitored even if no wallet has it
+type withAddress struct {
+	AddressSet
+	address common.Address
+}
+
+func (s withAddress) Contains(ctx context.Context, addresses []common.Address) (map[common.Address]bool, error) {
+	monitored, err := s.AddressSet.Contains(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+	if monitored == nil {
+		monitored = make(map[common.Address]bool)
+	}
+	for _, addr := range addresses {
+		if addr == s.address {
+			monitored[addr] = true
+		}
+	}
+	return monitored, nil
+}
+
+// rateLimiter spaces requests out to at most a rate per second, shared by
+// the batches of a backfill
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	return &rateLimiter{ticker: time.NewTicker(time.Second / time.Duration(perSecond))}
+}
+
+// wait blocks until n more requests may be sent
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.ticker.C:
+		}
+	}
+	return nil
+}
+
+func (l *rateLimiter) stop() {
+	l.ticker.Stop()
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/errors"
+	"mpc/pkg/events"
+	"mpc/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// EventService tells users about their wallets: deposits, settled
+// transactions and new wallets, and queues the same events for the webhooks
+// of the organizations owning the wallets. Events are best effort, a failure
+// to publish one is logged and does not fail the operation behind it.
+type EventService struct {
+	walletRepo     *repository.WalletRepository
+	broker         *events.Broker
+	webhookService *WebhookService
+}
+
+func NewEventService(walletRepo *repository.WalletRepository, broker *events.Broker, webhookService *WebhookService) *EventService {
+	return &EventService{
+		walletRepo:     walletRepo,
+		broker:         broker,
+		webhookService: webhookService,
+	}
+}
+
+// TransferFound publishes a transfer the worker found on chain. Transfers
+// reaching a wallet are deposits of its owner, reverted ones are failed
+// transactions of the wallets involved.
+func (s *EventService) TransferFound(ctx context.Context, txn model.Transaction) {
+	if txn.Status == model.TxStatusFailed {
+		s.TransactionSettled(ctx, txn)
+		return
+	}
+	if txn.Direction != model.TxDirectionIn && txn.Direction != model.TxDirectionInternal {
+		return
+	}
+	if wallet, ok := s.wallet(ctx, txn.ToAddress); ok {
+		s.publish(ctx, wallet.UserID, model.EventDepositReceived, txn)
+		s.enqueue(ctx, wallet.OrganizationID, model.EventDepositReceived, txn)
+	}
+}
+
+// TransactionSettled publishes a confirmed or failed transaction to the
+// owners of its wallets and their organizations, once each
+func (s *EventService) TransactionSettled(ctx context.Context, txn model.Transaction) {
+	var eventType string
+	switch txn.Status {
+	case model.TxStatusConfirmed:
+		eventType = model.EventTransactionConfirmed
+	case model.TxStatusFailed:
+		eventType = model.EventTransactionFailed
+	default:
+		return
+	}
+
+	notified := make(map[uuid.UUID]bool)
+	for _, address := range []string{txn.FromAddress, txn.ToAddress} {
+		wallet, ok := s.wallet(ctx, address)
+		if !ok {
+			continue
+		}
+		if !notified[wallet.UserID] {
+			notified[wallet.UserID] = true
+			s.publish(ctx, wallet.UserID, eventType, txn)
+		}
+		if wallet.OrganizationID != nil && !notified[*wallet.OrganizationID] {
+			notified[*wallet.OrganizationID] = true
+			s.enqueue(ctx, wallet.OrganizationID, eventType, txn)
+		}
+	}
+}
+
+// WalletCreated publishes a new wallet to its owner and organization
+func (s *EventService) WalletCreated(ctx context.Context, wallet model.Wallet) {
+	res := model.WalletResponse{
+		ID:      wallet.ID,
+		UserID:  wallet.UserID,
+		Address: wallet.Address,
+		Name:    wallet.Name,
+	}
+	s.publish(ctx, wallet.UserID, model.EventWalletCreated, res)
+	s.enqueue(ctx, wallet.OrganizationID, model.EventWalletCreated, res)
+}
+
+// Subscribe returns the events of a user from now on, see events.Broker
+func (s *EventService) Subscribe(userID uuid.UUID) (<-chan model.Event, func()) {
+	return s.broker.Subscribe(userID)
+}
+
+// EventsSince returns the kept events of a user after the event lastID
+func (s *EventService) EventsSince(ctx context.Context, userID uuid.UUID, lastID string) ([]model.Event, error) {
+	if !events.ValidID(lastID) {
+		return nil, errors.ErrInvalidEventID
+	}
+	return s.broker.Since(ctx, userID, lastID)
+}
+
+// wallet returns the wallet with address, false for other addresses
+func (s *EventService) wallet(ctx context.Context, address string) (model.Wallet, bool) {
+	wallet, err := s.walletRepo.GetWalletByAddress(ctx, strings.ToLower(address))
+	if err != nil {
+		if !stderrors.Is(err, pgx.ErrNoRows) {
+			logger.Error("failed to get wallet owner", err, zap.String("address", address))
+		}
+		return model.Wallet{}, false
+	}
+	return wallet, true
+}
+
+func (s *EventService) publish(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) {
+	if _, err := s.broker.Publish(ctx, userID, eventType, data); err != nil {
+		logger.Error("failed to publish event", err,
+			zap.String("user_id", userID.String()),
+			zap.String("type", eventType))
+	}
+}
+
+// enqueue queues an event for the webhooks of an organization, wallets
+// without one have none
+func (s *EventService) enqueue(ctx context.Context, orgID *uuid.UUID, eventType string, data interface{}) {
+	if orgID == nil || s.webhookService == nil {
+		return
+	}
+	s.webhookService.Enqueue(ctx, *orgID, eventType, data)
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"mpc/internal/config"
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/errors"
+	"mpc/pkg/ethereum"
+	"mpc/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// AddressSet holds the addresses the worker monitors
+type AddressSet interface {
+	// Contains returns the monitored addresses among addresses
+	Contains(ctx context.Context, addresses []common.Address) (map[common.Address]bool, error)
+}
+
+// BlockScanner walks the blocks of a chain in order and records the native
+// and ERC-20 transfers of monitored addresses. The last scanned block is
+// stored, so the scanner catches up after downtime, and the hashes of recent
+// blocks are kept to detect reorgs.
+type BlockScanner struct {
+	blockRepo    *repository.BlockRepository
+	assetService *AssetService
+	eventService *EventService
+	addresses    AddressSet
+	ethClient    *ethereum.EthClient
+	cfg          config.ScannerConfig
+	chainID      int
+}
+
+func NewBlockScanner(
+	cfg *config.ScannerConfig,
+	blockRepo *repository.BlockRepository,
+	assetService *AssetService,
+	eventService *EventService,
+	addresses AddressSet,
+	ethClient *ethereum.EthClient,
+	chainID int,
+) *BlockScanner {
+	s := &BlockScanner{
+		blockRepo:    blockRepo,
+		assetService: assetService,
+		eventService: eventService,
+		addresses:    addresses,
+		ethClient:    ethClient,
+		cfg:          *cfg,
+		chainID:      chainID,
+	}
+	if s.cfg.PollInterval <= 0 {
+		s.cfg.PollInterval = 10 * time.Second
+	}
+	if s.cfg.BatchSize <= 0 {
+		s.cfg.BatchSize = 100
+	}
+	if s.cfg.ReorgWindow <= 0 {
+		s.cfg.ReorgWindow = 128
+	}
+	return s
+}
+
+// Run scans new blocks until ctx is done. While behind the chain head it
+// scans batch after batch without waiting.
+func (s *BlockScanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		behind, err := s.Poll(ctx)
+		if err != nil {
+			logger.Error("failed to scan blocks", err, zap.Int("chain_id", s.chainID))
+		}
+
+		if behind && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll scans up to one batch of blocks after the last scanned one and reports
+// whether the scanner is still behind the chain head. A chain never scanned
+// starts at its latest block.
+func (s *BlockScanner) Poll(ctx context.Context) (bool, error) {
+	head, err := s.ethClient.BlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	next, parentHash := head, ""
+	last, err := s.blockRepo.GetLastScannedBlock(ctx, s.chainID)
+	if err == nil {
+		next, parentHash = last.Number+1, last.Hash
+	} else if !stderrors.Is(err, repository.ErrNoScannedBlock) {
+		return false, err
+	}
+
+	for scanned := 0; scanned < s.cfg.BatchSize && next <= head; scanned++ {
+		block, err := s.ethClient.BlockByNumber(ctx, new(big.Int).SetUint64(next))
+		if err != nil {
+			return false, err
+		}
+
+		if parentHash != "" && block.ParentHash().Hex() != parentHash {
+			next, parentHash, err = s.rollback(ctx, next-1)
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if err := s.scanBlock(ctx, block); err != nil {
+			return false, fmt.Errorf("failed to scan block %d: %w", next, err)
+		}
+		next, parentHash = next+1, block.Hash().Hex()
+	}
+
+	// Only the latest blocks can be reorged
+	if next > uint64(s.cfg.ReorgWindow) {
+		if err := s.blockRepo.PruneScannedBlocks(ctx, s.chainID, next-uint64(s.cfg.ReorgWindow)); err != nil {
+			logger.Error("failed to prune scanned blocks", err, zap.Int("chain_id", s.chainID))
+		}
+	}
+	return next <= head, nil
+}
+
+// scanBlock records the native and ERC-20 transfers of monitored addresses
+// in block together with the block
+func (s *BlockScanner) scanBlock(ctx context.Context, block *types.Block) error {
+	candidates, err := s.nativeTransfers(ctx, block)
+	if err != nil {
+		return err
+	}
+	tokenTransfers, err := s.tokenTransfers(ctx, block)
+	if err != nil {
+		return err
+	}
+	candidates = append(candidates, tokenTransfers...)
+
+	txns, err := s.monitoredTransfers(ctx, candidates)
+	if err != nil {
+		return err
+	}
+	if err := s.addReceipts(ctx, txns); err != nil {
+		return err
+	}
+	for _, txn := range txns {
+		logger.Info("transfer found",
+			zap.Int("chain_id", s.chainID),
+			zap.Uint64("block", txn.BlockNumber),
+			zap.String("tx_hash", txn.TxHash),
+			zap.String("direction", string(txn.Direction)),
+			zap.String("from", txn.FromAddress),
+			zap.String("to", txn.ToAddress),
+			zap.String("amount", txn.Amount),
+			zap.String("symbol", txn.Symbol))
+	}
+
+	created, err := s.blockRepo.SaveScannedBlock(ctx, model.ScannedBlock{
+		ChainID:    s.chainID,
+		Number:     block.NumberU64(),
+		Hash:       block.Hash().Hex(),
+		ParentHash: block.ParentHash().Hex(),
+	}, txns)
+	if err != nil {
+		return err
+	}
+	for _, txn := range created {
+		s.eventService.TransferFound(ctx, txn)
+	}
+	return nil
+}
+
+// nativeTransfers returns the transactions of block with a recipient, their
+// value in the native currency of the chain
+func (s *BlockScanner) nativeTransfers(ctx context.Context, block *types.Block) ([]model.Transaction, error) {
+	chain, err := s.assetService.GetChainByChainID(ctx, s.chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain: %w", err)
+	}
+
+	var txns []model.Transaction
+	for i, tx := range block.Transactions() {
+		// Contract creations and calls moving no ether transfer nothing
+		// natively, their token transfers are read from the logs
+		if tx.To() == nil || tx.Value().Sign() == 0 {
+			continue
+		}
+		from, err := s.ethClient.TransactionSender(ctx, tx, block.Hash(), uint(i))
+		if err != nil {
+			return nil, err
+		}
+
+		txn := s.scannedTransaction(block, tx.Hash(), from, *tx.To())
+		txn.Symbol = chain.NativeCurrency
+		txn.Amount = ethereum.FromBaseUnits(tx.Value(), 18)
+		txn.RawAmount = tx.Value().String()
+		txns = append(txns, txn)
+	}
+	return txns, nil
+}
+
+// tokenTransfers returns the transfers of block on the ERC-20 tokens
+// registered for the chain, read from their Transfer logs
+func (s *BlockScanner) tokenTransfers(ctx context.Context, block *types.Block) ([]model.Transaction, error) {
+	tokens, err := s.tokens(ctx)
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+	contracts := make([]common.Address, 0, len(tokens))
+	for contract := range tokens {
+		contracts = append(contracts, contract)
+	}
+
+	transfers, err := s.ethClient.TokenTransfers(ctx, block.Hash(), contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	txns := make([]model.Transaction, 0, len(transfers))
+	for _, transfer := range transfers {
+		txns = append(txns, s.tokenTransaction(block, transfer, tokens[transfer.Contract]))
+	}
+	return txns, nil
+}
+
+// tokenTransaction returns the record of an ERC-20 transfer found in block
+func (s *BlockScanner) tokenTransaction(block *types.Block, transfer ethereum.TokenTransfer, token model.TokenResponse) model.Transaction {
+	logIndex := transfer.LogIndex
+
+	txn := s.scannedTransaction(block, transfer.TxHash, transfer.From, transfer.To)
+	txn.Symbol = token.Symbol
+	txn.Amount = ethereum.FromBaseUnits(transfer.Amount, token.Decimals)
+	txn.RawAmount = transfer.Amount.String()
+	txn.ContractAddress = strings.ToLower(transfer.Contract.Hex())
+	txn.LogIndex = &logIndex
+	return txn
+}
+
+// tokens returns the ERC-20 tokens registered for the chain by contract
+func (s *BlockScanner) tokens(ctx context.Context) (map[common.Address]model.TokenResponse, error) {
+	registered, err := s.assetService.GetTokensByChainID(ctx, s.chainID)
+	if stderrors.Is(err, errors.ErrTokenNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+
+	tokens := make(map[common.Address]model.TokenResponse)
+	for _, token := range registered {
+		if token.Type == model.TokenTypeERC20 && common.IsHexAddress(token.ContractAddress) {
+			tokens[common.HexToAddress(token.ContractAddress)] = token
+		}
+	}
+	return tokens, nil
+}
+
+// monitoredTransfers returns the transfers from or to a monitored address
+// with their direction
+func (s *BlockScanner) monitoredTransfers(ctx context.Context, candidates []model.Transaction) ([]model.Transaction, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	addresses := make([]common.Address, 0, 2*len(candidates))
+	for _, txn := range candidates {
+		addresses = append(addresses, common.HexToAddress(txn.FromAddress), common.HexToAddress(txn.ToAddress))
+	}
+	monitored, err := s.addresses.Contains(ctx, addresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check monitored addresses: %w", err)
+	}
+
+	var txns []model.Transaction
+	for _, txn := range candidates {
+		fromMonitored := monitored[common.HexToAddress(txn.FromAddress)]
+		toMonitored := monitored[common.HexToAddress(txn.ToAddress)]
+		switch {
+		case fromMonitored && toMonitored:
+			txn.Direction = model.TxDirectionInternal
+		case fromMonitored:
+			txn.Direction = model.TxDirectionOut
+		case toMonitored:
+			txn.Direction = model.TxDirectionIn
+		default:
+			continue
+		}
+		txns = append(txns, txn)
+	}
+	return txns, nil
+}
+
+// addReceipts adds the gas and fee paid to the transfers from the receipts
+// of their transactions. Reverted transactions failed.
+func (s *BlockScanner) addReceipts(ctx context.Context, txns []model.Transaction) error {
+	receipts := make(map[string]*types.Receipt)
+	for i := range txns {
+		txn := &txns[i]
+		receipt, ok := receipts[txn.TxHash]
+		if !ok {
+			var err error
+			receipt, err = s.ethClient.TransactionReceipt(ctx, common.HexToHash(txn.TxHash))
+			if err != nil {
+				return err
+			}
+			if receipt == nil {
+				return fmt.Errorf("receipt of %s not found", txn.TxHash)
+			}
+			receipts[txn.TxHash] = receipt
+		}
+
+		txn.GasUsed = receipt.GasUsed
+		if receipt.EffectiveGasPrice != nil {
+			txn.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+		}
+		txn.Fee = ethereum.ReceiptFee(receipt).String()
+		if receipt.Status == types.ReceiptStatusFailed {
+			txn.Status = model.TxStatusFailed
+			txn.FailureReason = "execution reverted"
+		}
+	}
+	return nil
+}
+
+// scannedTransaction returns the record of a transfer found in block. It is
+// mined, the tracker confirms it.
+func (s *BlockScanner) scannedTransaction(block *types.Block, hash common.Hash, from, to common.Address) model.Transaction {
+	blockTime := time.Unix(int64(block.Time()), 0).UTC()
+	txn := model.Transaction{
+		TxHash:      strings.ToLower(hash.Hex()),
+		FromAddress: strings.ToLower(from.Hex()),
+		ToAddress:   strings.ToLower(to.Hex()),
+		ChainID:     s.chainID,
+		Status:      model.TxStatusPending,
+		Source:      model.TxSourceScanner,
+		BlockNumber: block.NumberU64(),
+		BlockHash:   block.Hash().Hex(),
+		BlockTime:   &blockTime,
+	}
+	if tx := block.Transaction(hash); tx != nil {
+		txn.GasLimit = tx.Gas()
+	}
+	return txn
+}
+
+// rollback handles a reorg below block number. It finds the latest scanned
+// block still on the canonical chain, forgets the blocks after it with the
+// transactions found in them and returns where scanning resumes. Transactions
+// sent through the API are left to the tracker, which follows their receipts.
+func (s *BlockScanner) rollback(ctx context.Context, number uint64) (uint64, string, error) {
+	blocks, err := s.blockRepo.GetScannedBlocks(ctx, s.chainID, s.cfg.ReorgWindow)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(blocks) == 0 {
+		return number + 1, "", nil
+	}
+
+	var ancestor *model.ScannedBlock
+	for i, block := range blocks {
+		if block.Number > number {
+			continue
+		}
+		header, err := s.ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(block.Number))
+		if err != nil {
+			return 0, "", err
+		}
+		if header.Hash().Hex() == block.Hash {
+			ancestor = &blocks[i]
+			break
+		}
+	}
+
+	// Deeper than the kept hashes, rescan all of them
+	resume, parentHash := blocks[len(blocks)-1].Number, ""
+	if ancestor != nil {
+		resume, parentHash = ancestor.Number+1, ancestor.Hash
+	} else {
+		logger.Warn("reorg deeper than the reorg window",
+			zap.Int("chain_id", s.chainID),
+			zap.Int("reorg_window", s.cfg.ReorgWindow))
+	}
+
+	removed, err := s.blockRepo.RollbackScannedBlocks(ctx, s.chainID, resume-1)
+	if err != nil {
+		return 0, "", err
+	}
+	logger.Warn("reorg detected",
+		zap.Int("chain_id", s.chainID),
+		zap.Uint64("block", number),
+		zap.Uint64("resume_at", resume),
+		zap.Int("removed_transactions", removed))
+	return resume, parentHash, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"mpc/internal/config"
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/ethereum"
+	"mpc/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// trackedStatuses are the statuses that can still change on chain
+var trackedStatuses = []model.TransactionStatus{
+	model.TxStatusBroadcast,
+	model.TxStatusPending,
+	model.TxStatusDropped,
+}
+
+// TransactionTracker follows the broadcast transactions of a chain until
+// they are confirmed, failed, dropped or replaced
+type TransactionTracker struct {
+	txnRepo      *repository.TransactionRepository
+	assetService *AssetService
+	eventService *EventService
+	ethClient    *ethereum.EthClient
+	nonces       *ethereum.NonceManager
+	cfg          config.TrackerConfig
+	chainID      int
+}
+
+func NewTransactionTracker(
+	cfg *config.TrackerConfig,
+	txnRepo *repository.TransactionRepository,
+	assetService *AssetService,
+	eventService *EventService,
+	ethClient *ethereum.EthClient,
+	nonces *ethereum.NonceManager,
+	chainID int,
+) *TransactionTracker {
+	t := &TransactionTracker{
+		txnRepo:      txnRepo,
+		assetService: assetService,
+		eventService: eventService,
+		ethClient:    ethClient,
+		nonces:       nonces,
+		cfg:          *cfg,
+		chainID:      chainID,
+	}
+	if t.cfg.PollInterval <= 0 {
+		t.cfg.PollInterval = 15 * time.Second
+	}
+	if t.cfg.BatchSize <= 0 {
+		t.cfg.BatchSize = 100
+	}
+	return t
+}
+
+// Run polls the tracked transactions until ctx is done
+func (t *TransactionTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.Poll(ctx); err != nil {
+			logger.Error("failed to track transactions", err, zap.Int("chain_id", t.chainID))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll checks the receipts of one batch of tracked transactions. Checked
+// transactions are touched, so the next batch starts with the others.
+func (t *TransactionTracker) Poll(ctx context.Context) error {
+	chain, err := t.assetService.GetChainByChainID(ctx, t.chainID)
+	if err != nil {
+		return fmt.Errorf("failed to get chain: %w", err)
+	}
+	confirmations := max(chain.Confirmations, 1)
+
+	head, err := t.ethClient.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	txns, err := t.txnRepo.GetTransactionsByStatus(ctx, t.chainID, trackedStatuses, t.cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, txn := range txns {
+		err := t.check(ctx, txn, head, confirmations)
+		// Moved by someone else meanwhile, checked again on the next poll
+		if stderrors.Is(err, repository.ErrTransactionStatusChanged) {
+			continue
+		}
+		if err != nil {
+			logger.Error("failed to check transaction", err, zap.String("tx_hash", txn.TxHash))
+		}
+	}
+	return nil
+}
+
+// check moves a transaction according to its receipt
+func (t *TransactionTracker) check(ctx context.Context, txn model.Transaction, head uint64, confirmations int) error {
+	receipt, err := t.ethClient.TransactionReceipt(ctx, common.HexToHash(txn.TxHash))
+	if err != nil {
+		return err
+	}
+	if receipt == nil {
+		return t.checkUnmined(ctx, txn)
+	}
+
+	block := receipt.BlockNumber.Uint64()
+	depth := 0
+	if head >= block {
+		depth = int(head-block) + 1
+	}
+
+	update := model.TransactionUpdate{
+		Status:        model.TxStatusPending,
+		GasUsed:       receipt.GasUsed,
+		BlockNumber:   block,
+		BlockHash:     receipt.BlockHash.Hex(),
+		Confirmations: &depth,
+	}
+	if receipt.EffectiveGasPrice != nil {
+		update.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+		update.Fee = ethereum.ReceiptFee(receipt).String()
+	}
+	if depth >= confirmations {
+		update.Status = model.TxStatusConfirmed
+		if receipt.Status == types.ReceiptStatusFailed {
+			update.Status = model.TxStatusFailed
+			update.Reason = "execution reverted"
+		}
+	}
+	return t.update(ctx, txn, update)
+}
+
+// checkUnmined handles a transaction without receipt
+func (t *TransactionTracker) checkUnmined(ctx context.Context, txn model.Transaction) error {
+	// Mined before, the block was reorged out
+	if txn.Status == model.TxStatusPending {
+		zero := 0
+		return t.update(ctx, txn, model.TransactionUpdate{
+			Status:        model.TxStatusBroadcast,
+			Reason:        fmt.Sprintf("block %d reorged out", txn.BlockNumber),
+			Confirmations: &zero,
+			ClearReceipt:  true,
+		})
+	}
+
+	// Another transaction of the sender was mined with the same nonce
+	if txn.Nonce != nil {
+		replaced, err := t.isNonceTaken(ctx, txn)
+		if err != nil {
+			return err
+		}
+		if replaced {
+			reason := fmt.Sprintf("nonce %d used by another transaction", *txn.Nonce)
+			if txn.ReplacedByID != nil {
+				reason = fmt.Sprintf("nonce %d used by replacement %s", *txn.Nonce, txn.ReplacedByID)
+			}
+			return t.update(ctx, txn, model.TransactionUpdate{
+				Status: model.TxStatusReplaced,
+				Reason: reason,
+			})
+		}
+	}
+
+	// Nodes evict a transaction once it is replaced, it waits for its nonce instead
+	if txn.Status == model.TxStatusBroadcast && txn.ReplacedByID == nil && time.Since(txn.CreatedAt) > t.cfg.DropTimeout &&
+		!t.ethClient.IsKnownTransaction(ctx, common.HexToHash(txn.TxHash)) {
+		if err := t.update(ctx, txn, model.TransactionUpdate{
+			Status: model.TxStatusDropped,
+			Reason: "not found in the mempool",
+		}); err != nil {
+			return err
+		}
+		// The nonce blocks every later transaction of the sender until reused
+		if txn.Nonce != nil {
+			if err := t.nonces.Dropped(ctx, big.NewInt(int64(t.chainID)), common.HexToAddress(txn.FromAddress), *txn.Nonce, txn.TxHash); err != nil {
+				logger.Error("failed to release nonce of dropped transaction", err, zap.String("tx_hash", txn.TxHash))
+			}
+		}
+		return nil
+	}
+
+	return t.update(ctx, txn, model.TransactionUpdate{Status: txn.Status})
+}
+
+// isNonceTaken reports whether the nonce of txn was used by a mined transaction
+// other than txn
+func (t *TransactionTracker) isNonceTaken(ctx context.Context, txn model.Transaction) (bool, error) {
+	mined, err := t.ethClient.MinedNonce(ctx, common.HexToAddress(txn.FromAddress))
+	if err != nil {
+		return false, err
+	}
+	if mined <= *txn.Nonce {
+		return false, nil
+	}
+
+	// txn itself may have been mined since its receipt was fetched
+	receipt, err := t.ethClient.TransactionReceipt(ctx, common.HexToHash(txn.TxHash))
+	if err != nil {
+		return false, err
+	}
+	return receipt == nil, nil
+}
+
+func (t *TransactionTracker) update(ctx context.Context, txn model.Transaction, update model.TransactionUpdate) error {
+	updated, err := t.txnRepo.UpdateTransactionStatus(ctx, txn.ID, txn.Status, update)
+	if err != nil {
+		return err
+	}
+	if updated.Status != txn.Status {
+		logger.Info("transaction status changed",
+			zap.String("tx_hash", txn.TxHash),
+			zap.String("from", string(txn.Status)),
+			zap.String("to", string(updated.Status)))
+		t.eventService.TransactionSettled(ctx, updated)
+	}
+	return nil
+}
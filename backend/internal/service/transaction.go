@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"math/big"
 	"strconv"
@@ -12,35 +13,50 @@ import (
 	"mpc/pkg/errors"
 	"mpc/pkg/ethereum"
 	"mpc/pkg/logger"
+	"mpc/pkg/policy"
+	"mpc/pkg/tracing"
 	"mpc/pkg/tss"
 	"mpc/pkg/utils"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 type TransactionService struct {
 	txnRepo       *repository.TransactionRepository
 	assetService  *AssetService
 	walletService *WalletService
-	ethClient     *ethereum.EthClient
-	tssClient     *tss.TSS
+	ethClients    *ethereum.Registry
+	tssClient     tss.Client
+	policyClient  *policy.Client
+	nonces        *ethereum.NonceManager
+	eventService  *EventService
 }
 
 func NewTransactionService(
 	txnRepo *repository.TransactionRepository,
 	walletService *WalletService,
 	assetService *AssetService,
-	ethClient *ethereum.EthClient,
-	tssClient *tss.TSS,
+	ethClients *ethereum.Registry,
+	tssClient tss.Client,
+	policyClient *policy.Client,
+	nonces *ethereum.NonceManager,
+	eventService *EventService,
 ) *TransactionService {
 	return &TransactionService{
 		txnRepo:       txnRepo,
 		assetService:  assetService,
 		walletService: walletService,
-		ethClient:     ethClient,
+		ethClients:    ethClients,
 		tssClient:     tssClient,
+		policyClient:  policyClient,
+		nonces:        nonces,
+		eventService:  eventService,
 	}
 }
 
@@ -102,11 +118,157 @@ func (s *TransactionService) CreateAndSubmitTransaction(
 	userID uuid.UUID,
 	req model.CreateAndSubmitTransactionRequest,
 ) (model.Transaction, error) {
-	// Validate request
-	if err := s.validateRequest(req); err != nil {
+	wallet, token, ethClient, err := s.prepareTransfer(ctx, userID, &req)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	speed, err := ethClient.ParseSpeed(req.Speed)
+	if err != nil {
+		return model.Transaction{}, errors.ErrInvalidRequest
+	}
+
+	// Lưu giao dịch trước khi ký, trạng thái được cập nhật theo từng bước
+	txn, err := s.createTransactionRecord(ctx, req)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	build := transferBuilder(ethClient, token, req, speed)
+	return s.handleTxn(ctx, ethClient, txn, wallet, build, token.Type == model.TokenTypeERC20, req.ShareData)
+}
+
+// SimulateTransaction builds the transaction of a create request and runs it
+// against the pending block without signing or sending it.
+func (s *TransactionService) SimulateTransaction(
+	ctx context.Context,
+	userID uuid.UUID,
+	req model.CreateAndSubmitTransactionRequest,
+) (model.SimulationResult, error) {
+	_, token, ethClient, err := s.prepareTransfer(ctx, userID, &req)
+	if err != nil {
+		return model.SimulationResult{}, err
+	}
+	speed, err := ethClient.ParseSpeed(req.Speed)
+	if err != nil {
+		return model.SimulationResult{}, errors.ErrInvalidRequest
+	}
+
+	build := transferBuilder(ethClient, token, req, speed)
+	return s.simulate(ctx, ethClient, req.FromAddress, build, token.Type == model.TokenTypeERC20)
+}
+
+// CreateContractCall creates and submits a contract call of the user's wallet.
+func (s *TransactionService) CreateContractCall(
+	ctx context.Context,
+	userID uuid.UUID,
+	req model.ContractCallRequest,
+) (model.Transaction, error) {
+	wallet, ethClient, data, err := s.prepareContractCall(ctx, userID, &req)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	speed, err := ethClient.ParseSpeed(req.Speed)
+	if err != nil {
+		return model.Transaction{}, errors.ErrInvalidRequest
+	}
+	chain, err := s.assetService.GetChainByChainID(ctx, req.ChainID)
+	if err != nil {
 		return model.Transaction{}, err
 	}
 
+	// Calldata được lưu lại để tăng tốc giao dịch thì dựng lại đúng lời gọi
+	txn, err := s.txnRepo.CreateTransaction(ctx, model.Transaction{
+		FromAddress: req.FromAddress,
+		ToAddress:   req.ToAddress,
+		ChainID:     req.ChainID,
+		Status:      model.TxStatusCreated,
+		Symbol:      chain.NativeCurrency,
+		Amount:      req.Value,
+		Data:        hexutil.Encode(data),
+	})
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to create transaction record: %w", err)
+	}
+	build := contractCallBuilder(ethClient, req, data, speed)
+	return s.handleTxn(ctx, ethClient, txn, wallet, build, false, req.ShareData)
+}
+
+// SimulateContractCall builds a contract call and runs it against the
+// pending block without signing or sending it.
+func (s *TransactionService) SimulateContractCall(
+	ctx context.Context,
+	userID uuid.UUID,
+	req model.ContractCallRequest,
+) (model.SimulationResult, error) {
+	_, ethClient, data, err := s.prepareContractCall(ctx, userID, &req)
+	if err != nil {
+		return model.SimulationResult{}, err
+	}
+	speed, err := ethClient.ParseSpeed(req.Speed)
+	if err != nil {
+		return model.SimulationResult{}, errors.ErrInvalidRequest
+	}
+
+	return s.simulate(ctx, ethClient, req.FromAddress, contractCallBuilder(ethClient, req, data, speed), false)
+}
+
+// simulate builds a transaction with the pending nonce of from and runs it
+// against the pending block. A revert is part of the result, not an error.
+func (s *TransactionService) simulate(
+	ctx context.Context,
+	ethClient *ethereum.EthClient,
+	from string,
+	build txBuilder,
+	gasOnly bool,
+) (model.SimulationResult, error) {
+	nonce, err := ethClient.PendingNonce(ctx, common.HexToAddress(from))
+	if err != nil {
+		return model.SimulationResult{}, err
+	}
+
+	// Giao dịch revert ngay khi ước lượng gas thì không dựng được
+	tx, err := build(ctx, nonce)
+	var rev *ethereum.RevertError
+	if stderrors.As(err, &rev) {
+		return model.SimulationResult{Revert: revertDetails(rev)}, nil
+	}
+	if err != nil {
+		return model.SimulationResult{}, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	if err := s.checkFunds(ctx, ethClient, from, tx, gasOnly); err != nil {
+		return model.SimulationResult{}, err
+	}
+
+	result := model.SimulationResult{
+		GasLimit:             tx.Gas(),
+		MaxFeePerGas:         tx.GasFeeCap().String(),
+		MaxPriorityFeePerGas: tx.GasTipCap().String(),
+		MaxFee:               new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas())).String(),
+	}
+	sim, err := ethClient.Simulate(ctx, common.HexToAddress(from), tx)
+	if stderrors.As(err, &rev) {
+		result.Revert = revertDetails(rev)
+		return result, nil
+	}
+	if err != nil {
+		return model.SimulationResult{}, err
+	}
+	result.Success = true
+	result.GasUsed = sim.GasUsed
+	return result, nil
+}
+
+// prepareTransfer validates a create request against the user's wallet and
+// resolves the token and the client of its chain.
+func (s *TransactionService) prepareTransfer(
+	ctx context.Context,
+	userID uuid.UUID,
+	req *model.CreateAndSubmitTransactionRequest,
+) (model.Wallet, model.TokenResponse, *ethereum.EthClient, error) {
+	// Validate request
+	if err := s.validateRequest(*req); err != nil {
+		return model.Wallet{}, model.TokenResponse{}, nil, err
+	}
+
 	req.FromAddress = strings.ToLower(req.FromAddress)
 	req.ToAddress = strings.ToLower(req.ToAddress)
 
@@ -114,29 +276,109 @@ func (s *TransactionService) CreateAndSubmitTransaction(
 	wallet, err := s.walletService.GetWalletByUserID(ctx, userID)
 	if err != nil {
 		logger.Error("failed to get wallet by user ID", err)
-		return model.Transaction{}, errors.ErrInvalidRequest
+		return model.Wallet{}, model.TokenResponse{}, nil, errors.ErrInvalidRequest
 	}
 
 	if !strings.EqualFold(wallet.Address, req.FromAddress) {
 		logger.Warn("wallet address does not match the from address in the request")
-		return model.Transaction{}, errors.ErrInvalidRequest
+		return model.Wallet{}, model.TokenResponse{}, nil, errors.ErrInvalidRequest
 	}
 
-	// Check if the wallet has enough balance
-	enough, err := s.ethClient.IsEnoughBalance(ctx, req.FromAddress, req.Amount)
+	// Resolve the asset to transfer
+	token, err := s.assetService.GetTokenBySymbol(ctx, req.ChainID, req.Symbol)
 	if err != nil {
-		return model.Transaction{}, fmt.Errorf("failed to check balance: %w", err)
+		return model.Wallet{}, model.TokenResponse{}, nil, err
 	}
-	if !enough {
-		return model.Transaction{}, errors.ErrInssuficientBalance
+	if token.Type != model.TokenTypeNative && token.Type != model.TokenTypeERC20 {
+		return model.Wallet{}, model.TokenResponse{}, nil, errors.ErrUnsupportedTokenType
 	}
 
-	hash, err := s.handleTxn(ctx, userID.String(), req)
+	ethClient, err := chainClient(ctx, s.ethClients, req.ChainID)
 	if err != nil {
-		return model.Transaction{}, err
+		return model.Wallet{}, model.TokenResponse{}, nil, err
 	}
-	// Create transaction record in the database
-	return s.createTransactionRecord(ctx, req.FromAddress, req.ToAddress, hash, req.ChainID)
+
+	// Check if the wallet has enough tokens, native balance is checked
+	// together with the gas cost once the transaction is built
+	if token.Type == model.TokenTypeERC20 {
+		enough, err := ethClient.IsEnoughTokenBalance(ctx, token.ContractAddress, req.FromAddress, req.Amount, token.Decimals)
+		if err != nil {
+			return model.Wallet{}, model.TokenResponse{}, nil, fmt.Errorf("failed to check balance: %w", err)
+		}
+		if !enough {
+			return model.Wallet{}, model.TokenResponse{}, nil, errors.ErrInssuficientBalance
+		}
+	}
+	return wallet, token, ethClient, nil
+}
+
+// prepareContractCall validates a contract call against the user's wallet
+// and resolves the client of its chain and the calldata.
+func (s *TransactionService) prepareContractCall(
+	ctx context.Context,
+	userID uuid.UUID,
+	req *model.ContractCallRequest,
+) (model.Wallet, *ethereum.EthClient, []byte, error) {
+	if !common.IsHexAddress(req.FromAddress) || !common.IsHexAddress(req.ToAddress) {
+		return model.Wallet{}, nil, nil, errors.ErrInvalidAddress
+	}
+	req.FromAddress = strings.ToLower(req.FromAddress)
+	req.ToAddress = strings.ToLower(req.ToAddress)
+
+	if req.Value == "" {
+		req.Value = "0"
+	}
+	value, err := ethereum.ToBaseUnits(req.Value, 18)
+	if err != nil || value.Sign() < 0 {
+		return model.Wallet{}, nil, nil, errors.ErrInvalidAmount
+	}
+
+	var data []byte
+	if len(req.ABI) > 0 {
+		data, err = ethereum.EncodeCall(string(req.ABI), req.Method, req.Args)
+	} else {
+		data, err = hexutil.Decode(req.Data)
+	}
+	if err != nil {
+		return model.Wallet{}, nil, nil, errors.ErrInvalidCalldata.WithDetails("invalid calldata: "+err.Error(), nil)
+	}
+
+	wallet, err := s.walletService.GetWalletByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("failed to get wallet by user ID", err)
+		return model.Wallet{}, nil, nil, errors.ErrInvalidRequest
+	}
+	if !strings.EqualFold(wallet.Address, req.FromAddress) {
+		logger.Warn("wallet address does not match the from address in the request")
+		return model.Wallet{}, nil, nil, errors.ErrInvalidRequest
+	}
+
+	ethClient, err := chainClient(ctx, s.ethClients, req.ChainID)
+	if err != nil {
+		return model.Wallet{}, nil, nil, err
+	}
+	return wallet, ethClient, data, nil
+}
+
+// GetTransaction retrieves a transaction of the user's wallet with its status history.
+func (s *TransactionService) GetTransaction(ctx context.Context, userID uuid.UUID, id uuid.UUID) (model.Transaction, error) {
+	wallet, err := s.walletService.GetWalletByUserID(ctx, userID)
+	if err != nil {
+		return model.Transaction{}, errors.ErrWalletNotFound
+	}
+
+	txn, err := s.txnRepo.GetTransactionByID(ctx, id)
+	if err != nil {
+		return model.Transaction{}, errors.ErrTransactionNotFound
+	}
+	if !strings.EqualFold(txn.FromAddress, wallet.Address) && !strings.EqualFold(txn.ToAddress, wallet.Address) {
+		return model.Transaction{}, errors.ErrTransactionNotFound
+	}
+
+	if txn.Events, err = s.txnRepo.GetTransactionEvents(ctx, id); err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to get transaction events: %w", err)
+	}
+	return txn, nil
 }
 
 // validateRequest validates the transaction request.
@@ -156,13 +398,15 @@ func (s *TransactionService) validateRequest(req model.CreateAndSubmitTransactio
 // createTransactionRecord creates a new transaction record in the database.
 func (s *TransactionService) createTransactionRecord(
 	ctx context.Context,
-	fromAddress, toAddress, txHash string, chainID int,
+	req model.CreateAndSubmitTransactionRequest,
 ) (model.Transaction, error) {
 	txn := model.Transaction{
-		FromAddress: fromAddress,
-		ToAddress:   toAddress,
-		TxHash:      txHash,
-		ChainID:     chainID,
+		FromAddress: req.FromAddress,
+		ToAddress:   req.ToAddress,
+		ChainID:     req.ChainID,
+		Status:      model.TxStatusCreated,
+		Symbol:      req.Symbol,
+		Amount:      req.Amount,
 	}
 
 	// Save transaction in the repository
@@ -174,48 +418,428 @@ func (s *TransactionService) createTransactionRecord(
 	return createdTxn, nil
 }
 
-func (s *TransactionService) handleTxn(ctx context.Context, userID string, req model.CreateAndSubmitTransactionRequest) (string, error) {
-	// Validate chain ID (Sepolia testnet: 11155111)
-	chainID := big.NewInt(11155111)
-	if req.ChainID != 0 && req.ChainID != int(chainID.Int64()) {
-		return "", fmt.Errorf("invalid chain ID: got %d, want %d", req.ChainID, chainID.Uint64())
+// transition moves txn to a new status and keeps the stored copy in txn.
+func (s *TransactionService) transition(ctx context.Context, txn *model.Transaction, update model.TransactionUpdate) error {
+	updated, err := s.txnRepo.UpdateTransactionStatus(ctx, txn.ID, txn.Status, update)
+	if err != nil {
+		return err
+	}
+	*txn = updated
+	if updated.Status == model.TxStatusFailed {
+		s.eventService.TransactionSettled(ctx, updated)
 	}
+	return nil
+}
 
-	// Validate addresses
-	if !common.IsHexAddress(req.FromAddress) || !common.IsHexAddress(req.ToAddress) {
-		return "", fmt.Errorf("invalid address format")
+// handleTxn reserves a nonce for txn, builds its transaction with build,
+// checks the balance and simulates it, then signs and broadcasts it.
+// gasOnly tells the transferred value is not native.
+func (s *TransactionService) handleTxn(
+	ctx context.Context,
+	ethClient *ethereum.EthClient,
+	txn model.Transaction,
+	wallet model.Wallet,
+	build txBuilder,
+	gasOnly bool,
+	shareData string,
+) (_ model.Transaction, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.handleTxn", trace.WithAttributes(
+		attribute.String("tx.from", txn.FromAddress),
+		attribute.String("tx.to", txn.ToAddress),
+		attribute.Int("tx.chain_id", txn.ChainID),
+		attribute.String("tx.symbol", txn.Symbol),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	var nonce *ethereum.Nonce
+	var signedTx *types.Transaction
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Giao dịch lỗi được đánh dấu failed, trừ khi node đã nhận được nó
+		ctx := context.WithoutCancel(ctx)
+		update := model.TransactionUpdate{Status: model.TxStatusFailed, Reason: err.Error()}
+		if nonce != nil && s.settleNonce(ctx, ethClient, nonce, signedTx) {
+			update.Status = model.TxStatusBroadcast
+		}
+		if err := s.transition(ctx, &txn, update); err != nil {
+			logger.Error("failed to record transaction status", err)
+		}
+	}()
+
+	chainID := big.NewInt(int64(txn.ChainID))
+
+	// Giữ nonce cho giao dịch, trả lại nếu không ký hoặc gửi được
+	nonce, err = s.nonces.Reserve(ctx, chainID, common.HexToAddress(txn.FromAddress))
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to reserve nonce: %w", err)
 	}
 
-	// Tạo transaction
-	tx, err := s.ethClient.CreateTransaction(ctx, req.FromAddress, req.ToAddress, req.Amount)
+	tx, err := build(ctx, nonce.Value)
 	if err != nil {
-		return "", fmt.Errorf("failed to create transaction: %w", err)
+		return model.Transaction{}, revertError("failed to create transaction", err)
+	}
+	if err := s.checkFunds(ctx, ethClient, txn.FromAddress, tx, gasOnly); err != nil {
+		return model.Transaction{}, err
 	}
 
-	// Lấy transaction hash
-	signer := types.NewEIP155Signer(chainID)
-	txHash := signer.Hash(tx)
+	// Chạy thử trên pending block, giao dịch sẽ revert thì không tốn một phiên ký TSS
+	if _, err := ethClient.Simulate(ctx, common.HexToAddress(txn.FromAddress), tx); err != nil {
+		return model.Transaction{}, revertError("failed to simulate transaction", err)
+	}
 
-	// Ký bằng TSS (nhận chữ ký DER)
-	derSig, err := s.tssClient.Sign(ctx, userID, req.ShareData, txHash.Bytes())
+	// Ký và gửi, signedTx được giữ lại kể cả khi gửi lỗi để kiểm tra node đã nhận chưa
+	signedTx, err = s.signAndSend(ctx, ethClient, &txn, wallet, tx, chainID, shareData)
 	if err != nil {
-		return "", fmt.Errorf("TSS signing failed: %w", err)
+		return model.Transaction{}, err
+	}
+	if err := nonce.Sent(ctx, signedTx.Hash().Hex()); err != nil {
+		logger.Error("failed to record sent nonce", err)
 	}
+	// Từ đây tracker theo dõi receipt cho tới khi đủ số confirmation
+	if err := s.transition(ctx, &txn, model.TransactionUpdate{Status: model.TxStatusBroadcast}); err != nil {
+		logger.Error("failed to record broadcast transaction", err)
+	}
+	return txn, nil
+}
 
-	fmt.Print("from address: ", req.FromAddress)
+// txBuilder builds the transaction of a request with the given nonce
+type txBuilder func(ctx context.Context, nonce uint64) (*types.Transaction, error)
+
+// transferBuilder builds the EIP-1559 transaction of a create request, ERC-20
+// tokens call transfer on their contract.
+func transferBuilder(ethClient *ethereum.EthClient, token model.TokenResponse, req model.CreateAndSubmitTransactionRequest, speed ethereum.Speed) txBuilder {
+	chainID := big.NewInt(int64(req.ChainID))
+	return func(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+		if token.Type == model.TokenTypeERC20 {
+			return ethClient.CreateTokenTransfer(ctx, chainID, nonce, req.FromAddress, token.ContractAddress, req.ToAddress, req.Amount, token.Decimals, speed)
+		}
+		return ethClient.CreateTransaction(ctx, chainID, nonce, req.FromAddress, req.ToAddress, req.Amount, speed)
+	}
+}
+
+// contractCallBuilder builds the EIP-1559 transaction of a contract call
+func contractCallBuilder(ethClient *ethereum.EthClient, req model.ContractCallRequest, data []byte, speed ethereum.Speed) txBuilder {
+	chainID := big.NewInt(int64(req.ChainID))
+	return func(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+		value, err := ethereum.ToBaseUnits(req.Value, 18)
+		if err != nil {
+			return nil, err
+		}
+		return ethClient.CreateContractCall(ctx, chainID, nonce, req.FromAddress, req.ToAddress, value, data, req.GasLimit, speed)
+	}
+}
+
+// checkFunds checks that the native balance pays the value and the maximum
+// gas cost of tx. gasOnly tells the value is not native, so a shortfall is
+// missing gas funds.
+func (s *TransactionService) checkFunds(ctx context.Context, ethClient *ethereum.EthClient, address string, tx *types.Transaction, gasOnly bool) error {
+	enough, err := ethClient.HasFundsFor(ctx, address, tx)
+	if err != nil {
+		return fmt.Errorf("failed to check balance: %w", err)
+	}
+	if !enough {
+		if gasOnly {
+			return errors.ErrInsufficientGasFunds
+		}
+		return errors.ErrInssuficientBalance
+	}
+	return nil
+}
 
-	sig, err := utils.ConvertDERToEthSignature(derSig, txHash.Bytes(), req.FromAddress)
+// signAndSend signs tx through TSS with the wallet's key and broadcasts it,
+// moving txn through signing and signed. The signed transaction is returned
+// even if the broadcast failed.
+func (s *TransactionService) signAndSend(
+	ctx context.Context,
+	ethClient *ethereum.EthClient,
+	txn *model.Transaction,
+	wallet model.Wallet,
+	tx *types.Transaction,
+	chainID *big.Int,
+	shareData string,
+) (*types.Transaction, error) {
+	nonce := tx.Nonce()
+	err := s.transition(ctx, txn, model.TransactionUpdate{
+		Status:               model.TxStatusSigning,
+		Nonce:                &nonce,
+		GasLimit:             tx.Gas(),
+		MaxFeePerGas:         tx.GasFeeCap().String(),
+		MaxPriorityFeePerGas: tx.GasTipCap().String(),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to convert DER signature: %w", err)
+		return nil, err
+	}
+
+	// Lấy transaction hash (London signer cho giao dịch dynamic fee)
+	signer := types.NewLondonSigner(chainID)
+	txHash := signer.Hash(tx)
+
+	// Gửi kèm giao dịch chưa ký để policy service và các node tự kiểm tra hash và luật giao dịch
+	unsignedTx, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+	sig, err := tssSign(ctx, s.tssClient, s.policyClient, wallet, policy.Request{
+		MsgHash:    txHash.Bytes(),
+		UnsignedTx: unsignedTx,
+		ChainID:    chainID.Uint64(),
+	}, shareData)
+	if err != nil {
+		return nil, err
 	}
 	signedTx, err := tx.WithSignature(signer, sig)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign transaction: %w", err)
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	err = s.transition(ctx, txn, model.TransactionUpdate{
+		Status: model.TxStatusSigned,
+		TxHash: signedTx.Hash().Hex(),
+	})
+	if err != nil {
+		return nil, err
 	}
+
 	// Gửi transaction
-	txHashSent, err := s.ethClient.SendTransaction(ctx, signedTx)
+	if _, err := ethClient.SendTransaction(ctx, signedTx); err != nil {
+		return signedTx, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	return signedTx, nil
+}
+
+// SpeedUpTransaction rebroadcasts a stuck transaction with the same nonce and higher fees.
+func (s *TransactionService) SpeedUpTransaction(ctx context.Context, userID uuid.UUID, id uuid.UUID, req model.ReplaceTransactionRequest) (model.Transaction, error) {
+	return s.replaceTransaction(ctx, userID, id, req, false)
+}
+
+// CancelTransaction replaces a stuck transaction with a 0-value transfer of
+// the wallet to itself, using the same nonce and higher fees.
+func (s *TransactionService) CancelTransaction(ctx context.Context, userID uuid.UUID, id uuid.UUID, req model.ReplaceTransactionRequest) (model.Transaction, error) {
+	return s.replaceTransaction(ctx, userID, id, req, true)
+}
+
+func (s *TransactionService) replaceTransaction(ctx context.Context, userID uuid.UUID, id uuid.UUID, req model.ReplaceTransactionRequest, cancel bool) (_ model.Transaction, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.replaceTransaction", trace.WithAttributes(
+		attribute.String("tx.id", id.String()),
+		attribute.Bool("tx.cancel", cancel),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	wallet, err := s.walletService.GetWalletByUserID(ctx, userID)
+	if err != nil {
+		return model.Transaction{}, errors.ErrWalletNotFound
+	}
+
+	orig, err := s.txnRepo.GetTransactionByID(ctx, id)
+	if err != nil {
+		return model.Transaction{}, errors.ErrTransactionNotFound
+	}
+	if !strings.EqualFold(orig.FromAddress, wallet.Address) {
+		return model.Transaction{}, errors.ErrTransactionNotFound
+	}
+
+	// Chỉ thay thế được giao dịch chưa được đào và chưa bị thay thế
+	if orig.Status != model.TxStatusBroadcast && orig.Status != model.TxStatusDropped {
+		return model.Transaction{}, errors.ErrTransactionNotReplaceable
+	}
+	if orig.ReplacedByID != nil || orig.Nonce == nil {
+		return model.Transaction{}, errors.ErrTransactionNotReplaceable
+	}
+	prevFees, err := storedFees(orig)
+	if err != nil {
+		return model.Transaction{}, errors.ErrTransactionNotReplaceable
+	}
+
+	ethClient, err := chainClient(ctx, s.ethClients, orig.ChainID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	speed, err := ethClient.ParseSpeed(req.Speed)
 	if err != nil {
-		return "", fmt.Errorf("failed to send transaction: %w", err)
+		return model.Transaction{}, errors.ErrInvalidRequest
+	}
+
+	// Giao dịch thay thế dùng lại nonce của giao dịch cũ
+	replacement := model.Transaction{
+		FromAddress: orig.FromAddress,
+		ToAddress:   orig.ToAddress,
+		ChainID:     orig.ChainID,
+		Status:      model.TxStatusCreated,
+		Symbol:      orig.Symbol,
+		Amount:      orig.Amount,
+		Nonce:       orig.Nonce,
+		ReplacesID:  &orig.ID,
+		Data:        orig.Data,
+	}
+	var token model.TokenResponse
+	if cancel {
+		chain, err := s.assetService.GetChainByChainID(ctx, orig.ChainID)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+		replacement.ToAddress = orig.FromAddress
+		replacement.Symbol = chain.NativeCurrency
+		replacement.Amount = "0"
+		replacement.Data = ""
+	} else {
+		if token, err = s.assetService.GetTokenBySymbol(ctx, orig.ChainID, orig.Symbol); err != nil {
+			return model.Transaction{}, err
+		}
+		if token.Type != model.TokenTypeNative && token.Type != model.TokenTypeERC20 {
+			return model.Transaction{}, errors.ErrUnsupportedTokenType
+		}
+	}
+
+	txn, err := s.txnRepo.CreateTransaction(ctx, replacement)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to create transaction record: %w", err)
+	}
+
+	chainID := big.NewInt(int64(orig.ChainID))
+	from := common.HexToAddress(orig.FromAddress)
+	nonce := *orig.Nonce
+	var signedTx *types.Transaction
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Nonce vẫn thuộc về giao dịch cũ nên không trả lại
+		ctx := context.WithoutCancel(ctx)
+		update := model.TransactionUpdate{Status: model.TxStatusFailed, Reason: err.Error()}
+		if signedTx != nil && ethClient.IsKnownTransaction(ctx, signedTx.Hash()) {
+			update.Status = model.TxStatusBroadcast
+			s.recordReplacement(ctx, orig, txn, signedTx)
+		}
+		if err := s.transition(ctx, &txn, update); err != nil {
+			logger.Error("failed to record transaction status", err)
+		}
+	}()
+
+	// Dựng lại giao dịch với cùng nonce, hủy là chuyển 0 về chính ví
+	var tx *types.Transaction
+	switch {
+	case cancel:
+		tx, err = ethClient.CreateTransaction(ctx, chainID, nonce, orig.FromAddress, orig.FromAddress, "0", speed)
+	case orig.Data != "":
+		tx, err = rebuildContractCall(ctx, ethClient, chainID, nonce, orig, speed)
+	case token.Type == model.TokenTypeERC20:
+		tx, err = ethClient.CreateTokenTransfer(ctx, chainID, nonce, orig.FromAddress, token.ContractAddress, orig.ToAddress, orig.Amount, token.Decimals, speed)
+	default:
+		tx, err = ethClient.CreateTransaction(ctx, chainID, nonce, orig.FromAddress, orig.ToAddress, orig.Amount, speed)
+	}
+	if err != nil {
+		return model.Transaction{}, revertError("failed to create transaction", err)
+	}
+	// Node chỉ nhận giao dịch cùng nonce nếu cả hai mức phí đều tăng đủ
+	tx = ethClient.BumpFees(tx, prevFees)
+
+	if err := s.checkFunds(ctx, ethClient, orig.FromAddress, tx, cancel || token.Type == model.TokenTypeERC20); err != nil {
+		return model.Transaction{}, err
+	}
+	if _, err := ethClient.Simulate(ctx, from, tx); err != nil {
+		return model.Transaction{}, revertError("failed to simulate transaction", err)
+	}
+
+	signedTx, err = s.signAndSend(ctx, ethClient, &txn, wallet, tx, chainID, req.ShareData)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if err := s.nonces.Replaced(ctx, chainID, from, nonce, signedTx.Hash().Hex()); err != nil {
+		logger.Error("failed to record replaced nonce", err)
+	}
+	s.recordReplacement(ctx, orig, txn, signedTx)
+	if err := s.transition(ctx, &txn, model.TransactionUpdate{Status: model.TxStatusBroadcast}); err != nil {
+		logger.Error("failed to record broadcast transaction", err)
+	}
+	return txn, nil
+}
+
+// rebuildContractCall builds the stored contract call of orig again with
+// nonce, keeping its gas limit
+func rebuildContractCall(ctx context.Context, ethClient *ethereum.EthClient, chainID *big.Int, nonce uint64, orig model.Transaction, speed ethereum.Speed) (*types.Transaction, error) {
+	data, err := hexutil.Decode(orig.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored calldata: %w", err)
+	}
+	value, err := ethereum.ToBaseUnits(orig.Amount, 18)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored value: %w", err)
+	}
+	return ethClient.CreateContractCall(ctx, chainID, nonce, orig.FromAddress, orig.ToAddress, value, data, orig.GasLimit, speed)
+}
+
+// recordReplacement links a stuck transaction to its broadcast replacement,
+// the tracker marks whichever of them is not mined as replaced
+func (s *TransactionService) recordReplacement(ctx context.Context, orig, replacement model.Transaction, signedTx *types.Transaction) {
+	if err := s.txnRepo.SetTransactionReplacedBy(ctx, orig.ID, replacement.ID); err != nil {
+		logger.Error("failed to link replacement transaction", err)
+	}
+	logger.Info("transaction replaced",
+		zap.String("tx_hash", orig.TxHash),
+		zap.String("replacement_tx_hash", signedTx.Hash().Hex()))
+}
+
+// storedFees returns the fees a transaction was broadcast with
+func storedFees(txn model.Transaction) (*ethereum.Fees, error) {
+	maxFee, ok := new(big.Int).SetString(txn.MaxFeePerGas, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid max fee per gas %q", txn.MaxFeePerGas)
+	}
+	tip, ok := new(big.Int).SetString(txn.MaxPriorityFeePerGas, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid max priority fee per gas %q", txn.MaxPriorityFeePerGas)
+	}
+	return &ethereum.Fees{MaxFeePerGas: maxFee, MaxPriorityFeePerGas: tip}, nil
+}
+
+// settleNonce gives back the nonce of a failed transaction. A broadcast
+// that failed on our side may still have reached the node, its nonce is
+// then kept as sent and settleNonce reports true.
+func (s *TransactionService) settleNonce(ctx context.Context, ethClient *ethereum.EthClient, nonce *ethereum.Nonce, signedTx *types.Transaction) bool {
+	if signedTx != nil && ethClient.IsKnownTransaction(ctx, signedTx.Hash()) {
+		if err := nonce.Sent(ctx, signedTx.Hash().Hex()); err != nil {
+			logger.Error("failed to record sent nonce", err)
+		}
+		return true
+	}
+	if err := nonce.Release(ctx); err != nil {
+		logger.Error("failed to release nonce", err)
+	}
+	return false
+}
+
+// chainClient returns the client of a chain, chains that are unknown or not
+// active are an invalid request
+func chainClient(ctx context.Context, registry *ethereum.Registry, chainID int) (*ethereum.EthClient, error) {
+	client, err := registry.Client(ctx, chainID)
+	if stderrors.Is(err, ethereum.ErrUnknownChain) {
+		return nil, errors.ErrInvalidChainID
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to chain %d: %w", chainID, err)
+	}
+	return client, nil
+}
+
+// revertError turns a revert into ErrTransactionReverted with its decoded
+// reason, other errors are wrapped with msg
+func revertError(msg string, err error) error {
+	var rev *ethereum.RevertError
+	if stderrors.As(err, &rev) {
+		return errors.ErrTransactionReverted.WithDetails(rev.Error(), revertDetails(rev))
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func revertDetails(rev *ethereum.RevertError) *model.RevertDetails {
+	details := &model.RevertDetails{
+		Reason:   rev.Reason,
+		Selector: rev.Selector,
+	}
+	if len(rev.Data) > 0 {
+		details.Data = hexutil.Encode(rev.Data)
 	}
-	return txHashSent, nil
+	return details
 }
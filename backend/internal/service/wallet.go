@@ -2,47 +2,78 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
 	"mpc/internal/model"
 	"mpc/internal/repository"
 	"mpc/pkg/errors"
 	"mpc/pkg/ethereum"
 	"mpc/pkg/logger"
+	"mpc/pkg/policy"
 	"mpc/pkg/tss"
+	"mpc/pkg/utils"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 type WalletService struct {
-	walletRepo *repository.WalletRepository
-	tssClient  *tss.TSS
-	ethClient  *ethereum.EthClient
+	walletRepo   *repository.WalletRepository
+	orgRepo      *repository.OrganizationRepository
+	tssClient    tss.Client
+	policyClient *policy.Client
+	ethClients   *ethereum.Registry
+	eventService *EventService
+	addressIndex *AddressIndex
 }
 
-func NewWalletService(walletRepo *repository.WalletRepository, tssClient *tss.TSS, ethClient *ethereum.EthClient) *WalletService {
+func NewWalletService(
+	walletRepo *repository.WalletRepository,
+	orgRepo *repository.OrganizationRepository,
+	tssClient tss.Client,
+	policyClient *policy.Client,
+	ethClients *ethereum.Registry,
+	eventService *EventService,
+	addressIndex *AddressIndex,
+) *WalletService {
 	return &WalletService{
-		walletRepo: walletRepo,
-		tssClient:  tssClient,
-		ethClient:  ethClient,
+		walletRepo:   walletRepo,
+		orgRepo:      orgRepo,
+		tssClient:    tssClient,
+		policyClient: policyClient,
+		ethClients:   ethClients,
+		eventService: eventService,
+		addressIndex: addressIndex,
 	}
 }
 
 func (s *WalletService) CreateWallet(ctx context.Context, userID uuid.UUID) (model.Wallet, string, error) {
 	// Create Ethereum wallet
-	shareData, addressHex, err := s.tssClient.CreateWallet(ctx, userID.String())
+	key, err := s.tssClient.CreateWallet(ctx, userID.String())
 	if err != nil {
 		logger.Error("Service:CreateWallet", err)
 		return model.Wallet{}, "", err
 	}
-	addressHex = strings.ToLower(addressHex)
+	addressHex := strings.ToLower(key.Address)
 
-	// Create wallet in repository
-	wallet, err := s.walletRepo.CreateWallet(ctx, userID, addressHex, []byte(""), "Default")
+	// Create wallet in repository, keeping the key metadata for signing
+	wallet, err := s.walletRepo.CreateWallet(ctx, userID, addressHex, []byte(""), "Default", key.ID, key.Parties, key.Threshold)
 	if err != nil {
 		logger.Error("Service:CreateWallet", err)
 		return model.Wallet{}, "", err
 	}
-	return wallet, shareData, nil
+	// The workers monitor the address as soon as they read it. The wallet and
+	// its key exist already, an address not announced is indexed by the next
+	// reconciliation of the workers instead.
+	if err := s.addressIndex.AddressAdded(ctx, wallet); err != nil {
+		logger.Error("Service:CreateWallet: failed to announce wallet address, monitored after the next reconciliation", err)
+	}
+	s.eventService.WalletCreated(ctx, wallet)
+	return wallet, key.ShareData, nil
 }
 
 func (s *WalletService) GetWalletByUserID(ctx context.Context, userID uuid.UUID) (model.Wallet, error) {
@@ -67,8 +98,12 @@ func (s *WalletService) GetBalanceByAddress(
 		logger.Error("Service:GetBalance", err)
 		return model.GetBalanceResponse{}, err
 	}
+	ethClient, err := chainClient(ctx, s.ethClients, request.ChainID)
+	if err != nil {
+		return model.GetBalanceResponse{}, err
+	}
 	address := strings.ToLower(wallet.Address)
-	balance, err := s.ethClient.GetBalance(ctx, address)
+	balance, err := ethClient.GetBalance(ctx, address)
 	if err != nil {
 		logger.Error("Service:GetBalance", err)
 		return model.GetBalanceResponse{}, err
@@ -79,3 +114,159 @@ func (s *WalletService) GetBalanceByAddress(
 		Balance: balance,
 	}, nil
 }
+
+// SignMessage signs a message of a wallet of the user with personal_sign
+// (EIP-191). Wallets of an organization with a signing allowlist only sign
+// sign-in messages (EIP-4361) of an allowed domain.
+func (s *WalletService) SignMessage(
+	ctx context.Context,
+	userID uuid.UUID,
+	walletID uuid.UUID,
+	req model.SignMessageRequest,
+) (model.SignatureResponse, error) {
+	wallet, err := s.getUserWallet(ctx, userID, walletID)
+	if err != nil {
+		return model.SignatureResponse{}, err
+	}
+
+	message := ethereum.MessageBytes(req.Message)
+	allowlist, restricted, err := s.signingAllowlist(ctx, wallet)
+	if err != nil {
+		return model.SignatureResponse{}, err
+	}
+	if restricted {
+		domain, ok := ethereum.SignInDomain(message)
+		if !ok || !allowlist.AllowsDomain(domain) {
+			logger.Warn("refused to sign message", zap.String("wallet", wallet.Address), zap.String("domain", domain))
+			return model.SignatureResponse{}, errors.ErrSigningNotAllowed
+		}
+	}
+
+	return s.signHash(ctx, wallet, policy.Request{MsgHash: ethereum.MessageHash(message), Message: message}, req.ShareData)
+}
+
+// SignTypedData signs EIP-712 typed data of a wallet of the user like
+// eth_signTypedData_v4. Wallets of an organization with a signing allowlist
+// only sign typed data of an allowed verifying contract.
+func (s *WalletService) SignTypedData(
+	ctx context.Context,
+	userID uuid.UUID,
+	walletID uuid.UUID,
+	req model.SignTypedDataRequest,
+) (model.SignatureResponse, error) {
+	wallet, err := s.getUserWallet(ctx, userID, walletID)
+	if err != nil {
+		return model.SignatureResponse{}, err
+	}
+
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(req.TypedData, &typedData); err != nil {
+		return model.SignatureResponse{}, errors.ErrInvalidTypedData
+	}
+	hash, err := ethereum.TypedDataHash(typedData)
+	if err != nil {
+		return model.SignatureResponse{}, errors.ErrInvalidTypedData.WithDetails(err.Error(), nil)
+	}
+
+	allowlist, restricted, err := s.signingAllowlist(ctx, wallet)
+	if err != nil {
+		return model.SignatureResponse{}, err
+	}
+	if restricted && !allowlist.AllowsContract(typedData.Domain.VerifyingContract) {
+		logger.Warn("refused to sign typed data",
+			zap.String("wallet", wallet.Address),
+			zap.String("verifying_contract", typedData.Domain.VerifyingContract))
+		return model.SignatureResponse{}, errors.ErrSigningNotAllowed
+	}
+
+	return s.signHash(ctx, wallet, policy.Request{MsgHash: hash, TypedData: req.TypedData}, req.ShareData)
+}
+
+// getUserWallet returns a wallet of the user, other wallets are not found
+func (s *WalletService) getUserWallet(ctx context.Context, userID uuid.UUID, walletID uuid.UUID) (model.Wallet, error) {
+	wallet, err := s.walletRepo.GetWalletByID(ctx, walletID)
+	if err != nil || wallet.UserID != userID {
+		return model.Wallet{}, errors.ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+// signingAllowlist returns the signing allowlist of the wallet's
+// organization, restricted is false if there is none
+func (s *WalletService) signingAllowlist(ctx context.Context, wallet model.Wallet) (allowlist model.SigningAllowlist, restricted bool, err error) {
+	if wallet.OrganizationID == nil {
+		return model.SigningAllowlist{}, false, nil
+	}
+	org, err := s.orgRepo.GetOrganizationByID(ctx, *wallet.OrganizationID)
+	if err != nil {
+		return model.SigningAllowlist{}, false, fmt.Errorf("failed to get organization: %w", err)
+	}
+	allowlist, restricted = org.SigningAllowlist()
+	return allowlist, restricted, nil
+}
+
+// signHash signs the hash of approval with the wallet's key, v is returned
+// as 27 or 28 like wallets do for messages
+func (s *WalletService) signHash(ctx context.Context, wallet model.Wallet, approval policy.Request, shareData string) (model.SignatureResponse, error) {
+	sig, err := tssSign(ctx, s.tssClient, s.policyClient, wallet, approval, shareData)
+	if err != nil {
+		return model.SignatureResponse{}, err
+	}
+	sig[64] += 27
+
+	return model.SignatureResponse{
+		Address:   wallet.Address,
+		Hash:      hexutil.Encode(approval.MsgHash),
+		Signature: hexutil.Encode(sig),
+	}, nil
+}
+
+// tssSign signs the hash of approval with the wallet's key through TSS once
+// the policy service approved it. approval carries the transaction, message
+// or typed data behind the hash, the session and key are set here. The
+// signature has 65 bytes, the recovery id last.
+func tssSign(
+	ctx context.Context,
+	tssClient tss.Client,
+	policyClient *policy.Client,
+	wallet model.Wallet,
+	approval policy.Request,
+	shareData string,
+) ([]byte, error) {
+	// Duyệt giao dịch: các node TSS chỉ ký hash đã được policy service chấp thuận
+	if policyClient == nil {
+		return nil, fmt.Errorf("policy client is not configured")
+	}
+	// Mỗi lần ký là một session riêng, kết quả ký được lưu theo session ID
+	approval.SessionID = uuid.New().String()
+	approval.KeyID = wallet.KeyID
+	authToken, err := policyClient.Approve(ctx, approval)
+	if stderrors.Is(err, policy.ErrRejected) {
+		return nil, errors.ErrSignatureRejected.WithDetails(err.Error(), nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve signature: %w", err)
+	}
+
+	// Ký bằng TSS với các party của khóa (nhận chữ ký DER)
+	derSig, err := tssClient.Sign(ctx, tss.SignRequest{
+		SessionID:  approval.SessionID,
+		KeyID:      wallet.KeyID,
+		Parties:    wallet.Parties,
+		Threshold:  wallet.Threshold,
+		ShareData:  shareData,
+		MsgHash:    approval.MsgHash,
+		AuthToken:  authToken,
+		UnsignedTx: approval.UnsignedTx,
+		ChainID:    approval.ChainID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TSS signing failed: %w", err)
+	}
+
+	sig, err := utils.ConvertDERToEthSignature(derSig, approval.MsgHash, wallet.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert DER signature: %w", err)
+	}
+	return sig, nil
+}
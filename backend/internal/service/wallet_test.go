@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mpc/internal/config"
+	rd "mpc/internal/db/redis"
+	db "mpc/internal/db/sqlc"
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/ethereum"
+	"mpc/pkg/events"
+	"mpc/pkg/policy"
+	"mpc/pkg/tss"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+)
+
+// walletTable keeps the wallets in memory, answering the wallet queries of
+// the services like the database
+type walletTable struct {
+	mu      sync.Mutex
+	wallets map[pgtype.UUID]db.Wallet
+}
+
+// walletRow scans a wallet in the column order of the wallet queries, the
+// field order of db.Wallet
+type walletRow struct {
+	wallet db.Wallet
+	err    error
+}
+
+func (r walletRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	fields := reflect.ValueOf(r.wallet)
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(fields.Field(i))
+	}
+	return nil
+}
+
+func (t *walletTable) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(sql, "-- name: CreateWallet "):
+		wallet := db.Wallet{
+			ID:                  pgtype.UUID{Bytes: uuid.New(), Valid: true},
+			UserID:              args[0].(pgtype.UUID),
+			Address:             args[1].(string),
+			EncryptedPrivateKey: args[2].([]byte),
+			Name:                args[3].(pgtype.Text),
+			Status:              args[4].(string),
+			KeyID:               args[5].(string),
+			Parties:             args[6].([]int32),
+			Threshold:           args[7].(int32),
+			CreatedAt:           args[8].(pgtype.Timestamp),
+			UpdatedAt:           args[9].(pgtype.Timestamp),
+		}
+		t.wallets[wallet.ID] = wallet
+		return walletRow{wallet: wallet}
+	case strings.HasPrefix(sql, "-- name: GetWalletByID "):
+		wallet, ok := t.wallets[args[0].(pgtype.UUID)]
+		if !ok {
+			return walletRow{err: pgx.ErrNoRows}
+		}
+		return walletRow{wallet: wallet}
+	}
+	return walletRow{err: errors.New("unexpected query: " + sql)}
+}
+
+func (t *walletTable) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("unexpected query: " + sql)
+}
+
+func (t *walletTable) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query: " + sql)
+}
+
+// newTestWalletService returns a wallet service signing with the fake TSS,
+// with the policy service approving every request it is sent
+func newTestWalletService(t *testing.T) (*WalletService, *[]policy.Request) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	redisClient := &rd.Client{Client: redis.NewClient(&redis.Options{Addr: s.Addr()})}
+	t.Cleanup(func() { redisClient.Close() })
+
+	var approved []policy.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req policy.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		approved = append(approved, req)
+		json.NewEncoder(w).Encode(map[string]string{"auth_token": "token"})
+	}))
+	t.Cleanup(srv.Close)
+	policyClient, err := policy.NewClient(&config.PolicyConfig{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	tssClient, err := tss.NewFake(&config.TSSConfig{
+		FakeSeed:  "seed",
+		Parties:   []uint32{1, 2, 3},
+		Threshold: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewFake: %v", err)
+	}
+
+	walletRepo := repository.NewWalletRepository(&walletTable{wallets: make(map[pgtype.UUID]db.Wallet)})
+	broker := events.NewBroker(redisClient, &config.EventsConfig{})
+	return NewWalletService(
+		walletRepo,
+		nil,
+		tssClient,
+		policyClient,
+		nil,
+		NewEventService(walletRepo, broker, nil),
+		NewAddressIndex(redisClient, walletRepo),
+	), &approved
+}
+
+func TestCreateWallet(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestWalletService(t)
+	userID := uuid.New()
+
+	wallet, shareData, err := s.CreateWallet(ctx, userID)
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	if wallet.UserID != userID || wallet.KeyID != userID.String() || shareData == "" {
+		t.Fatalf("CreateWallet = %+v, share %q", wallet, shareData)
+	}
+	if wallet.Address != strings.ToLower(wallet.Address) || len(wallet.Address) != 42 {
+		t.Errorf("Address = %s, want a lowercase address", wallet.Address)
+	}
+	if len(wallet.Parties) != 3 || wallet.Threshold != 1 {
+		t.Errorf("Parties = %v with threshold %d, want 3 parties with threshold 1", wallet.Parties, wallet.Threshold)
+	}
+
+	// The address is announced to the workers and the wallet to its user
+	announced, err := s.addressIndex.redisClient.XRange(ctx, addressStream, "-", "+").Result()
+	if err != nil || len(announced) != 1 || announced[0].Values["address"] != wallet.Address {
+		t.Errorf("announced addresses = %v (%v), want %s", announced, err, wallet.Address)
+	}
+	published, err := s.eventService.EventsSince(ctx, userID, "0-0")
+	if err != nil || len(published) != 1 || published[0].Type != model.EventWalletCreated {
+		t.Errorf("events = %v (%v), want %s", published, err, model.EventWalletCreated)
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	ctx := context.Background()
+	s, approved := newTestWalletService(t)
+	userID := uuid.New()
+
+	wallet, shareData, err := s.CreateWallet(ctx, userID)
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+
+	res, err := s.SignMessage(ctx, userID, wallet.ID, model.SignMessageRequest{Message: "hello", ShareData: shareData})
+	if err != nil {
+		t.Fatalf("SignMessage: %v", err)
+	}
+	hash := ethereum.MessageHash(ethereum.MessageBytes("hello"))
+	if res.Hash != hexutil.Encode(hash) {
+		t.Errorf("Hash = %s, want %s", res.Hash, hexutil.Encode(hash))
+	}
+	if len(*approved) != 1 || !reflect.DeepEqual([]byte((*approved)[0].MsgHash), hash) || (*approved)[0].KeyID != wallet.KeyID {
+		t.Errorf("approved = %+v, want the message hash of key %s", *approved, wallet.KeyID)
+	}
+
+	// The signature recovers to the wallet, v is 27 or 28
+	sig := hexutil.MustDecode(res.Signature)
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		t.Fatalf("Signature = %s, want 65 bytes with v 27 or 28", res.Signature)
+	}
+	sig[64] -= 27
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("SigToPub: %v", err)
+	}
+	if got := strings.ToLower(crypto.PubkeyToAddress(*pub).Hex()); got != wallet.Address {
+		t.Errorf("signer = %s, want %s", got, wallet.Address)
+	}
+
+	// Other users cannot sign with the wallet
+	_, err = s.SignMessage(ctx, uuid.New(), wallet.ID, model.SignMessageRequest{Message: "hello", ShareData: shareData})
+	if err == nil {
+		t.Errorf("SignMessage of another user succeeded")
+	}
+}
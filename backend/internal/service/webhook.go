@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"time"
+
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/errors"
+	"mpc/pkg/logger"
+	"mpc/pkg/webhook"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// webhookEvents are the event types a webhook subscribes to
+var webhookEvents = map[string]bool{
+	model.EventDepositReceived:      true,
+	model.EventTransactionConfirmed: true,
+	model.EventTransactionFailed:    true,
+	model.EventWalletCreated:        true,
+}
+
+// WebhookService manages the webhooks of organizations and queues events for
+// them, the worker delivers the queue with WebhookDispatcher
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+}
+
+func NewWebhookService(webhookRepo *repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+	}
+}
+
+// CreateWebhook creates a webhook for an organization with a new secret
+func (s *WebhookService) CreateWebhook(ctx context.Context, orgID uuid.UUID, req *model.CreateWebhookRequest) (*model.Webhook, error) {
+	if err := validateWebhook(ctx, req.URL, req.Events); err != nil {
+		return nil, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	wh := &model.Webhook{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Name:           req.Name,
+		URL:            req.URL,
+		Events:         req.Events,
+		Secret:         secret,
+		Status:         model.WebhookStatusActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.webhookRepo.CreateWebhook(ctx, wh); err != nil {
+		logger.Error("Failed to create webhook", err)
+		return nil, err
+	}
+	return wh, nil
+}
+
+// GetWebhooks gets all webhooks of an organization
+func (s *WebhookService) GetWebhooks(ctx context.Context, orgID uuid.UUID) ([]*model.Webhook, error) {
+	webhooks, err := s.webhookRepo.GetWebhooksByOrganization(ctx, orgID)
+	if err != nil {
+		logger.Error("Failed to get webhooks", err)
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetWebhook gets a webhook of an organization
+func (s *WebhookService) GetWebhook(ctx context.Context, orgID, webhookID uuid.UUID) (*model.Webhook, error) {
+	wh, err := s.webhookRepo.GetWebhook(ctx, orgID, webhookID)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrWebhookNotFound
+		}
+		logger.Error("Failed to get webhook", err)
+		return nil, err
+	}
+	return wh, nil
+}
+
+// UpdateWebhook changes the fields of a webhook set in req
+func (s *WebhookService) UpdateWebhook(ctx context.Context, orgID, webhookID uuid.UUID, req *model.UpdateWebhookRequest) (*model.Webhook, error) {
+	wh, err := s.GetWebhook(ctx, orgID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		wh.Name = *req.Name
+	}
+	if req.URL != nil {
+		wh.URL = *req.URL
+	}
+	if req.Events != nil {
+		wh.Events = req.Events
+	}
+	if req.Status != nil {
+		wh.Status = *req.Status
+	}
+	if err := validateWebhook(ctx, wh.URL, wh.Events); err != nil {
+		return nil, err
+	}
+	wh.UpdatedAt = time.Now()
+
+	if err := s.webhookRepo.UpdateWebhook(ctx, wh); err != nil {
+		logger.Error("Failed to update webhook", err)
+		return nil, err
+	}
+	return wh, nil
+}
+
+// DeleteWebhook deletes a webhook of an organization and its queued events
+func (s *WebhookService) DeleteWebhook(ctx context.Context, orgID, webhookID uuid.UUID) error {
+	deleted, err := s.webhookRepo.DeleteWebhook(ctx, orgID, webhookID)
+	if err != nil {
+		logger.Error("Failed to delete webhook", err)
+		return err
+	}
+	if !deleted {
+		return errors.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetWebhookEvents gets the events queued for a webhook, newest first,
+// filtered by delivery status if status is set
+func (s *WebhookService) GetWebhookEvents(ctx context.Context, orgID, webhookID uuid.UUID, status string, page, pageSize int) (*model.WebhookEventListResponse, error) {
+	if _, err := s.GetWebhook(ctx, orgID, webhookID); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", model.WebhookEventPending, model.WebhookEventDelivered, model.WebhookEventDead:
+	default:
+		return nil, errors.ErrInvalidRequest
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	events, err := s.webhookRepo.GetWebhookEvents(ctx, webhookID, status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		logger.Error("Failed to get webhook events", err)
+		return nil, err
+	}
+	return &model.WebhookEventListResponse{
+		Events:   events,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// RedeliverEvent queues an event of a webhook again, dead or not, for
+// MaxAttempts more attempts starting now
+func (s *WebhookService) RedeliverEvent(ctx context.Context, orgID, webhookID, eventID uuid.UUID) (*model.WebhookEvent, error) {
+	if _, err := s.GetWebhook(ctx, orgID, webhookID); err != nil {
+		return nil, err
+	}
+
+	event, err := s.webhookRepo.RedeliverEvent(ctx, webhookID, eventID)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrWebhookEventNotFound
+		}
+		logger.Error("Failed to redeliver webhook event", err)
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Enqueue queues an event for the webhooks of an organization subscribed to
+// it. Failures are logged, like the events pushed to users.
+func (s *WebhookService) Enqueue(ctx context.Context, orgID uuid.UUID, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("failed to encode webhook event", err, zap.String("type", eventType))
+		return
+	}
+	if _, err := s.webhookRepo.EnqueueEvent(ctx, orgID, eventType, payload); err != nil {
+		logger.Error("failed to queue webhook event", err,
+			zap.String("organization_id", orgID.String()),
+			zap.String("type", eventType))
+	}
+}
+
+// validateWebhook checks the URL and the events of a webhook. The URL must be
+// https and resolve to public addresses only, the worker must not be made to
+// post to internal services.
+func validateWebhook(ctx context.Context, rawURL string, events []string) error {
+	if err := webhook.CheckURL(ctx, rawURL); err != nil {
+		return errors.ErrInvalidWebhook.WithDetails("invalid webhook URL: "+err.Error(), nil)
+	}
+	if len(events) == 0 {
+		return errors.ErrInvalidWebhook
+	}
+	for _, event := range events {
+		if !webhookEvents[event] {
+			return errors.ErrInvalidWebhook
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"mpc/internal/config"
+	"mpc/internal/model"
+	"mpc/internal/repository"
+	"mpc/pkg/logger"
+	"mpc/pkg/webhook"
+
+	"go.uber.org/zap"
+)
+
+// WebhookDispatcher posts the queued webhook events to the webhooks of
+// organizations. An event is retried with exponential backoff until the
+// webhook answers with a 2xx status, and is dead after MaxAttempts failures.
+// The queue is in the database, so several workers may run a dispatcher.
+type WebhookDispatcher struct {
+	webhookRepo *repository.WebhookRepository
+	httpClient  *http.Client
+	cfg         config.WebhookConfig
+}
+
+func NewWebhookDispatcher(
+	cfg *config.WebhookConfig,
+	webhookRepo *repository.WebhookRepository,
+) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		cfg:         *cfg,
+	}
+	if d.cfg.PollInterval <= 0 {
+		d.cfg.PollInterval = 5 * time.Second
+	}
+	if d.cfg.BatchSize <= 0 {
+		d.cfg.BatchSize = 50
+	}
+	if d.cfg.Concurrency <= 0 {
+		d.cfg.Concurrency = 10
+	}
+	if d.cfg.Timeout <= 0 {
+		d.cfg.Timeout = 10 * time.Second
+	}
+	if d.cfg.MaxAttempts <= 0 {
+		d.cfg.MaxAttempts = 10
+	}
+	if d.cfg.BackoffMin <= 0 {
+		d.cfg.BackoffMin = 30 * time.Second
+	}
+	if d.cfg.BackoffMax < d.cfg.BackoffMin {
+		d.cfg.BackoffMax = d.cfg.BackoffMin
+	}
+	d.httpClient = &http.Client{
+		Timeout: d.cfg.Timeout,
+		// Without proxy, every connection goes through the dialer refusing
+		// internal addresses, whatever the host resolves to at delivery time
+		Transport: &http.Transport{
+			DialContext:         webhook.NewDialer(d.cfg.Timeout).DialContext,
+			TLSHandshakeTimeout: d.cfg.Timeout,
+			MaxIdleConnsPerHost: d.cfg.Concurrency,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// A webhook answers itself, redirects count as failures
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return d
+}
+
+// Run delivers the due events until ctx is done
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means more events are due, keep going without waiting
+		for {
+			n, err := d.Poll(ctx)
+			if err != nil {
+				logger.Error("failed to dispatch webhook events", err)
+			}
+			if err != nil || n < d.cfg.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll claims one batch of due events and delivers them, returning the
+// number of events claimed
+func (d *WebhookDispatcher) Poll(ctx context.Context) (int, error) {
+	// Claimed events wait out the delivery before another worker retries them
+	deliveries, err := d.webhookRepo.ClaimDueEvents(ctx, d.cfg.BatchSize, d.cfg.Timeout+time.Minute)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook events: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, d.cfg.Concurrency)
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery repository.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// deliver posts an event to its webhook and records the outcome
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery repository.WebhookDelivery) {
+	event := delivery.Event
+	status, err := d.post(ctx, delivery)
+	if err == nil {
+		if err := d.webhookRepo.MarkEventDelivered(ctx, event.ID, status); err != nil {
+			logger.Error("failed to record webhook delivery", err, zap.String("event_id", event.ID.String()))
+		}
+		return
+	}
+
+	var responseStatus *int
+	if status != 0 {
+		responseStatus = &status
+	}
+	dead := event.Attempts >= d.cfg.MaxAttempts
+	retryIn := webhook.Backoff(event.Attempts, d.cfg.BackoffMin, d.cfg.BackoffMax)
+	if dead {
+		logger.Warn("webhook event is dead",
+			zap.String("event_id", event.ID.String()),
+			zap.String("webhook_id", event.WebhookID.String()),
+			zap.Int("attempts", event.Attempts),
+			zap.Error(err))
+	}
+	if err := d.webhookRepo.MarkEventFailed(ctx, event.ID, responseStatus, err.Error(), retryIn, dead); err != nil {
+		logger.Error("failed to record webhook failure", err, zap.String("event_id", event.ID.String()))
+	}
+}
+
+// post sends the signed event, returning the response status if there was a response
+func (d *WebhookDispatcher) post(ctx context.Context, delivery repository.WebhookDelivery) (int, error) {
+	event := delivery.Event
+	body, err := json.Marshal(model.WebhookPayload{
+		ID:        event.ID,
+		Event:     event.Event,
+		Data:      event.Data,
+		CreatedAt: event.CreatedAt,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mpc-webhooks/1.0")
+	req.Header.Set(webhook.IDHeader, event.ID.String())
+	req.Header.Set(webhook.EventHeader, event.Event)
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -4,12 +4,22 @@ type AppError struct {
 	Code    string
 	Message string
 	Status  int
+	Details interface{} // returned to the client next to the code, if set
 }
 
 func (e *AppError) Error() string {
 	return e.Message
 }
 
+// WithDetails returns a copy of the error with a message and details for
+// this occurrence
+func (e *AppError) WithDetails(message string, details interface{}) *AppError {
+	err := *e
+	err.Message = message
+	err.Details = details
+	return &err
+}
+
 func NewAppError(code string, message string, status int) *AppError {
 	return &AppError{
 		Code:    code,
@@ -28,9 +38,12 @@ var (
 
 // User Errors
 var (
-	ErrUserNotFound   = NewAppError("USER_NOT_FOUND", "user not found", 404)
-	ErrInvalidRequest = NewAppError("INVALID_REQUEST", "invalid request", 400)
-	ErrWalletNotFound = NewAppError("WALLET_NOT_FOUND", "wallet not found", 404)
+	ErrUserNotFound      = NewAppError("USER_NOT_FOUND", "user not found", 404)
+	ErrInvalidRequest    = NewAppError("INVALID_REQUEST", "invalid request", 400)
+	ErrWalletNotFound    = NewAppError("WALLET_NOT_FOUND", "wallet not found", 404)
+	ErrInvalidTypedData  = NewAppError("INVALID_TYPED_DATA", "invalid typed data", 400)
+	ErrSigningNotAllowed = NewAppError("SIGNING_NOT_ALLOWED", "domain or contract is not allowed to request signatures", 403)
+	ErrSignatureRejected = NewAppError("SIGNATURE_REJECTED", "signature rejected by the policy service", 403)
 )
 
 // Asset Errors
@@ -44,13 +57,17 @@ var (
 
 // Transaction Errors
 var (
-	ErrTransactionNotFound = NewAppError("TRANSACTION_NOT_FOUND", "transaction not found", 404)
-	ErrTransactionFailed   = NewAppError("TRANSACTION_FAILED", "transaction failed", 400)
-	ErrNotImplemented      = NewAppError("NOT_IMPLEMENTED", "not implemented", 501)
-	ErrInvalidWallet       = NewAppError("INVALID_WALLET", "invalid wallet", 400)
-	ErrInvalidAmount       = NewAppError("INVALID_AMOUNT", "invalid amount", 400)
-	ErrInvalidAddress      = NewAppError("INVALID_ADDRESS", "invalid address", 400)
-	ErrInssuficientBalance = NewAppError("INSUFFICIENT_BALANCE", "insufficient balance", 400)
+	ErrTransactionNotFound       = NewAppError("TRANSACTION_NOT_FOUND", "transaction not found", 404)
+	ErrTransactionFailed         = NewAppError("TRANSACTION_FAILED", "transaction failed", 400)
+	ErrNotImplemented            = NewAppError("NOT_IMPLEMENTED", "not implemented", 501)
+	ErrInvalidWallet             = NewAppError("INVALID_WALLET", "invalid wallet", 400)
+	ErrInvalidAmount             = NewAppError("INVALID_AMOUNT", "invalid amount", 400)
+	ErrInvalidAddress            = NewAppError("INVALID_ADDRESS", "invalid address", 400)
+	ErrInssuficientBalance       = NewAppError("INSUFFICIENT_BALANCE", "insufficient balance", 400)
+	ErrInsufficientGasFunds      = NewAppError("INSUFFICIENT_GAS_FUNDS", "insufficient native balance for gas", 400)
+	ErrTransactionNotReplaceable = NewAppError("TRANSACTION_NOT_REPLACEABLE", "transaction is no longer pending and cannot be replaced", 409)
+	ErrTransactionReverted       = NewAppError("TRANSACTION_REVERTED", "transaction reverted in simulation", 422)
+	ErrInvalidCalldata           = NewAppError("INVALID_CALLDATA", "invalid calldata", 400)
 )
 
 // B2B Organization Errors
@@ -87,3 +104,15 @@ var (
 var (
 	ErrUsageNotFound = NewAppError("USAGE_NOT_FOUND", "usage data not found", 404)
 )
+
+// Event Errors
+var (
+	ErrInvalidEventID = NewAppError("INVALID_EVENT_ID", "invalid last event ID", 400)
+)
+
+// Webhook Errors
+var (
+	ErrWebhookNotFound      = NewAppError("WEBHOOK_NOT_FOUND", "webhook not found", 404)
+	ErrInvalidWebhook       = NewAppError("INVALID_WEBHOOK", "invalid webhook", 400)
+	ErrWebhookEventNotFound = NewAppError("WEBHOOK_EVENT_NOT_FOUND", "webhook event not found", 404)
+)
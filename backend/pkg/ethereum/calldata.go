@@ -0,0 +1,198 @@
+package ethereum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// EncodeCall encodes a call of method with args. abiJSON is a contract ABI
+// or a single fragment of it. Arguments are JSON values: numbers as numbers
+// or decimal strings, addresses and bytes as hex strings, arrays as arrays
+// and tuples as arrays or objects keyed by component name.
+func EncodeCall(abiJSON string, method string, args []json.RawMessage) ([]byte, error) {
+	def := strings.TrimSpace(abiJSON)
+	if strings.HasPrefix(def, "{") {
+		def = "[" + def + "]"
+	}
+	parsed, err := abi.JSON(strings.NewReader(def))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABI: %w", err)
+	}
+	m, ok := parsed.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method %s not found in ABI", method)
+	}
+	if len(args) != len(m.Inputs) {
+		return nil, fmt.Errorf("method %s takes %d arguments, got %d", m.Sig, len(m.Inputs), len(args))
+	}
+
+	values := make([]interface{}, len(args))
+	for i, input := range m.Inputs {
+		v, err := decodeArg(input.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, input.Type.String(), err)
+		}
+		values[i] = v.Interface()
+	}
+	return parsed.Pack(method, values...)
+}
+
+// decodeArg converts a JSON value to the Go type the abi package packs as typ
+func decodeArg(typ abi.Type, raw json.RawMessage) (reflect.Value, error) {
+	goType := typ.GetType()
+
+	switch typ.T {
+	case abi.SliceTy, abi.ArrayTy:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return reflect.Value{}, fmt.Errorf("expected an array")
+		}
+		var v reflect.Value
+		if typ.T == abi.SliceTy {
+			v = reflect.MakeSlice(goType, len(elems), len(elems))
+		} else {
+			if len(elems) != typ.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d elements, got %d", typ.Size, len(elems))
+			}
+			v = reflect.New(goType).Elem()
+		}
+		for i, elem := range elems {
+			ev, err := decodeArg(*typ.Elem, elem)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			v.Index(i).Set(ev)
+		}
+		return v, nil
+
+	case abi.TupleTy:
+		elems, err := tupleElems(typ, raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.New(goType).Elem()
+		for i, elemType := range typ.TupleElems {
+			ev, err := decodeArg(*elemType, elems[i])
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: %w", typ.TupleRawNames[i], err)
+			}
+			v.Field(i).Set(ev)
+		}
+		return v, nil
+
+	case abi.IntTy, abi.UintTy:
+		n, err := decodeInt(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		bits := typ.Size
+		if typ.T == abi.IntTy {
+			bits--
+		}
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		if n.Cmp(limit) >= 0 || (typ.T == abi.UintTy && n.Sign() < 0) || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return reflect.Value{}, fmt.Errorf("value out of range of %s", typ.String())
+		}
+		// Up to 64 bits the abi package packs native integers
+		if goType.Kind() == reflect.Ptr {
+			return reflect.ValueOf(n), nil
+		}
+		if typ.T == abi.UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(goType), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(goType), nil
+
+	case abi.BoolTy:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return reflect.Value{}, fmt.Errorf("expected a boolean")
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.StringTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, fmt.Errorf("expected a string")
+		}
+		return reflect.ValueOf(s), nil
+
+	case abi.AddressTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil || !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("expected an address")
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.BytesTy, abi.FixedBytesTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, fmt.Errorf("expected a hex string")
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("expected a hex string: %w", err)
+		}
+		if typ.T == abi.BytesTy {
+			return reflect.ValueOf(b), nil
+		}
+		if len(b) != typ.Size {
+			return reflect.Value{}, fmt.Errorf("expected %d bytes, got %d", typ.Size, len(b))
+		}
+		v := reflect.New(goType).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported type")
+}
+
+// tupleElems returns the components of a tuple given as array or object
+func tupleElems(typ abi.Type, raw json.RawMessage) ([]json.RawMessage, error) {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err == nil {
+		if len(elems) != len(typ.TupleElems) {
+			return nil, fmt.Errorf("expected %d components, got %d", len(typ.TupleElems), len(elems))
+		}
+		return elems, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("expected an array or object")
+	}
+	elems = make([]json.RawMessage, len(typ.TupleRawNames))
+	for i, name := range typ.TupleRawNames {
+		v, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("missing component %s", name)
+		}
+		elems[i] = v
+	}
+	return elems, nil
+}
+
+// decodeInt reads an integer given as JSON number or as decimal or hex string
+func decodeInt(raw json.RawMessage) (*big.Int, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		var num json.Number
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&num); err != nil {
+			return nil, fmt.Errorf("expected an integer")
+		}
+		s = num.String()
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
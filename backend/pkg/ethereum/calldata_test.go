@@ -0,0 +1,78 @@
+package ethereum
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func rawArgs(t *testing.T, args string) []json.RawMessage {
+	t.Helper()
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(args), &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestEncodeCall(t *testing.T) {
+	spender := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	approve := `{"type":"function","name":"approve","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}]}`
+
+	// Same encoding as a token transfer, only the selector differs
+	want := TransferCalldata(spender, new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil))
+	copy(want, common.FromHex("0x095ea7b3"))
+
+	for _, args := range []string{
+		`["` + spender.Hex() + `", "1000000000000000000000000"]`,
+		`["` + spender.Hex() + `", "0xd3c21bcecceda1000000"]`,
+	} {
+		got, err := EncodeCall(approve, "approve", rawArgs(t, args))
+		if err != nil {
+			t.Fatalf("EncodeCall(%s): %v", args, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("EncodeCall(%s) = %x, want %x", args, got, want)
+		}
+	}
+}
+
+func TestEncodeCallTypes(t *testing.T) {
+	abiJSON := `[{"type":"function","name":"mint","inputs":[
+		{"name":"to","type":"address"},
+		{"name":"ids","type":"uint8[]"},
+		{"name":"tag","type":"bytes4"},
+		{"name":"order","type":"tuple","components":[{"name":"price","type":"int64"},{"name":"open","type":"bool"},{"name":"memo","type":"string"}]}
+	]}]`
+	to := `"0x00000000000000000000000000000000000000aa"`
+
+	tests := []struct {
+		args    string
+		wantErr bool
+	}{
+		{args: `[` + to + `, [1, 2, 255], "0x01020304", {"price": -5, "open": true, "memo": "hi"}]`},
+		{args: `[` + to + `, [1], "0x01020304", [-5, true, "hi"]]`},
+		{args: `[` + to + `, [256], "0x01020304", [-5, true, "hi"]]`, wantErr: true},
+		{args: `[` + to + `, [1], "0x0102", [-5, true, "hi"]]`, wantErr: true},
+		{args: `[` + to + `, [1], "0x01020304", {"price": -5, "open": true}]`, wantErr: true},
+		{args: `["not an address", [1], "0x01020304", [-5, true, "hi"]]`, wantErr: true},
+		{args: `[` + to + `, [1], "0x01020304"]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := EncodeCall(abiJSON, "mint", rawArgs(t, tt.args))
+		if tt.wantErr && err == nil {
+			t.Errorf("EncodeCall(%s) succeeded, want error", tt.args)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("EncodeCall(%s): %v", tt.args, err)
+		}
+	}
+
+	if _, err := EncodeCall(abiJSON, "burn", nil); err == nil {
+		t.Error("EncodeCall with unknown method succeeded, want error")
+	}
+}
@@ -0,0 +1,207 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"mpc/internal/config"
+	"mpc/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+)
+
+// ErrNoEndpoint is returned when none of the RPC URLs of a chain could be dialed
+var ErrNoEndpoint = errors.New("no RPC endpoint available")
+
+// endpoint is one RPC URL of a chain
+type endpoint struct {
+	url     string
+	client  *ethclient.Client
+	healthy bool
+	head    uint64
+}
+
+// endpoints are the RPC URLs of a chain in order of preference. Calls go to
+// the first healthy one, a health loop redials broken endpoints and skips
+// the ones failing or lagging behind the others.
+type endpoints struct {
+	list        []*endpoint
+	mu          sync.RWMutex
+	timeout     time.Duration
+	maxBlockLag uint64
+	chainID     int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newEndpoints(ctx context.Context, chainID int, urls []string, cfg *config.EthConfig) (*endpoints, error) {
+	e := &endpoints{
+		timeout:     cfg.RPCTimeout,
+		maxBlockLag: cfg.MaxBlockLag,
+		chainID:     chainID,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for _, url := range urls {
+		if url != "" {
+			e.list = append(e.list, &endpoint{url: url})
+		}
+	}
+
+	if e.timeout <= 0 {
+		e.timeout = 5 * time.Second
+	}
+	interval := cfg.HealthInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	e.check(ctx)
+	if e.current() == nil {
+		return nil, ErrNoEndpoint
+	}
+	go e.run(interval)
+	return e, nil
+}
+
+// current returns the client of the first healthy endpoint, or of the first
+// dialed one while none is healthy. It is nil only if nothing could be dialed.
+func (e *endpoints) current() *ethclient.Client {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var fallback *ethclient.Client
+	for _, ep := range e.list {
+		if ep.client == nil {
+			continue
+		}
+		if ep.healthy {
+			return ep.client
+		}
+		if fallback == nil {
+			fallback = ep.client
+		}
+	}
+	return fallback
+}
+
+// run checks the endpoints every interval until close
+func (e *endpoints) run(interval time.Duration) {
+	defer close(e.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.check(context.Background())
+		}
+	}
+}
+
+// check dials missing clients and asks every endpoint for its head. An
+// endpoint is healthy if it answers and is at most maxBlockLag blocks
+// behind the best head.
+func (e *endpoints) check(ctx context.Context) {
+	type status struct {
+		client *ethclient.Client
+		head   uint64
+		err    error
+	}
+
+	e.mu.RLock()
+	statuses := make([]status, len(e.list))
+	for i, ep := range e.list {
+		statuses[i].client = ep.client
+	}
+	e.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i, ep := range e.list {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, e.timeout)
+			defer cancel()
+
+			st := &statuses[i]
+			if st.client != nil {
+				if st.head, st.err = st.client.BlockNumber(ctx); st.err == nil {
+					return
+				}
+			}
+
+			// Dial a new client, a broken one is kept until that works
+			client, err := ethclient.DialContext(ctx, url)
+			if err != nil {
+				if st.err == nil {
+					st.err = err
+				}
+				return
+			}
+			st.client = client
+			st.head, st.err = client.BlockNumber(ctx)
+		}(i, ep.url)
+	}
+	wg.Wait()
+
+	var best uint64
+	for _, st := range statuses {
+		if st.err == nil && st.head > best {
+			best = st.head
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, ep := range e.list {
+		st := statuses[i]
+		healthy := st.err == nil && best-st.head <= e.maxBlockLag
+		if healthy != ep.healthy {
+			fields := []zap.Field{
+				zap.Int("chain_id", e.chainID),
+				zap.String("url", ep.url),
+				zap.Uint64("head", st.head),
+				zap.Uint64("best_head", best),
+			}
+			if healthy {
+				logger.Info("RPC endpoint is healthy", fields...)
+			} else {
+				logger.Warn("RPC endpoint is unhealthy", append(fields, zap.Error(st.err))...)
+			}
+		}
+		ep.healthy = healthy
+		ep.head = st.head
+
+		if ep.client != nil && ep.client != st.client {
+			ep.client.Close()
+		}
+		ep.client = st.client
+	}
+}
+
+// close stops the health loop and closes the clients
+func (e *endpoints) close() {
+	select {
+	case <-e.stop:
+		return
+	default:
+		close(e.stop)
+	}
+	<-e.done
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ep := range e.list {
+		if ep.client != nil {
+			ep.client.Close()
+			ep.client = nil
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	geth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ERC-20 function selectors
+var (
+	transferSelector  = common.FromHex("0xa9059cbb") // transfer(address,uint256)
+	balanceOfSelector = common.FromHex("0x70a08231") // balanceOf(address)
+)
+
+// TransferTopic is the topic of Transfer(address,address,uint256) logs
+var TransferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// TokenTransfer is a Transfer log of an ERC-20 contract
+type TokenTransfer struct {
+	Contract    common.Address
+	From        common.Address
+	To          common.Address
+	Amount      *big.Int // base units
+	TxHash      common.Hash
+	LogIndex    uint
+	BlockNumber uint64
+}
+
+// ParseTransferLog reads an ERC-20 Transfer log, false if log is not one.
+// ERC-721 transfers share the topic but index the token ID, they are not
+// ERC-20 transfers.
+func ParseTransferLog(log types.Log) (TokenTransfer, bool) {
+	if len(log.Topics) != 3 || log.Topics[0] != TransferTopic || len(log.Data) != 32 {
+		return TokenTransfer{}, false
+	}
+	return TokenTransfer{
+		Contract:    log.Address,
+		From:        common.BytesToAddress(log.Topics[1].Bytes()),
+		To:          common.BytesToAddress(log.Topics[2].Bytes()),
+		Amount:      new(big.Int).SetBytes(log.Data),
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+		BlockNumber: log.BlockNumber,
+	}, true
+}
+
+// TokenTransfers returns the ERC-20 transfers of a block on the given contracts
+func (c *EthClient) TokenTransfers(ctx context.Context, block common.Hash, contracts []common.Address) ([]TokenTransfer, error) {
+	logs, err := c.rpc().FilterLogs(ctx, geth.FilterQuery{
+		BlockHash: &block,
+		Addresses: contracts,
+		Topics:    [][]common.Hash{{TransferTopic}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch logs: %w", err)
+	}
+
+	var transfers []TokenTransfer
+	for _, log := range logs {
+		// Removed logs belong to a reorged block
+		if log.Removed {
+			continue
+		}
+		if transfer, ok := ParseTransferLog(log); ok {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
+
+// AccountTokenTransfers returns the ERC-20 transfers from or to account on
+// the given contracts in the blocks from to to, ordered by block and log
+func (c *EthClient) AccountTokenTransfers(ctx context.Context, from, to uint64, contracts []common.Address, account common.Address) ([]TokenTransfer, error) {
+	accountTopic := common.BytesToHash(account.Bytes())
+	// Topics match by position, the sender and the recipient take a query each
+	queries := [][][]common.Hash{
+		{{TransferTopic}, {accountTopic}},
+		{{TransferTopic}, nil, {accountTopic}},
+	}
+
+	type logID struct {
+		tx    common.Hash
+		index uint
+	}
+	seen := make(map[logID]bool)
+	var transfers []TokenTransfer
+	for _, topics := range queries {
+		logs, err := c.rpc().FilterLogs(ctx, geth.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: contracts,
+			Topics:    topics,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch logs: %w", err)
+		}
+		for _, log := range logs {
+			// Transfers of account to itself match both queries
+			id := logID{log.TxHash, log.Index}
+			if log.Removed || seen[id] {
+				continue
+			}
+			seen[id] = true
+			if transfer, ok := ParseTransferLog(log); ok {
+				transfers = append(transfers, transfer)
+			}
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber < transfers[j].BlockNumber
+		}
+		return transfers[i].LogIndex < transfers[j].LogIndex
+	})
+	return transfers, nil
+}
+
+// TransferCalldata encodes transfer(to, amount)
+func TransferCalldata(to common.Address, amount *big.Int) []byte {
+	data := make([]byte, 0, 4+32+32)
+	data = append(data, transferSelector...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+	return data
+}
+
+// CreateTokenTransfer builds an EIP-1559 transaction calling transfer on an
+// ERC-20 contract. amount is in whole tokens and scaled by decimals.
+func (c *EthClient) CreateTokenTransfer(ctx context.Context, chainID *big.Int, nonce uint64, fromAddressHex string, tokenAddressHex string, to string, amount string, decimals int32, speed Speed) (*types.Transaction, error) {
+	if !common.IsHexAddress(tokenAddressHex) {
+		return nil, fmt.Errorf("invalid token address")
+	}
+	if !common.IsHexAddress(to) {
+		return nil, fmt.Errorf("invalid recipient address")
+	}
+	units, err := ToBaseUnits(amount, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	data := TransferCalldata(common.HexToAddress(to), units)
+	return c.buildTransaction(ctx, chainID, nonce, common.HexToAddress(fromAddressHex), common.HexToAddress(tokenAddressHex), big.NewInt(0), data, 0, speed)
+}
+
+// TokenBalance returns the ERC-20 balance of owner in base units
+func (c *EthClient) TokenBalance(ctx context.Context, tokenAddressHex string, owner string) (*big.Int, error) {
+	token := common.HexToAddress(tokenAddressHex)
+	data := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(common.HexToAddress(owner).Bytes(), 32)...)
+
+	result, err := c.rpc().CallContract(ctx, geth.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call balanceOf: %w", err)
+	}
+	if len(result) != 32 {
+		return nil, fmt.Errorf("unexpected balanceOf result of %d bytes", len(result))
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// IsEnoughTokenBalance reports whether owner holds at least amount whole tokens
+func (c *EthClient) IsEnoughTokenBalance(ctx context.Context, tokenAddressHex string, owner string, amount string, decimals int32) (bool, error) {
+	units, err := ToBaseUnits(amount, decimals)
+	if err != nil {
+		return false, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	balance, err := c.TokenBalance(ctx, tokenAddressHex, owner)
+	if err != nil {
+		return false, err
+	}
+	return balance.Cmp(units) >= 0, nil
+}
@@ -0,0 +1,103 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestToBaseUnits(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int32
+		want     string
+		wantErr  bool
+	}{
+		{amount: "1", decimals: 18, want: "1000000000000000000"},
+		{amount: "1.5", decimals: 6, want: "1500000"},
+		{amount: "0.000001", decimals: 6, want: "1"},
+		{amount: "0.0000001", decimals: 6, wantErr: true},
+		{amount: "abc", decimals: 6, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ToBaseUnits(tt.amount, tt.decimals)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ToBaseUnits(%s, %d) = %s, want error", tt.amount, tt.decimals, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ToBaseUnits(%s, %d): %v", tt.amount, tt.decimals, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ToBaseUnits(%s, %d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestFromBaseUnits(t *testing.T) {
+	tests := []struct {
+		units    string
+		decimals int32
+		want     string
+	}{
+		{units: "1000000000000000000", decimals: 18, want: "1"},
+		{units: "1500000", decimals: 6, want: "1.5"},
+		{units: "1", decimals: 6, want: "0.000001"},
+		{units: "0", decimals: 6, want: "0"},
+		{units: "42", decimals: 0, want: "42"},
+	}
+
+	for _, tt := range tests {
+		units, _ := new(big.Int).SetString(tt.units, 10)
+		if got := FromBaseUnits(units, tt.decimals); got != tt.want {
+			t.Errorf("FromBaseUnits(%s, %d) = %s, want %s", tt.units, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseTransferLog(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	from := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	log := types.Log{
+		Address:     token,
+		Topics:      []common.Hash{TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(1500000).Bytes(), 32),
+		Index:       3,
+		BlockNumber: 42,
+	}
+
+	transfer, ok := ParseTransferLog(log)
+	if !ok {
+		t.Fatal("ParseTransferLog did not read an ERC-20 transfer")
+	}
+	if transfer.Contract != token || transfer.From != from || transfer.To != to || transfer.Amount.Int64() != 1500000 || transfer.LogIndex != 3 || transfer.BlockNumber != 42 {
+		t.Errorf("ParseTransferLog = %+v", transfer)
+	}
+
+	// ERC-721 transfers index the token ID and have no data
+	nft := log
+	nft.Topics = append(nft.Topics, common.BigToHash(big.NewInt(7)))
+	nft.Data = nil
+	if _, ok := ParseTransferLog(nft); ok {
+		t.Error("ParseTransferLog read an ERC-721 transfer")
+	}
+}
+
+func TestTransferCalldata(t *testing.T) {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	data := TransferCalldata(to, big.NewInt(1500000))
+
+	want := "a9059cbb" +
+		"00000000000000000000000000000000000000000000000000000000000000aa" +
+		"000000000000000000000000000000000000000000000000000000000016e360"
+	if got := common.Bytes2Hex(data); got != want {
+		t.Fatalf("calldata = %s, want %s", got, want)
+	}
+}
@@ -2,10 +2,13 @@ package ethereum
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"mpc/internal/config"
 	"mpc/pkg/logger"
 
+	geth "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -13,56 +16,208 @@ import (
 	"go.uber.org/zap"
 )
 
+// EthClient talks to one chain through its RPC endpoints
 type EthClient struct {
-	rpcURL string
-	client *ethclient.Client
+	chainID      int
+	endpoints    *endpoints
+	cfg          config.EthConfig
+	percentiles  map[Speed]float64
+	defaultSpeed Speed
 }
 
-// NewEthClient initializes a new Ethereum client
-func NewEthClient(rpcURL string) (*EthClient, error) {
-	client, err := ethclient.Dial(rpcURL)
+// NewEthClient dials the RPC URLs of a chain, calls go to the first healthy one
+func NewEthClient(ctx context.Context, cfg *config.EthConfig, chainID int, urls []string) (*EthClient, error) {
+	c := &EthClient{
+		chainID: chainID,
+		cfg:     *cfg,
+		percentiles: map[Speed]float64{
+			SpeedSlow:     cfg.SlowPercentile,
+			SpeedStandard: cfg.StandardPercentile,
+			SpeedFast:     cfg.FastPercentile,
+		},
+		defaultSpeed: SpeedStandard,
+	}
+	if cfg.DefaultSpeed != "" {
+		var err error
+		if c.defaultSpeed, err = c.ParseSpeed(cfg.DefaultSpeed); err != nil {
+			return nil, err
+		}
+	}
+	if c.cfg.FeeHistoryBlocks == 0 {
+		c.cfg.FeeHistoryBlocks = 20
+	}
+	if c.cfg.BaseFeeMultiplier == 0 {
+		c.cfg.BaseFeeMultiplier = 2
+	}
+	if c.cfg.ReplacementFeeBump < 10 {
+		c.cfg.ReplacementFeeBump = 10
+	}
+
+	endpoints, err := newEndpoints(ctx, chainID, urls, &c.cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+		return nil, fmt.Errorf("failed to connect to chain %d: %w", chainID, err)
 	}
+	c.endpoints = endpoints
+	return c, nil
+}
+
+// Close stops the health checks and closes the connections
+func (c *EthClient) Close() {
+	c.endpoints.close()
+}
 
-	return &EthClient{
-		rpcURL: rpcURL,
-		client: client,
-	}, nil
+// rpc returns the client of the endpoint calls currently go to
+func (c *EthClient) rpc() *ethclient.Client {
+	return c.endpoints.current()
 }
 
-// CreateTransaction sends a transaction from a wallet to another address
-func (c *EthClient) CreateTransaction(ctx context.Context, fromAddressHex string, to string, amount string) (*types.Transaction, error) {
+// CreateTransaction builds an EIP-1559 transaction from a wallet to another address
+func (c *EthClient) CreateTransaction(ctx context.Context, chainID *big.Int, nonce uint64, fromAddressHex string, to string, amount string, speed Speed) (*types.Transaction, error) {
 	// Validate recipient address and amount
 	toAddress, amountWei, err := c.validateTransactionInputs(to, amount)
 	if err != nil {
 		return nil, err
 	}
 
-	fromAddress := common.HexToAddress(fromAddressHex)
+	return c.buildTransaction(ctx, chainID, nonce, common.HexToAddress(fromAddressHex), toAddress, amountWei, nil, 0, speed)
+}
 
-	// Fetch nonce, gas price, and chain ID
-	nonce, err := c.fetchNonce(ctx, fromAddress)
-	if err != nil {
-		return nil, err
+// CreateContractCall builds an EIP-1559 transaction calling a contract with
+// data and value in wei. The gas limit is estimated if gasLimit is 0.
+func (c *EthClient) CreateContractCall(ctx context.Context, chainID *big.Int, nonce uint64, fromAddressHex string, to string, value *big.Int, data []byte, gasLimit uint64, speed Speed) (*types.Transaction, error) {
+	if !common.IsHexAddress(to) {
+		return nil, fmt.Errorf("invalid contract address")
 	}
-	gasPrice, err := c.fetchGasPrice(ctx)
+
+	return c.buildTransaction(ctx, chainID, nonce, common.HexToAddress(fromAddressHex), common.HexToAddress(to), value, data, gasLimit, speed)
+}
+
+// buildTransaction fills in fees and gas limit of an EIP-1559 transaction,
+// a gasLimit of 0 is estimated
+func (c *EthClient) buildTransaction(ctx context.Context, chainID *big.Int, nonce uint64, from common.Address, to common.Address, value *big.Int, data []byte, gasLimit uint64, speed Speed) (*types.Transaction, error) {
+	fees, err := c.SuggestFees(ctx, speed)
 	if err != nil {
 		return nil, err
 	}
-	tx := types.NewTransaction(nonce, toAddress, amountWei, 210000, gasPrice, nil)
+	gas := gasLimit
+	if gas == 0 {
+		gas, err = c.EstimateGas(ctx, geth.CallMsg{
+			From:      from,
+			To:        &to,
+			GasFeeCap: fees.MaxFeePerGas,
+			GasTipCap: fees.MaxPriorityFeePerGas,
+			Value:     value,
+			Data:      data,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.MaxPriorityFeePerGas,
+		GasFeeCap: fees.MaxFeePerGas,
+		Gas:       gas,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	})
 
 	return tx, nil
 }
 
+// HasFundsFor reports whether address can pay the value and the maximum gas cost of tx
+func (c *EthClient) HasFundsFor(ctx context.Context, address string, tx *types.Transaction) (bool, error) {
+	balance, err := c.rpc().BalanceAt(ctx, common.HexToAddress(address), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch balance: %w", err)
+	}
+
+	return balance.Cmp(tx.Cost()) >= 0, nil
+}
+
 func (c *EthClient) SendTransaction(ctx context.Context, signedTx *types.Transaction) (string, error) {
-	err := c.client.SendTransaction(ctx, signedTx)
+	err := c.rpc().SendTransaction(ctx, signedTx)
 	if err != nil {
 		return "", fmt.Errorf("failed to send transaction: %w", err)
 	}
 	return signedTx.Hash().Hex(), nil
 }
 
+// IsKnownTransaction reports whether the node has the transaction, mined or in its mempool
+func (c *EthClient) IsKnownTransaction(ctx context.Context, hash common.Hash) bool {
+	_, _, err := c.rpc().TransactionByHash(ctx, hash)
+	return err == nil
+}
+
+// ChainID returns the chain ID of the connected node
+func (c *EthClient) ChainID(ctx context.Context) (*big.Int, error) {
+	chainID, err := c.rpc().ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain ID: %w", err)
+	}
+	return chainID, nil
+}
+
+// BlockNumber returns the number of the latest block
+func (c *EthClient) BlockNumber(ctx context.Context) (uint64, error) {
+	number, err := c.rpc().BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch block number: %w", err)
+	}
+	return number, nil
+}
+
+// BlockByNumber returns a block with its transactions, the latest one if number is nil
+func (c *EthClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	block, err := c.rpc().BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch block: %w", err)
+	}
+	return block, nil
+}
+
+// HeaderByNumber returns a block header, the latest one if number is nil
+func (c *EthClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := c.rpc().HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch block header: %w", err)
+	}
+	return header, nil
+}
+
+// TransactionSender returns the sender of the transaction at index in a block
+func (c *EthClient) TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error) {
+	sender, err := c.rpc().TransactionSender(ctx, tx, block, index)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to fetch sender: %w", err)
+	}
+	return sender, nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction, nil if it is not mined
+func (c *EthClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := c.rpc().TransactionReceipt(ctx, hash)
+	if errors.Is(err, geth.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch receipt: %w", err)
+	}
+	return receipt, nil
+}
+
+// MinedNonce returns the number of transactions of an address included in the latest block
+func (c *EthClient) MinedNonce(ctx context.Context, address common.Address) (uint64, error) {
+	nonce, err := c.rpc().NonceAt(ctx, address, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch nonce: %w", err)
+	}
+	return nonce, nil
+}
+
 func (c *EthClient) IsEnoughBalance(ctx context.Context, address string, amount string) (bool, error) {
 	// Validate recipient address and amount
 	_, amountWei, err := c.validateTransactionInputs(address, amount)
@@ -70,7 +225,7 @@ func (c *EthClient) IsEnoughBalance(ctx context.Context, address string, amount
 		return false, err
 	}
 
-	balance, err := c.client.BalanceAt(ctx, common.HexToAddress(address), nil)
+	balance, err := c.rpc().BalanceAt(ctx, common.HexToAddress(address), nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to fetch balance: %w", err)
 	}
@@ -83,7 +238,7 @@ func (c *EthClient) GetBalance(ctx context.Context, address string) (string, err
 		return "", fmt.Errorf("invalid address: %s", address)
 	}
 	addr := common.HexToAddress(address)
-	balance, err := c.client.BalanceAt(ctx, addr, nil)
+	balance, err := c.rpc().BalanceAt(ctx, addr, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch balance: %w", err)
 	}
@@ -109,9 +264,9 @@ func (c *EthClient) validateTransactionInputs(address string, amount string) (co
 	return addr, amountWei, nil
 }
 
-// fetchNonce retrieves the nonce without retry
-func (c *EthClient) fetchNonce(ctx context.Context, fromAddress common.Address) (uint64, error) {
-	nonce, err := c.client.PendingNonceAt(ctx, fromAddress)
+// PendingNonce retrieves the next nonce of an address including pending transactions
+func (c *EthClient) PendingNonce(ctx context.Context, fromAddress common.Address) (uint64, error) {
+	nonce, err := c.rpc().PendingNonceAt(ctx, fromAddress)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch nonce: %w", err)
 	}
@@ -119,38 +274,36 @@ func (c *EthClient) fetchNonce(ctx context.Context, fromAddress common.Address)
 	return nonce, nil
 }
 
-// fetchGasPrice retrieves the gas price without retry
-func (c *EthClient) fetchGasPrice(ctx context.Context) (*big.Int, error) {
-	gasPrice, err := c.client.SuggestGasPrice(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch gas price: %w", err)
-	}
-	logger.Info("got gas price", zap.String("gas_price", gasPrice.String()))
-	return gasPrice, nil
-}
-
 // Convert amount to Wei
 func (c *EthClient) convertToWei(amount string) (*big.Int, error) {
+	return ToBaseUnits(amount, 18)
+}
+
+// ToBaseUnits converts a decimal amount to the smallest unit of a token with
+// the given decimals. Amounts more precise than the token are refused.
+func ToBaseUnits(amount string, decimals int32) (*big.Int, error) {
 	// Parse the decimal amount
 	decimalAmount, err := decimal.NewFromString(amount)
 	if err != nil {
 		return nil, fmt.Errorf("invalid decimal amount: %w", err)
 	}
 
-	// Multiply by 10^18 for Wei conversion
-	weiAmount := decimalAmount.Mul(decimal.NewFromBigInt(big.NewInt(1), 18))
-
-	// Convert to big.Int
-	wei := new(big.Int)
-	wei, ok := wei.SetString(weiAmount.String(), 10)
-	if !ok {
-		return nil, fmt.Errorf("failed to convert to wei")
+	// Multiply by 10^decimals
+	units := decimalAmount.Shift(decimals)
+	if !units.IsInteger() {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", amount, decimals)
 	}
 
 	logger.Info("amount conversion",
 		zap.String("original_amount", amount),
-		zap.String("decimal_amount", decimalAmount.String()),
-		zap.String("wei_amount", wei.String()))
+		zap.Int32("decimals", decimals),
+		zap.String("base_units", units.String()))
+
+	return units.BigInt(), nil
+}
 
-	return wei, nil
+// FromBaseUnits converts an amount in the smallest unit of a token with the
+// given decimals to a decimal amount
+func FromBaseUnits(units *big.Int, decimals int32) string {
+	return decimal.NewFromBigInt(units, -decimals).String()
 }
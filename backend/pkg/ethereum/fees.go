@@ -0,0 +1,163 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"mpc/pkg/logger"
+
+	geth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"go.uber.org/zap"
+)
+
+// Speed is a fee tier, faster tiers pay a higher priority fee
+type Speed string
+
+const (
+	SpeedSlow     Speed = "slow"
+	SpeedStandard Speed = "standard"
+	SpeedFast     Speed = "fast"
+)
+
+// Fees are the EIP-1559 fees of a transaction
+type Fees struct {
+	BaseFee              *big.Int // base fee of the next block
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerGas         *big.Int
+}
+
+// ParseSpeed validates a fee tier, empty selects the configured default
+func (c *EthClient) ParseSpeed(speed string) (Speed, error) {
+	if speed == "" {
+		return c.defaultSpeed, nil
+	}
+	if _, ok := c.percentiles[Speed(speed)]; !ok {
+		return "", fmt.Errorf("unknown fee speed: %s", speed)
+	}
+	return Speed(speed), nil
+}
+
+// SuggestFees derives the fees of a speed tier from eth_feeHistory
+func (c *EthClient) SuggestFees(ctx context.Context, speed Speed) (*Fees, error) {
+	percentile, ok := c.percentiles[speed]
+	if !ok {
+		return nil, fmt.Errorf("unknown fee speed: %s", speed)
+	}
+
+	history, err := c.rpc().FeeHistory(ctx, c.cfg.FeeHistoryBlocks, nil, []float64{percentile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, fmt.Errorf("empty fee history")
+	}
+	// BaseFee has one more entry than blocks, the last one is the next block's
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	tip, err := c.priorityFee(ctx, history.Reward)
+	if err != nil {
+		return nil, err
+	}
+
+	maxFee := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(c.cfg.BaseFeeMultiplier))
+	maxFee.Add(maxFee, tip)
+
+	logger.Info("suggested fees",
+		zap.String("speed", string(speed)),
+		zap.String("base_fee", baseFee.String()),
+		zap.String("max_priority_fee_per_gas", tip.String()),
+		zap.String("max_fee_per_gas", maxFee.String()))
+
+	return &Fees{
+		BaseFee:              baseFee,
+		MaxPriorityFeePerGas: tip,
+		MaxFeePerGas:         maxFee,
+	}, nil
+}
+
+// priorityFee averages the reward percentile over the blocks that had
+// transactions, falling back to the node's suggestion on an idle chain
+func (c *EthClient) priorityFee(ctx context.Context, rewards [][]*big.Int) (*big.Int, error) {
+	sum := new(big.Int)
+	count := int64(0)
+	for _, reward := range rewards {
+		if len(reward) == 0 || reward[0].Sign() == 0 {
+			continue
+		}
+		sum.Add(sum, reward[0])
+		count++
+	}
+	if count > 0 {
+		return sum.Div(sum, big.NewInt(count)), nil
+	}
+
+	tip, err := c.rpc().SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gas tip cap: %w", err)
+	}
+	return tip, nil
+}
+
+// BumpFees returns tx with fees of at least ReplacementFeeBump percent above
+// prev, the fees of the pending transaction tx replaces. Nodes only accept a
+// transaction with the same nonce if both fees are raised this way, fees of
+// tx already above that are kept.
+func (c *EthClient) BumpFees(tx *types.Transaction, prev *Fees) *types.Transaction {
+	tip := maxBig(tx.GasTipCap(), bumpFee(prev.MaxPriorityFeePerGas, c.cfg.ReplacementFeeBump))
+	feeCap := maxBig(tx.GasFeeCap(), bumpFee(prev.MaxFeePerGas, c.cfg.ReplacementFeeBump))
+	feeCap = maxBig(feeCap, tip)
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:    tx.ChainId(),
+		Nonce:      tx.Nonce(),
+		GasTipCap:  tip,
+		GasFeeCap:  feeCap,
+		Gas:        tx.Gas(),
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	})
+}
+
+// bumpFee raises fee by percent, rounding up
+func bumpFee(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// EstimateGas estimates the gas limit of msg. Contract calls get
+// GasLimitBuffer percent on top since their gas use can change. A call
+// that reverts returns a *RevertError.
+func (c *EthClient) EstimateGas(ctx context.Context, msg geth.CallMsg) (uint64, error) {
+	gas, err := c.rpc().EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, callError("estimate gas", err)
+	}
+	if gas > params.TxGas {
+		gas += gas * c.cfg.GasLimitBuffer / 100
+	}
+	return gas, nil
+}
+
+// ReceiptFee returns the wei the sender paid for the gas of a mined transaction
+func ReceiptFee(receipt *types.Receipt) *big.Int {
+	fee := new(big.Int)
+	if receipt.EffectiveGasPrice != nil {
+		fee.Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	if receipt.BlobGasPrice != nil {
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice))
+	}
+	return fee
+}
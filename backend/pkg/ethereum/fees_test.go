@@ -0,0 +1,63 @@
+package ethereum
+
+import (
+	"math/big"
+	"mpc/internal/config"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBumpFees(t *testing.T) {
+	c := &EthClient{cfg: config.EthConfig{ReplacementFeeBump: 10}}
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	tests := []struct {
+		name              string
+		tip, feeCap       int64 // fees of the rebuilt transaction
+		prevTip, prevCap  int64
+		wantTip, wantFees int64
+	}{
+		{name: "raised to the minimum bump", tip: 100, feeCap: 1000, prevTip: 100, prevCap: 1000, wantTip: 110, wantFees: 1100},
+		{name: "rounded up", tip: 1, feeCap: 1, prevTip: 3, prevCap: 7, wantTip: 4, wantFees: 8},
+		{name: "higher suggestion kept", tip: 500, feeCap: 5000, prevTip: 100, prevCap: 1000, wantTip: 500, wantFees: 5000},
+		{name: "fee cap covers tip", tip: 100, feeCap: 100, prevTip: 1000, prevCap: 100, wantTip: 1100, wantFees: 1100},
+	}
+
+	for _, tt := range tests {
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(11155111),
+			Nonce:     7,
+			GasTipCap: big.NewInt(tt.tip),
+			GasFeeCap: big.NewInt(tt.feeCap),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(1),
+		})
+		got := c.BumpFees(tx, &Fees{
+			MaxPriorityFeePerGas: big.NewInt(tt.prevTip),
+			MaxFeePerGas:         big.NewInt(tt.prevCap),
+		})
+
+		if got.GasTipCap().Int64() != tt.wantTip || got.GasFeeCap().Int64() != tt.wantFees {
+			t.Errorf("%s: fees = %s/%s, want %d/%d", tt.name, got.GasTipCap(), got.GasFeeCap(), tt.wantTip, tt.wantFees)
+		}
+		if got.Nonce() != tx.Nonce() || got.Gas() != tx.Gas() || got.Value().Cmp(tx.Value()) != 0 || *got.To() != to {
+			t.Errorf("%s: replacement changed the transaction", tt.name)
+		}
+	}
+}
+
+func TestReceiptFee(t *testing.T) {
+	receipt := &types.Receipt{GasUsed: 21000, EffectiveGasPrice: big.NewInt(2_000_000_000)}
+	if got := ReceiptFee(receipt); got.String() != "42000000000000" {
+		t.Errorf("ReceiptFee = %s, want 42000000000000", got)
+	}
+
+	// Blob transactions also pay for their blobs
+	receipt.BlobGasUsed, receipt.BlobGasPrice = 131072, big.NewInt(1)
+	if got := ReceiptFee(receipt); got.String() != "42000000131072" {
+		t.Errorf("ReceiptFee = %s, want 42000000131072", got)
+	}
+}
@@ -0,0 +1,53 @@
+package ethereum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// siweSuffix ends the first line of an EIP-4361 sign-in message
+const siweSuffix = " wants you to sign in with your Ethereum account:"
+
+// MessageBytes returns the bytes of a personal_sign message. Like wallets do,
+// a 0x-prefixed hex string is the hex encoding of the message.
+func MessageBytes(message string) []byte {
+	if strings.HasPrefix(message, "0x") {
+		if b, err := hexutil.Decode(message); err == nil {
+			return b
+		}
+	}
+	return []byte(message)
+}
+
+// MessageHash returns the EIP-191 hash personal_sign signs for message
+func MessageHash(message []byte) []byte {
+	return accounts.TextHash(message)
+}
+
+// SignInDomain returns the domain asking for an EIP-4361 sign-in, false if
+// message is not a sign-in message
+func SignInDomain(message []byte) (string, bool) {
+	firstLine, _, _ := strings.Cut(string(message), "\n")
+	domain, ok := strings.CutSuffix(firstLine, siweSuffix)
+	if !ok || domain == "" {
+		return "", false
+	}
+	// The scheme is optional in the message
+	if _, rest, ok := strings.Cut(domain, "://"); ok {
+		domain = rest
+	}
+	return domain, true
+}
+
+// TypedDataHash returns the EIP-712 hash eth_signTypedData_v4 signs for data
+func TypedDataHash(data apitypes.TypedData) ([]byte, error) {
+	hash, _, err := apitypes.TypedDataAndHash(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid typed data: %w", err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,87 @@
+package ethereum
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+func TestSignInDomain(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+		wantOK  bool
+	}{
+		{message: "app.example.com wants you to sign in with your Ethereum account:\n0xabc\n\nURI: https://app.example.com", want: "app.example.com", wantOK: true},
+		{message: "https://app.example.com:8443 wants you to sign in with your Ethereum account:\n0xabc", want: "app.example.com:8443", wantOK: true},
+		{message: "Sign this to prove you own the wallet"},
+		{message: " wants you to sign in with your Ethereum account:\n0xabc"},
+	}
+
+	for _, tt := range tests {
+		got, ok := SignInDomain([]byte(tt.message))
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("SignInDomain(%q) = (%q, %v), want (%q, %v)", tt.message, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMessageHash(t *testing.T) {
+	// keccak256("\x19Ethereum Signed Message:\n5hello")
+	want := "0x50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750"
+	for _, message := range []string{"hello", "0x68656c6c6f"} {
+		if got := hexutil.Encode(MessageHash(MessageBytes(message))); got != want {
+			t.Errorf("MessageHash(%s) = %s, want %s", message, got, want)
+		}
+	}
+}
+
+func TestTypedDataHash(t *testing.T) {
+	// Example of the EIP-712 specification
+	typedDataJSON := `{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Person": [
+				{"name": "name", "type": "string"},
+				{"name": "wallet", "type": "address"}
+			],
+			"Mail": [
+				{"name": "from", "type": "Person"},
+				{"name": "to", "type": "Person"},
+				{"name": "contents", "type": "string"}
+			]
+		},
+		"primaryType": "Mail",
+		"domain": {
+			"name": "Ether Mail",
+			"version": "1",
+			"chainId": 1,
+			"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+		},
+		"message": {
+			"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+			"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+			"contents": "Hello, Bob!"
+		}
+	}`
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal([]byte(typedDataJSON), &typedData); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := TypedDataHash(typedData)
+	if err != nil {
+		t.Fatalf("TypedDataHash: %v", err)
+	}
+	want := "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+	if got := hexutil.Encode(hash); got != want {
+		t.Errorf("TypedDataHash = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,268 @@
+package ethereum
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"mpc/internal/config"
+	rd "mpc/internal/db/redis"
+	"mpc/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	nonceLockTTL   = 10 * time.Second
+	nonceLockRetry = 50 * time.Millisecond
+
+	nonceFieldNext     = "next"
+	nonceReservedField = "r:" // r:<nonce> -> reservation deadline in unix ms
+	nonceSentField     = "s:" // s:<nonce> -> hash of the broadcast transaction
+)
+
+// ErrNonceLockTimeout is returned when the nonce lock of an address stays taken
+var ErrNonceLockTimeout = errors.New("timed out waiting for nonce lock")
+
+// releaseLockScript deletes the lock only if it is still held by the caller
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// releaseSentScript forgets a sent nonce only if it still holds the given
+// transaction and not a replacement broadcast since
+var releaseSentScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call("HDEL", KEYS[1], ARGV[1])
+end
+return 0`)
+
+// NonceSource returns the next nonce of an address on a chain including pending transactions
+type NonceSource interface {
+	PendingNonce(ctx context.Context, chainID *big.Int, address common.Address) (uint64, error)
+}
+
+// NonceManager allocates nonces per chain and address so concurrent
+// transactions of a wallet never share one. State lives in Redis and is
+// only changed under a per-address lock, so several API instances can
+// share it. A nonce is reserved while its transaction is built and signed,
+// then either marked sent or released. A sent nonce is released again when
+// its transaction is dropped from the mempool. Nonces below the chain's
+// pending nonce are forgotten, and nonces that are neither reserved nor sent
+// are gaps handed out again before new ones.
+type NonceManager struct {
+	redis          *rd.Client
+	source         NonceSource
+	reservationTTL time.Duration
+}
+
+func NewNonceManager(cfg *config.EthConfig, redisClient *rd.Client, source NonceSource) *NonceManager {
+	ttl := cfg.NonceReservationTTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &NonceManager{
+		redis:          redisClient,
+		source:         source,
+		reservationTTL: ttl,
+	}
+}
+
+// Nonce is a nonce reserved for one transaction
+type Nonce struct {
+	Value uint64
+
+	m   *NonceManager
+	key string
+}
+
+// Reserve allocates the lowest free nonce of address on chainID
+func (m *NonceManager) Reserve(ctx context.Context, chainID *big.Int, address common.Address) (*Nonce, error) {
+	key := nonceKey(chainID, address)
+
+	unlock, err := m.lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	pending, err := m.source.PendingNonce(ctx, chainID, address)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := m.redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nonce state: %w", err)
+	}
+
+	next := pending
+	if v, ok := state[nonceFieldNext]; ok {
+		if stored, err := strconv.ParseUint(v, 10, 64); err == nil && stored > pending {
+			next = stored
+		}
+	}
+
+	reserved, sent := map[uint64]int64{}, map[uint64]bool{}
+	var stale []string
+	for field, v := range state {
+		prefix, n, ok := parseNonceField(field)
+		if !ok {
+			continue
+		}
+		// Used on chain or in the mempool, or the chain moved past it
+		if n < pending {
+			stale = append(stale, field)
+			continue
+		}
+		switch prefix {
+		case nonceReservedField:
+			deadline, _ := strconv.ParseInt(v, 10, 64)
+			reserved[n] = deadline
+		case nonceSentField:
+			sent[n] = true
+		}
+	}
+
+	// A nonce below next that is neither sent nor reserved is a gap left by a
+	// failed or dropped transaction, it must be used before any higher nonce
+	now := time.Now().UnixMilli()
+	nonce := next
+	for n := pending; n < next; n++ {
+		if sent[n] {
+			continue
+		}
+		if deadline, ok := reserved[n]; ok && deadline > now {
+			continue
+		}
+		logger.Warn("reusing nonce gap",
+			zap.String("key", key),
+			zap.Uint64("nonce", n),
+			zap.Uint64("pending", pending),
+			zap.Uint64("next", next))
+		nonce = n
+		break
+	}
+	if nonce == next {
+		next++
+	}
+
+	_, err = m.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(stale) > 0 {
+			pipe.HDel(ctx, key, stale...)
+		}
+		pipe.HSet(ctx, key,
+			nonceFieldNext, next,
+			nonceReservedField+strconv.FormatUint(nonce, 10), time.Now().Add(m.reservationTTL).UnixMilli(),
+		)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve nonce: %w", err)
+	}
+
+	return &Nonce{Value: nonce, m: m, key: key}, nil
+}
+
+// Sent records that the transaction using the nonce was broadcast
+func (n *Nonce) Sent(ctx context.Context, txHash string) error {
+	field := strconv.FormatUint(n.Value, 10)
+	_, err := n.m.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, n.key, nonceReservedField+field)
+		pipe.HSet(ctx, n.key, nonceSentField+field, txHash)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark nonce %d sent: %w", n.Value, err)
+	}
+	return nil
+}
+
+// Replaced records the hash of a transaction broadcast with an already sent
+// nonce of address, e.g. to speed up or cancel the first one
+func (m *NonceManager) Replaced(ctx context.Context, chainID *big.Int, address common.Address, nonce uint64, txHash string) error {
+	field := nonceSentField + strconv.FormatUint(nonce, 10)
+	if err := m.redis.HSet(ctx, nonceKey(chainID, address), field, txHash).Err(); err != nil {
+		return fmt.Errorf("failed to mark nonce %d replaced: %w", nonce, err)
+	}
+	return nil
+}
+
+// Release gives the nonce back after its transaction could not be signed or
+// broadcast, the next reservation of the address reuses it
+func (n *Nonce) Release(ctx context.Context) error {
+	field := nonceReservedField + strconv.FormatUint(n.Value, 10)
+	if err := n.m.redis.HDel(ctx, n.key, field).Err(); err != nil {
+		return fmt.Errorf("failed to release nonce %d: %w", n.Value, err)
+	}
+	return nil
+}
+
+// Dropped gives back the nonce of a broadcast transaction that left the
+// mempool without being mined. Nothing is released when the nonce was reused
+// by a replacement since.
+func (m *NonceManager) Dropped(ctx context.Context, chainID *big.Int, address common.Address, nonce uint64, txHash string) error {
+	field := nonceSentField + strconv.FormatUint(nonce, 10)
+	if err := releaseSentScript.Run(ctx, m.redis, []string{nonceKey(chainID, address)}, field, txHash).Err(); err != nil {
+		return fmt.Errorf("failed to release dropped nonce %d: %w", nonce, err)
+	}
+	return nil
+}
+
+func nonceKey(chainID *big.Int, address common.Address) string {
+	return fmt.Sprintf("nonce:%s:%s", chainID, strings.ToLower(address.Hex()))
+}
+
+// lock takes the nonce lock of key, waiting while another reservation holds it
+func (m *NonceManager) lock(ctx context.Context, key string) (func(), error) {
+	lockKey := key + ":lock"
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	value := hex.EncodeToString(token)
+
+	ctx, cancel := context.WithTimeout(ctx, nonceLockTTL)
+	defer cancel()
+
+	for {
+		ok, err := m.redis.SetNX(ctx, lockKey, value, nonceLockTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to take nonce lock: %w", err)
+		}
+		if ok {
+			return func() {
+				// The caller's context may be done, the lock must go anyway
+				if err := releaseLockScript.Run(context.Background(), m.redis, []string{lockKey}, value).Err(); err != nil {
+					logger.Error("failed to release nonce lock", err)
+				}
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrNonceLockTimeout
+		case <-time.After(nonceLockRetry):
+		}
+	}
+}
+
+// parseNonceField splits a reserved or sent field into its prefix and nonce
+func parseNonceField(field string) (string, uint64, bool) {
+	for _, prefix := range []string{nonceReservedField, nonceSentField} {
+		if v, ok := strings.CutPrefix(field, prefix); ok {
+			n, err := strconv.ParseUint(v, 10, 64)
+			return prefix, n, err == nil
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,101 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"mpc/internal/config"
+	rd "mpc/internal/db/redis"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeNonces answers the pending nonce of every address with pending
+type fakeNonces struct {
+	pending uint64
+}
+
+func (f *fakeNonces) PendingNonce(context.Context, *big.Int, common.Address) (uint64, error) {
+	return f.pending, nil
+}
+
+func newTestNonceManager(t *testing.T) (*NonceManager, *fakeNonces) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	client := &rd.Client{Client: redis.NewClient(&redis.Options{Addr: s.Addr()})}
+	t.Cleanup(func() { client.Close() })
+
+	source := &fakeNonces{pending: 5}
+	return NewNonceManager(&config.EthConfig{}, client, source), source
+}
+
+func reserve(t *testing.T, m *NonceManager, want uint64) *Nonce {
+	t.Helper()
+	nonce, err := m.Reserve(context.Background(), big.NewInt(1), common.Address{})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if nonce.Value != want {
+		t.Fatalf("Reserve = %d, want %d", nonce.Value, want)
+	}
+	return nonce
+}
+
+func TestReserveSequential(t *testing.T) {
+	m, _ := newTestNonceManager(t)
+
+	reserve(t, m, 5)
+	reserve(t, m, 6)
+	reserve(t, m, 7)
+}
+
+func TestReserveReusesReleasedNonce(t *testing.T) {
+	m, _ := newTestNonceManager(t)
+	ctx := context.Background()
+
+	first := reserve(t, m, 5)
+	second := reserve(t, m, 6)
+	if err := second.Sent(ctx, "0x06"); err != nil {
+		t.Fatalf("Sent: %v", err)
+	}
+	if err := first.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	reserve(t, m, 5)
+	reserve(t, m, 7)
+}
+
+func TestReserveReusesDroppedNonce(t *testing.T) {
+	m, source := newTestNonceManager(t)
+	ctx := context.Background()
+	chainID := big.NewInt(1)
+
+	for i, hash := range []string{"0x05", "0x06"} {
+		nonce := reserve(t, m, uint64(5+i))
+		if err := nonce.Sent(ctx, hash); err != nil {
+			t.Fatalf("Sent: %v", err)
+		}
+	}
+	reserve(t, m, 7)
+
+	// A dropped transaction that was replaced since keeps its nonce
+	if err := m.Replaced(ctx, chainID, common.Address{}, 5, "0x15"); err != nil {
+		t.Fatalf("Replaced: %v", err)
+	}
+	if err := m.Dropped(ctx, chainID, common.Address{}, 5, "0x05"); err != nil {
+		t.Fatalf("Dropped: %v", err)
+	}
+	reserve(t, m, 8)
+
+	if err := m.Dropped(ctx, chainID, common.Address{}, 5, "0x15"); err != nil {
+		t.Fatalf("Dropped: %v", err)
+	}
+	reserve(t, m, 5)
+
+	// Nonces the chain moved past are not handed out again
+	source.pending = 10
+	reserve(t, m, 10)
+}
@@ -0,0 +1,89 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"mpc/internal/config"
+	"mpc/internal/model"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrUnknownChain is returned for a chain that is missing or not active
+var ErrUnknownChain = errors.New("unknown chain")
+
+// ChainSource looks up the configuration of a chain
+type ChainSource interface {
+	GetChainByChainID(ctx context.Context, chainID int) (model.Chain, error)
+}
+
+// Registry holds one EthClient per chain. Clients are dialed on first use
+// from the RPC URLs stored for the chain and kept until Close.
+type Registry struct {
+	cfg     config.EthConfig
+	chains  ChainSource
+	mu      sync.Mutex
+	clients map[int]*EthClient
+}
+
+func NewRegistry(cfg *config.EthConfig, chains ChainSource) *Registry {
+	return &Registry{
+		cfg:     *cfg,
+		chains:  chains,
+		clients: make(map[int]*EthClient),
+	}
+}
+
+// Client returns the client of a chain, dialing it if needed
+func (r *Registry) Client(ctx context.Context, chainID int) (*EthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.clients[chainID]; ok {
+		return c, nil
+	}
+
+	chain, err := r.chains.GetChainByChainID(ctx, chainID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownChain, chainID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if chain.Status != "active" {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownChain, chainID)
+	}
+
+	c, err := NewEthClient(ctx, &r.cfg, chainID, chain.RPCURLs())
+	if err != nil {
+		return nil, err
+	}
+	r.clients[chainID] = c
+	return c, nil
+}
+
+// PendingNonce returns the next nonce of an address on a chain, it makes
+// the registry a NonceSource
+func (r *Registry) PendingNonce(ctx context.Context, chainID *big.Int, address common.Address) (uint64, error) {
+	c, err := r.Client(ctx, int(chainID.Int64()))
+	if err != nil {
+		return 0, err
+	}
+	return c.PendingNonce(ctx, address)
+}
+
+// Close closes the clients of all chains
+func (r *Registry) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for chainID, c := range r.clients {
+		c.Close()
+		delete(r.clients, chainID)
+	}
+}
@@ -0,0 +1,115 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mpc/internal/config"
+	"mpc/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type fakeChains map[int]model.Chain
+
+func (f fakeChains) GetChainByChainID(_ context.Context, chainID int) (model.Chain, error) {
+	chain, ok := f[chainID]
+	if !ok {
+		return model.Chain{}, fmt.Errorf("failed to get chain by ChainID: %w", pgx.ErrNoRows)
+	}
+	return chain, nil
+}
+
+// fakeNode answers eth_blockNumber with head, or fails while down is set
+func fakeNode(t *testing.T, head *atomic.Uint64, down *atomic.Bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if down.Load() || !strings.Contains(string(body), "eth_blockNumber") {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"0x%x"}`, head.Load())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRegistryUnknownChain(t *testing.T) {
+	r := NewRegistry(&config.EthConfig{}, fakeChains{
+		5: {ChainID: 5, RPCURL: "http://127.0.0.1:1", Status: "inactive"},
+	})
+	defer r.Close()
+
+	for _, chainID := range []int{1, 5} {
+		if _, err := r.Client(context.Background(), chainID); !errors.Is(err, ErrUnknownChain) {
+			t.Errorf("Client(%d) error = %v, want ErrUnknownChain", chainID, err)
+		}
+	}
+}
+
+func TestRegistryFailover(t *testing.T) {
+	var primaryHead, fallbackHead atomic.Uint64
+	var primaryDown, fallbackDown atomic.Bool
+	primaryHead.Store(100)
+	fallbackHead.Store(100)
+	primary := fakeNode(t, &primaryHead, &primaryDown)
+	fallback := fakeNode(t, &fallbackHead, &fallbackDown)
+
+	r := NewRegistry(&config.EthConfig{RPCTimeout: time.Second, HealthInterval: time.Hour, MaxBlockLag: 5}, fakeChains{
+		1: {ChainID: 1, RPCURL: primary.URL, FallbackRPCURLs: []string{fallback.URL}, Status: "active"},
+	})
+	defer r.Close()
+
+	ctx := context.Background()
+	c, err := r.Client(ctx, 1)
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	if again, _ := r.Client(ctx, 1); again != c {
+		t.Error("Client dialed the chain twice")
+	}
+
+	head := func() uint64 {
+		t.Helper()
+		n, err := c.BlockNumber(ctx)
+		if err != nil {
+			t.Fatalf("BlockNumber: %v", err)
+		}
+		return n
+	}
+
+	// Calls go to the primary while it is healthy
+	fallbackHead.Store(101)
+	c.endpoints.check(ctx)
+	if got := head(); got != 100 {
+		t.Errorf("head = %d, want 100 from the primary", got)
+	}
+
+	// A lagging primary is skipped
+	fallbackHead.Store(110)
+	c.endpoints.check(ctx)
+	if got := head(); got != 110 {
+		t.Errorf("head = %d, want 110 from the fallback", got)
+	}
+
+	// A failing primary is skipped until it answers again
+	primaryHead.Store(110)
+	primaryDown.Store(true)
+	c.endpoints.check(ctx)
+	if got := head(); got != 110 {
+		t.Errorf("head = %d, want 110 from the fallback", got)
+	}
+	primaryHead.Store(111)
+	primaryDown.Store(false)
+	c.endpoints.check(ctx)
+	if got := head(); got != 111 {
+		t.Errorf("head = %d, want 111 from the primary", got)
+	}
+}
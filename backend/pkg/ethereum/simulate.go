@@ -0,0 +1,165 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	geth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// knownErrors are the custom errors decoded from revert data, the ERC-20
+// errors of ERC-6093
+var knownErrors = mustParseABI(`[
+	{"type":"error","name":"ERC20InsufficientBalance","inputs":[{"name":"sender","type":"address"},{"name":"balance","type":"uint256"},{"name":"needed","type":"uint256"}]},
+	{"type":"error","name":"ERC20InsufficientAllowance","inputs":[{"name":"spender","type":"address"},{"name":"allowance","type":"uint256"},{"name":"needed","type":"uint256"}]},
+	{"type":"error","name":"ERC20InvalidSender","inputs":[{"name":"sender","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidReceiver","inputs":[{"name":"receiver","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidApprover","inputs":[{"name":"approver","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidSpender","inputs":[{"name":"spender","type":"address"}]}
+]`)
+
+func mustParseABI(def string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(def))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// RevertError is a transaction that reverted. Reason is the decoded
+// Error(string), Panic(uint256) or known custom error, Selector the 4-byte
+// selector of a custom error and Data the raw revert data.
+type RevertError struct {
+	Reason   string
+	Selector string
+	Data     []byte
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
+
+// decodeRevert decodes the revert data returned by a node
+func decodeRevert(data []byte) *RevertError {
+	rev := &RevertError{Data: data}
+	if len(data) < 4 {
+		return rev
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		rev.Reason = reason
+		return rev
+	}
+
+	rev.Selector = hexutil.Encode(data[:4])
+	customErr, err := knownErrors.ErrorByID([4]byte(data[:4]))
+	if err != nil {
+		rev.Reason = "custom error " + rev.Selector
+		return rev
+	}
+	args, err := customErr.Inputs.Unpack(data[4:])
+	if err != nil {
+		rev.Reason = customErr.Name
+		return rev
+	}
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = fmt.Sprint(arg)
+	}
+	rev.Reason = fmt.Sprintf("%s(%s)", customErr.Name, strings.Join(values, ", "))
+	return rev
+}
+
+// asRevert returns the revert of a failed call, nil if err is not a revert
+func asRevert(err error) *RevertError {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if s, ok := dataErr.ErrorData().(string); ok {
+			if data, err := hexutil.Decode(s); err == nil {
+				return decodeRevert(data)
+			}
+		}
+	}
+
+	// Some nodes only put the reason in the message
+	msg := err.Error()
+	if i := strings.Index(msg, "execution reverted"); i >= 0 {
+		reason := strings.TrimPrefix(msg[i+len("execution reverted"):], ":")
+		return &RevertError{Reason: strings.TrimSpace(reason)}
+	}
+	return nil
+}
+
+// callError returns the revert of a failed call, or err with context
+func callError(action string, err error) error {
+	if rev := asRevert(err); rev != nil {
+		return rev
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
+
+// Simulation is the outcome of running a transaction on the pending block
+type Simulation struct {
+	GasUsed    uint64
+	ReturnData []byte
+}
+
+// Simulate runs tx as sent by from with eth_call and eth_estimateGas against
+// the pending block. A transaction that would revert returns a *RevertError.
+func (c *EthClient) Simulate(ctx context.Context, from common.Address, tx *types.Transaction) (*Simulation, error) {
+	msg := geth.CallMsg{
+		From:      from,
+		To:        tx.To(),
+		Gas:       tx.Gas(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}
+
+	ret, err := c.rpc().PendingCallContract(ctx, msg)
+	if err != nil {
+		return nil, callError("simulate transaction", err)
+	}
+
+	// ethclient estimates against the latest block only
+	var gas hexutil.Uint64
+	if err := c.rpc().Client().CallContext(ctx, &gas, "eth_estimateGas", toCallArg(msg), "pending"); err != nil {
+		return nil, callError("estimate gas", err)
+	}
+
+	return &Simulation{GasUsed: uint64(gas), ReturnData: ret}, nil
+}
+
+// toCallArg encodes msg as the call object of the JSON-RPC API
+func toCallArg(msg geth.CallMsg) map[string]interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["input"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	return arg
+}
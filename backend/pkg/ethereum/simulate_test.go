@@ -0,0 +1,105 @@
+package ethereum
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// dataError is an RPC error carrying revert data, as returned by ethclient
+type dataError struct {
+	msg  string
+	data string
+}
+
+func (e dataError) Error() string          { return e.msg }
+func (e dataError) ErrorData() interface{} { return e.data }
+
+func encodeError(t *testing.T, sig string, args ...interface{}) []byte {
+	t.Helper()
+	parsed := mustParseABI(`[{"type":"function","name":"Error","inputs":[{"type":"string"}]}]`)
+	if sig == "Error" {
+		data, err := parsed.Pack("Error", args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	customErr := knownErrors.Errors[sig]
+	data, err := customErr.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(customErr.ID.Bytes()[:4], data...)
+}
+
+func TestAsRevert(t *testing.T) {
+	sender := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	panicData := append(common.FromHex("0x4e487b71"), common.LeftPadBytes([]byte{0x11}, 32)...)
+
+	tests := []struct {
+		name         string
+		err          error
+		wantReason   string
+		wantSelector string
+	}{
+		{
+			name:       "error string",
+			err:        dataError{"execution reverted", hexutil.Encode(encodeError(t, "Error", "not allowed"))},
+			wantReason: "not allowed",
+		},
+		{
+			name:       "panic",
+			err:        dataError{"execution reverted", hexutil.Encode(panicData)},
+			wantReason: "arithmetic underflow or overflow",
+		},
+		{
+			name:         "known custom error",
+			err:          dataError{"execution reverted", hexutil.Encode(encodeError(t, "ERC20InsufficientBalance", sender, big.NewInt(1), big.NewInt(5)))},
+			wantReason:   "ERC20InsufficientBalance(" + sender.Hex() + ", 1, 5)",
+			wantSelector: "0xe450d38c",
+		},
+		{
+			name:         "unknown custom error",
+			err:          dataError{"execution reverted", "0x12345678"},
+			wantReason:   "custom error 0x12345678",
+			wantSelector: "0x12345678",
+		},
+		{
+			name:       "reason in message",
+			err:        errors.New("execution reverted: paused"),
+			wantReason: "paused",
+		},
+	}
+
+	for _, tt := range tests {
+		rev := asRevert(tt.err)
+		if rev == nil {
+			t.Errorf("%s: asRevert = nil, want a revert", tt.name)
+			continue
+		}
+		if rev.Reason != tt.wantReason || rev.Selector != tt.wantSelector {
+			t.Errorf("%s: asRevert = (%q, %q), want (%q, %q)", tt.name, rev.Reason, rev.Selector, tt.wantReason, tt.wantSelector)
+		}
+	}
+
+	if rev := asRevert(errors.New("insufficient funds for gas * price + value")); rev != nil {
+		t.Errorf("asRevert(insufficient funds) = %v, want nil", rev)
+	}
+}
+
+func TestCallErrorKeepsRevert(t *testing.T) {
+	err := callError("estimate gas", dataError{"execution reverted", hexutil.Encode(encodeError(t, "Error", "nope"))})
+	var rev *RevertError
+	if !errors.As(err, &rev) || rev.Error() != "execution reverted: nope" {
+		t.Errorf("callError = %v, want a revert with reason nope", err)
+	}
+
+	err = callError("estimate gas", errors.New("timeout"))
+	if errors.As(err, &rev) {
+		t.Errorf("callError(timeout) = %v, want no revert", err)
+	}
+}
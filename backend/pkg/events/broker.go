@@ -0,0 +1,233 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mpc/internal/config"
+	"mpc/internal/db/redis"
+	"mpc/internal/model"
+	"mpc/pkg/logger"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// channel announces every published event to the API servers
+const channel = "events"
+
+// subscriberBuffer is the number of events a subscriber may lag behind
+// before it is dropped
+const subscriberBuffer = 64
+
+// Broker stores the events of each user in a capped Redis stream, where
+// clients resume from, and announces them on a pub/sub channel. Events
+// published by any process reach the subscribers of every API server.
+type Broker struct {
+	redisClient *redis.Client
+	cfg         config.EventsConfig
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan model.Event]struct{}
+}
+
+func NewBroker(redisClient *redis.Client, cfg *config.EventsConfig) *Broker {
+	b := &Broker{
+		redisClient: redisClient,
+		cfg:         *cfg,
+		subscribers: make(map[uuid.UUID]map[chan model.Event]struct{}),
+	}
+	if b.cfg.MaxLen <= 0 {
+		b.cfg.MaxLen = 1000
+	}
+	if b.cfg.Retention <= 0 {
+		b.cfg.Retention = 7 * 24 * time.Hour
+	}
+	return b
+}
+
+// streamKey is the Redis stream holding the events of a user
+func streamKey(userID uuid.UUID) string {
+	return "events:" + userID.String()
+}
+
+// Publish stores an event for the user and announces it. data is encoded as JSON.
+func (b *Broker) Publish(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) (model.Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return model.Event{}, fmt.Errorf("failed to encode event: %w", err)
+	}
+	event := model.Event{
+		Type:      eventType,
+		UserID:    userID,
+		Data:      payload,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	key := streamKey(userID)
+	event.ID, err = b.redisClient.XAdd(ctx, &goredis.XAddArgs{
+		Stream: key,
+		MaxLen: b.cfg.MaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":       event.Type,
+			"data":       string(event.Data),
+			"created_at": event.CreatedAt.Format(time.RFC3339Nano),
+		},
+	}).Result()
+	if err != nil {
+		return model.Event{}, fmt.Errorf("failed to store event: %w", err)
+	}
+	b.redisClient.Expire(ctx, key, b.cfg.Retention)
+
+	announcement, err := json.Marshal(event)
+	if err != nil {
+		return model.Event{}, fmt.Errorf("failed to encode event: %w", err)
+	}
+	// Subscribers that miss the announcement catch up from the stream
+	if err := b.redisClient.Publish(ctx, channel, announcement).Err(); err != nil {
+		logger.Error("failed to announce event", err, zap.String("event_id", event.ID))
+	}
+	return event, nil
+}
+
+// Since returns the stored events of a user after the event lastID, oldest first
+func (b *Broker) Since(ctx context.Context, userID uuid.UUID, lastID string) ([]model.Event, error) {
+	if !ValidID(lastID) {
+		return nil, fmt.Errorf("invalid event ID %q", lastID)
+	}
+	messages, err := b.redisClient.XRangeN(ctx, streamKey(userID), "("+lastID, "+", b.cfg.MaxLen).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	events := make([]model.Event, 0, len(messages))
+	for _, msg := range messages {
+		events = append(events, toEvent(userID, msg))
+	}
+	return events, nil
+}
+
+// Subscribe returns the events published for a user from now on. The channel
+// is closed when the subscriber lags too far behind, it then resumes with
+// Since. unsubscribe releases the subscription.
+func (b *Broker) Subscribe(userID uuid.UUID) (events <-chan model.Event, unsubscribe func()) {
+	ch := make(chan model.Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan model.Event]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(userID, ch)
+	}
+}
+
+// remove drops a subscriber, its channel is closed. b.mu must be held.
+func (b *Broker) remove(userID uuid.UUID, ch chan model.Event) {
+	subs := b.subscribers[userID]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	if len(subs) == 0 {
+		delete(b.subscribers, userID)
+	}
+	close(ch)
+}
+
+// Run delivers the announced events to the subscribers until ctx is done
+func (b *Broker) Run(ctx context.Context) {
+	pubsub := b.redisClient.Subscribe(ctx, channel)
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+
+	// The channel survives reconnects, announcements sent meanwhile are lost
+	for msg := range pubsub.Channel() {
+		var event model.Event
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			logger.Warn("invalid event announcement", zap.Error(err))
+			continue
+		}
+		b.deliver(event)
+	}
+}
+
+// deliver hands an event to the subscribers of its user, dropping the ones
+// that lag behind
+func (b *Broker) deliver(event model.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			logger.Warn("dropping lagging event subscriber", zap.String("user_id", event.UserID.String()))
+			b.remove(event.UserID, ch)
+		}
+	}
+}
+
+// toEvent reads an event stored in the stream of a user
+func toEvent(userID uuid.UUID, msg goredis.XMessage) model.Event {
+	event := model.Event{ID: msg.ID, UserID: userID}
+	event.Type, _ = msg.Values["type"].(string)
+	if data, ok := msg.Values["data"].(string); ok {
+		event.Data = json.RawMessage(data)
+	}
+	if createdAt, ok := msg.Values["created_at"].(string); ok {
+		event.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	}
+	return event
+}
+
+// After reports whether the event id comes after the event lastID. Any
+// event comes after an empty lastID.
+func After(id, lastID string) bool {
+	if lastID == "" {
+		return true
+	}
+	ms, seq, ok := parseID(id)
+	lastMs, lastSeq, lastOK := parseID(lastID)
+	if !ok || !lastOK {
+		return false
+	}
+	return ms > lastMs || (ms == lastMs && seq > lastSeq)
+}
+
+// ValidID reports whether id is an event ID
+func ValidID(id string) bool {
+	_, _, ok := parseID(id)
+	return ok
+}
+
+// parseID splits a Redis stream ID <milliseconds>-<sequence>
+func parseID(id string) (ms uint64, seq uint64, ok bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err = strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
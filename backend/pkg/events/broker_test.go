@@ -0,0 +1,84 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"mpc/internal/config"
+	"mpc/internal/model"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		id, lastID string
+		want       bool
+	}{
+		{id: "1700000000000-0", lastID: "", want: true},
+		{id: "1700000000000-1", lastID: "1700000000000-0", want: true},
+		{id: "1700000000001-0", lastID: "1700000000000-9", want: true},
+		{id: "1700000000000-0", lastID: "1700000000000-0", want: false},
+		{id: "999-0", lastID: "1000-0", want: false},
+		{id: "1000-0", lastID: "not an id", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := After(tt.id, tt.lastID); got != tt.want {
+			t.Errorf("After(%q, %q) = %v, want %v", tt.id, tt.lastID, got, tt.want)
+		}
+	}
+}
+
+func TestToEvent(t *testing.T) {
+	userID := uuid.New()
+	event := toEvent(userID, goredis.XMessage{
+		ID: "1700000000000-0",
+		Values: map[string]interface{}{
+			"type":       model.EventDepositReceived,
+			"data":       `{"tx_hash":"0x01"}`,
+			"created_at": "2024-12-10T08:00:00Z",
+		},
+	})
+
+	if event.ID != "1700000000000-0" || event.Type != model.EventDepositReceived || event.UserID != userID {
+		t.Errorf("toEvent = %+v", event)
+	}
+	var data map[string]string
+	if err := json.Unmarshal(event.Data, &data); err != nil || data["tx_hash"] != "0x01" {
+		t.Errorf("toEvent data = %s", event.Data)
+	}
+	if event.CreatedAt.IsZero() {
+		t.Error("toEvent did not read created_at")
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	b := NewBroker(nil, &config.EventsConfig{})
+	userID := uuid.New()
+	events, unsubscribe := b.Subscribe(userID)
+	defer unsubscribe()
+	other, unsubscribeOther := b.Subscribe(uuid.New())
+	defer unsubscribeOther()
+
+	b.deliver(model.Event{ID: "1-0", UserID: userID})
+	if event := <-events; event.ID != "1-0" {
+		t.Errorf("received event %s, want 1-0", event.ID)
+	}
+	if len(other) != 0 {
+		t.Error("event delivered to another user")
+	}
+
+	// A subscriber lagging behind is dropped and resumes from the stream
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.deliver(model.Event{ID: "2-0", UserID: userID})
+	}
+	n := 0
+	for range events {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("received %d events before the channel closed, want %d", n, subscriberBuffer)
+	}
+}
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mpc/internal/config"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrRejected is returned when the policy service refuses to approve a signature
+var ErrRejected = errors.New("signature rejected by the policy service")
+
+// Request asks the policy service to approve signing MsgHash with KeyID in
+// SessionID. The service recomputes the hash from the signed data, so one of
+// UnsignedTx (with ChainID), Message or TypedData must be set.
+type Request struct {
+	SessionID  string          `json:"session_id"`
+	KeyID      string          `json:"key_id"`
+	MsgHash    hexutil.Bytes   `json:"msg_hash"`
+	UnsignedTx hexutil.Bytes   `json:"unsigned_tx,omitempty"`
+	ChainID    uint64          `json:"chain_id,omitempty"`
+	Message    hexutil.Bytes   `json:"message,omitempty"`    // personal_sign message
+	TypedData  json.RawMessage `json:"typed_data,omitempty"` // EIP-712 typed data
+}
+
+// Client asks the policy service for the approvals TSS nodes require.
+// The service holds the signing key and evaluates every request, the
+// backend only forwards the token it returns.
+type Client struct {
+	url        string
+	authToken  string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the configured policy service
+func NewClient(cfg *config.PolicyConfig) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("policy service URL is not configured")
+	}
+	return &Client{
+		url:        strings.TrimSuffix(cfg.URL, "/"),
+		authToken:  cfg.AuthToken,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Approve returns the authorization token of the request, ErrRejected if the
+// policy service refused it
+func (c *Client) Approve(ctx context.Context, req Request) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal approval request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/approve", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.authToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach policy service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		AuthToken string `json:"auth_token"`
+		Error     string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&res); err != nil {
+		return "", fmt.Errorf("invalid policy service response (%s): %w", resp.Status, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("%w: %s", ErrRejected, res.Error)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("policy service responded with %s: %s", resp.Status, res.Error)
+	case res.AuthToken == "":
+		return "", fmt.Errorf("policy service returned no token")
+	}
+	return res.AuthToken, nil
+}
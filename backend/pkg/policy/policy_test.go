@@ -0,0 +1,53 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mpc/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApprove(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/approve" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"unauthorized"}`))
+			return
+		}
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.UnsignedTx) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"no transaction, message or typed data behind the hash"}`))
+			return
+		}
+		w.Write([]byte(`{"auth_token":"token-of-` + req.SessionID + `"}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(&config.PolicyConfig{URL: srv.URL, AuthToken: "secret", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+
+	token, err := c.Approve(ctx, Request{SessionID: "session", KeyID: "key", MsgHash: make([]byte, 32), UnsignedTx: []byte{0x02}, ChainID: 1})
+	if err != nil || token != "token-of-session" {
+		t.Fatalf("Approve() = %q, %v", token, err)
+	}
+
+	_, err = c.Approve(ctx, Request{SessionID: "session", KeyID: "key", MsgHash: make([]byte, 32)})
+	if !errors.Is(err, ErrRejected) {
+		t.Errorf("Approve() of a bare hash = %v, want ErrRejected", err)
+	}
+
+	c.authToken = "wrong"
+	_, err = c.Approve(ctx, Request{SessionID: "session", KeyID: "key", MsgHash: make([]byte, 32), UnsignedTx: []byte{0x02}, ChainID: 1})
+	if err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("Approve() with a wrong token = %v, want a non rejection error", err)
+	}
+}
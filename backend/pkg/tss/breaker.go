@@ -0,0 +1,55 @@
+package tss
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker is a circuit breaker guarding one coordinator. It opens after
+// maxFailures consecutive transient failures, and once the cooldown has
+// passed it lets a single probe through before closing again.
+type breaker struct {
+	mu          sync.Mutex
+	maxFailures int
+	cooldown    time.Duration
+	failures    int
+	openUntil   time.Time
+	probing     bool
+}
+
+func newBreaker(maxFailures int, cooldown time.Duration) *breaker {
+	return &breaker{maxFailures: maxFailures, cooldown: cooldown}
+}
+
+// allow reports whether a call may be sent to the coordinator
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxFailures <= 0 || b.failures < b.maxFailures {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of a call.
+// Only transient errors count, a refused request means the node is up.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil || !isTransient(err) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.maxFailures > 0 && b.failures >= b.maxFailures {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
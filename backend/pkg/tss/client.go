@@ -0,0 +1,43 @@
+package tss
+
+import (
+	"context"
+	"fmt"
+	"mpc/internal/config"
+	rd "mpc/internal/db/redis"
+)
+
+const (
+	ModeGRPC = "grpc" // TSS nodes reached over gRPC, results over Redis
+	ModeFake = "fake" // in-memory keys, for tests and local development
+)
+
+// Client generates keys and signs with them
+type Client interface {
+	// CreateWallet generates a new key for the keygen session sessionID
+	CreateWallet(ctx context.Context, sessionID string) (Key, error)
+	// Sign returns the DER encoded signature of req.MsgHash
+	Sign(ctx context.Context, req SignRequest) ([]byte, error)
+	// ResumeWallet waits for the key of a keygen session started earlier
+	ResumeWallet(ctx context.Context, sessionID string) (Key, error)
+	// ResumeSign waits for the signature of a sign session started earlier
+	ResumeSign(ctx context.Context, sessionID string) ([]byte, error)
+	Close() error
+}
+
+var (
+	_ Client = (*TSS)(nil)
+	_ Client = (*Fake)(nil)
+)
+
+// New creates the client selected by cfg.Mode
+func New(cfg *config.TSSConfig, redisClient *rd.Client) (Client, error) {
+	switch cfg.Mode {
+	case ModeGRPC, "":
+		return NewTSS(cfg, redisClient)
+	case ModeFake:
+		return NewFake(cfg)
+	default:
+		return nil, fmt.Errorf("unknown TSS mode: %s", cfg.Mode)
+	}
+}
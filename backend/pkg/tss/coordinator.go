@@ -0,0 +1,220 @@
+package tss
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/rand"
+	"mpc/internal/config"
+	pb "mpc/proto"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNoCoordinator is returned when every coordinator has its circuit open
+var ErrNoCoordinator = errors.New("no TSS coordinator available")
+
+// errNotConnected is a call that was not sent, the coordinator could not be reached
+var errNotConnected = status.Error(codes.Unavailable, "coordinator not connected")
+
+// drainingMessage is the status message of the Unavailable answer of a node
+// that is shutting down and refuses new sessions
+const drainingMessage = "node is draining"
+
+// coordinator is a TSS node sessions can be started on
+type coordinator struct {
+	address string
+	conn    *grpc.ClientConn
+	client  pb.MPCServiceClient
+	breaker *breaker
+}
+
+// dialCoordinators creates a client for every configured endpoint.
+// Connections are established lazily on the first call.
+func dialCoordinators(cfg *config.TSSConfig) ([]*coordinator, error) {
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	coordinators := make([]*coordinator, 0, len(cfg.Endpoints))
+	for _, address := range cfg.Endpoints {
+		conn, err := grpc.NewClient(address,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		)
+		if err != nil {
+			closeCoordinators(coordinators)
+			return nil, fmt.Errorf("failed to create gRPC client for %s: %w", address, err)
+		}
+		coordinators = append(coordinators, &coordinator{
+			address: address,
+			conn:    conn,
+			client:  pb.NewMPCServiceClient(conn),
+			breaker: newBreaker(cfg.BreakerFailures, cfg.BreakerCooldown),
+		})
+	}
+	return coordinators, nil
+}
+
+func closeCoordinators(coordinators []*coordinator) error {
+	var errs []error
+	for _, c := range coordinators {
+		if err := c.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// transportCredentials builds the mTLS credentials, plaintext if no CA is configured
+func transportCredentials(cfg *config.TSSConfig) (credentials.TransportCredentials, error) {
+	if cfg.TLSCA == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	ca, err := os.ReadFile(cfg.TLSCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TSS CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s", cfg.TLSCA)
+	}
+
+	// The nodes require a client certificate of their CA
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TSS client certificate: %w", err)
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   cfg.TLSServerName,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// notify sends req to the first coordinator whose circuit is closed. The
+// coordinators are replicas of the same node, node 1, which starts the
+// sessions and keeps no share of its own. A request is only retried, on the
+// same or the next coordinator, when it was not processed: the coordinator
+// could not be connected to before sending it, or refused it while draining.
+// Any other failure may come after the session started and is returned.
+func (t *TSS) notify(ctx context.Context, req *pb.ActionRequest) error {
+	span := trace.SpanFromContext(ctx)
+	backoff := t.cfg.BackoffMin
+
+	var lastErr error
+	next := 0 // every call starts with the first coordinator
+	for attempt := 0; attempt <= t.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			case <-time.After(jitter(backoff)):
+			}
+			backoff *= 2
+			if backoff > t.cfg.BackoffMax {
+				backoff = t.cfg.BackoffMax
+			}
+		}
+
+		c, i := t.pick(next)
+		if c == nil {
+			lastErr = ErrNoCoordinator
+			continue
+		}
+
+		var err error
+		if !c.connect(ctx, t.cfg.ConnectTimeout) {
+			err = errNotConnected
+		} else {
+			_, err = c.client.NotifyAction(ctx, req)
+		}
+		c.breaker.record(err)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = fmt.Errorf("%s: %w", c.address, err)
+		if !notProcessed(err) {
+			return lastErr
+		}
+		next = i + 1
+		span.AddEvent("coordinator unavailable", trace.WithAttributes(
+			attribute.String("tss.coordinator", c.address),
+			attribute.Int("tss.attempt", attempt),
+		))
+	}
+	return lastErr
+}
+
+// pick returns the first coordinator from index from whose circuit is
+// closed, with its index
+func (t *TSS) pick(from int) (*coordinator, int) {
+	for k := range t.coordinators {
+		i := (from + k) % len(t.coordinators)
+		if t.coordinators[i].breaker.allow() {
+			return t.coordinators[i], i
+		}
+	}
+	return nil, 0
+}
+
+// connect waits until the connection to the coordinator is ready, false if
+// it is not within timeout
+func (c *coordinator) connect(ctx context.Context, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c.conn.Connect()
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return true
+		case connectivity.Shutdown:
+			return false
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
+
+// notProcessed reports whether a failed call was not processed by the
+// coordinator, so it can be sent again
+func notProcessed(err error) bool {
+	if errors.Is(err, errNotConnected) {
+		return true
+	}
+	st, _ := status.FromError(err)
+	return st.Code() == codes.Unavailable && st.Message() == drainingMessage
+}
+
+// isTransient reports whether a call failed because the coordinator is down
+func isTransient(err error) bool {
+	return errors.Is(err, errNotConnected) || status.Code(err) == codes.Unavailable
+}
+
+// jitter spreads retries over [d/2, d)
+func jitter(d time.Duration) time.Duration {
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half))
+}
@@ -0,0 +1,136 @@
+package tss
+
+import (
+	"context"
+	"mpc/internal/config"
+	pb "mpc/proto"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testNode answers NotifyAction with err and counts the calls
+type testNode struct {
+	pb.UnimplementedMPCServiceServer
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (n *testNode) NotifyAction(ctx context.Context, req *pb.ActionRequest) (*pb.ActionResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls++
+	if n.err != nil {
+		return nil, n.err
+	}
+	return &pb.ActionResponse{Success: true}, nil
+}
+
+func (n *testNode) callCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls
+}
+
+func startTestNode(t *testing.T, err error) (*testNode, string) {
+	t.Helper()
+	lis, listenErr := net.Listen("tcp", "127.0.0.1:0")
+	if listenErr != nil {
+		t.Fatalf("Listen: %v", listenErr)
+	}
+	node := &testNode{err: err}
+	srv := grpc.NewServer()
+	pb.RegisterMPCServiceServer(srv, node)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return node, lis.Addr().String()
+}
+
+func newTestTSS(t *testing.T, endpoints ...string) *TSS {
+	t.Helper()
+	tss, err := NewTSS(&config.TSSConfig{
+		Endpoints:       endpoints,
+		Parties:         []uint32{1, 2, 3},
+		Threshold:       1,
+		ConnectTimeout:  time.Second,
+		MaxRetries:      3,
+		BackoffMin:      time.Millisecond,
+		BackoffMax:      time.Millisecond,
+		BreakerFailures: 5,
+		BreakerCooldown: time.Minute,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewTSS: %v", err)
+	}
+	t.Cleanup(func() { tss.Close() })
+	return tss
+}
+
+func TestNotifyPrefersFirstCoordinator(t *testing.T) {
+	first, firstAddr := startTestNode(t, nil)
+	second, secondAddr := startTestNode(t, nil)
+	tss := newTestTSS(t, firstAddr, secondAddr)
+
+	for i := 0; i < 3; i++ {
+		if err := tss.notify(context.Background(), &pb.ActionRequest{Action: pb.Action_INIT_SIGN}); err != nil {
+			t.Fatalf("notify: %v", err)
+		}
+	}
+	if first.callCount() != 3 || second.callCount() != 0 {
+		t.Errorf("calls = %d, %d, want 3, 0", first.callCount(), second.callCount())
+	}
+}
+
+func TestNotifyFailsOverUnprocessedRequests(t *testing.T) {
+	draining, drainingAddr := startTestNode(t, status.Error(codes.Unavailable, drainingMessage))
+	healthy, healthyAddr := startTestNode(t, nil)
+	tss := newTestTSS(t, drainingAddr, healthyAddr)
+
+	if err := tss.notify(context.Background(), &pb.ActionRequest{Action: pb.Action_INIT_KEYGEN}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if draining.callCount() != 1 || healthy.callCount() != 1 {
+		t.Errorf("calls = %d, %d, want 1, 1", draining.callCount(), healthy.callCount())
+	}
+
+	// Nothing listens on the address of a stopped node
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	downAddr := lis.Addr().String()
+	lis.Close()
+
+	tss = newTestTSS(t, downAddr, healthyAddr)
+	if err := tss.notify(context.Background(), &pb.ActionRequest{Action: pb.Action_INIT_KEYGEN}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if healthy.callCount() != 2 {
+		t.Errorf("calls of the healthy node = %d, want 2", healthy.callCount())
+	}
+}
+
+func TestNotifyDoesNotRetryProcessedRequests(t *testing.T) {
+	for _, err := range []error{
+		status.Error(codes.Aborted, "aborted"),
+		status.Error(codes.Unavailable, "connection reset"),
+		status.Error(codes.PermissionDenied, "refused"),
+	} {
+		first, firstAddr := startTestNode(t, err)
+		second, secondAddr := startTestNode(t, nil)
+		tss := newTestTSS(t, firstAddr, secondAddr)
+
+		if notifyErr := tss.notify(context.Background(), &pb.ActionRequest{Action: pb.Action_INIT_SIGN}); notifyErr == nil {
+			t.Errorf("notify succeeded after %v", err)
+		}
+		if first.callCount() != 1 || second.callCount() != 0 {
+			t.Errorf("after %v calls = %d, %d, want 1, 0", err, first.callCount(), second.callCount())
+		}
+	}
+}
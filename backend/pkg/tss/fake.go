@@ -0,0 +1,156 @@
+package tss
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"mpc/internal/config"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeSharePrefix marks the share data handed out by Fake
+const fakeSharePrefix = "fake-share:"
+
+// Fake is an in-memory Client for tests and local development. Keys are
+// derived from the seed and the key ID, so the same key ID always gets the
+// same address, and signatures are plain ECDSA in the DER encoding of the
+// TSS nodes. It must never be used with real funds.
+type Fake struct {
+	seed      []byte
+	parties   []uint32
+	threshold uint32
+
+	mu         sync.Mutex
+	keys       map[string]Key    // by keygen session
+	signatures map[string][]byte // by sign session
+}
+
+// NewFake creates a fake client generating keys with the configured parties
+func NewFake(cfg *config.TSSConfig) (*Fake, error) {
+	// Keys of an empty seed can be derived by anyone from the key ID
+	if cfg.FakeSeed == "" {
+		return nil, errors.New("TSS_FAKE_SEED is required by the fake TSS")
+	}
+	if len(cfg.Parties) == 0 || int(cfg.Threshold) >= len(cfg.Parties) {
+		return nil, fmt.Errorf("invalid TSS parties %v with threshold %d", cfg.Parties, cfg.Threshold)
+	}
+	return &Fake{
+		seed:       []byte(cfg.FakeSeed),
+		parties:    cfg.Parties,
+		threshold:  cfg.Threshold,
+		keys:       make(map[string]Key),
+		signatures: make(map[string][]byte),
+	}, nil
+}
+
+func (f *Fake) Close() error {
+	return nil
+}
+
+// CreateWallet derives the key of sessionID
+func (f *Fake) CreateWallet(ctx context.Context, sessionID string) (Key, error) {
+	if err := ctx.Err(); err != nil {
+		return Key{}, err
+	}
+
+	key := f.privateKey(sessionID)
+	result := Key{
+		ID:        sessionID,
+		Parties:   f.parties,
+		Threshold: f.threshold,
+		ShareData: base64.StdEncoding.EncodeToString([]byte(fakeSharePrefix + sessionID)),
+		Address:   crypto.PubkeyToAddress(key.PublicKey).Hex(),
+	}
+
+	f.mu.Lock()
+	f.keys[sessionID] = result
+	f.mu.Unlock()
+	return result, nil
+}
+
+// ResumeWallet returns the key of a session created by CreateWallet
+func (f *Fake) ResumeWallet(ctx context.Context, sessionID string) (Key, error) {
+	f.mu.Lock()
+	key, ok := f.keys[sessionID]
+	f.mu.Unlock()
+	if !ok {
+		return Key{}, fmt.Errorf("key generation failed: %w: unknown session %s", ErrTimeout, sessionID)
+	}
+	return key, nil
+}
+
+// ResumeSign returns the signature of a session signed by Sign
+func (f *Fake) ResumeSign(ctx context.Context, sessionID string) ([]byte, error) {
+	f.mu.Lock()
+	sig, ok := f.signatures[sessionID]
+	f.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("signature generation failed: %w: unknown session %s", ErrTimeout, sessionID)
+	}
+	return sig, nil
+}
+
+// Sign signs req.MsgHash with the key of req.KeyID. Like the TSS nodes it
+// refuses share data that does not belong to the key.
+func (f *Fake) Sign(ctx context.Context, req SignRequest) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keyID := req.KeyID
+	if keyID == "" {
+		keyID = req.SessionID
+	}
+	if len(req.Parties) > 0 && int(req.Threshold) >= len(req.Parties) {
+		return nil, fmt.Errorf("invalid TSS parties %v with threshold %d", req.Parties, req.Threshold)
+	}
+	if len(req.MsgHash) != 32 {
+		return nil, fmt.Errorf("invalid message hash length: %d", len(req.MsgHash))
+	}
+
+	share, err := base64.StdEncoding.DecodeString(req.ShareData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode share data: %w", err)
+	}
+	if !bytes.Equal(share, []byte(fakeSharePrefix+keyID)) {
+		return nil, fmt.Errorf("signature generation failed: share data does not belong to key %s", keyID)
+	}
+
+	sig, err := crypto.Sign(req.MsgHash, f.privateKey(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("signature generation failed: %w", err)
+	}
+
+	// Same encoding as the signature published by the TSS nodes
+	derSig, err := asn1.Marshal(struct{ R, S *big.Int }{
+		R: new(big.Int).SetBytes(sig[:32]),
+		S: new(big.Int).SetBytes(sig[32:64]),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signature generation failed: %w", err)
+	}
+
+	f.mu.Lock()
+	f.signatures[req.SessionID] = derSig
+	f.mu.Unlock()
+	return derSig, nil
+}
+
+// privateKey derives the key of keyID from the seed
+func (f *Fake) privateKey(keyID string) *ecdsa.PrivateKey {
+	digest := crypto.Keccak256(f.seed, []byte(keyID))
+	for {
+		// Rehash the rare digests that are not a valid scalar
+		if key, err := crypto.ToECDSA(digest); err == nil {
+			return key
+		}
+		digest = crypto.Keccak256(digest)
+	}
+}
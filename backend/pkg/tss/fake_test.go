@@ -0,0 +1,134 @@
+package tss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mpc/internal/config"
+	"mpc/pkg/utils"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestFake(t *testing.T, seed string) *Fake {
+	t.Helper()
+	f, err := NewFake(&config.TSSConfig{
+		FakeSeed:  seed,
+		Parties:   []uint32{1, 2, 3},
+		Threshold: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewFake: %v", err)
+	}
+	return f
+}
+
+func TestFakeRequiresSeed(t *testing.T) {
+	_, err := NewFake(&config.TSSConfig{Parties: []uint32{1, 2, 3}, Threshold: 1})
+	if err == nil {
+		t.Fatal("NewFake without a seed succeeded")
+	}
+}
+
+func TestFakeKeysAreDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	a, err := newTestFake(t, "seed").CreateWallet(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	b, err := newTestFake(t, "seed").CreateWallet(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	if a.Address != b.Address {
+		t.Fatalf("same seed and key ID gave %s and %s", a.Address, b.Address)
+	}
+
+	c, err := newTestFake(t, "seed").CreateWallet(ctx, "key-2")
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	if a.Address == c.Address {
+		t.Fatal("different key IDs gave the same address")
+	}
+}
+
+func TestFakeSignatureRecoversToWallet(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFake(t, "seed")
+
+	key, err := f.CreateWallet(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+
+	hash := crypto.Keccak256([]byte("transaction"))
+	derSig, err := f.Sign(ctx, SignRequest{
+		SessionID: "sign-1",
+		KeyID:     key.ID,
+		Parties:   key.Parties,
+		Threshold: key.Threshold,
+		ShareData: key.ShareData,
+		MsgHash:   hash,
+	})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	if _, err := utils.ConvertDERToEthSignature(derSig, hash, key.Address); err != nil {
+		t.Fatalf("signature does not recover to %s: %v", key.Address, err)
+	}
+}
+
+func TestFakeRejectsForeignShare(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFake(t, "seed")
+
+	other, err := f.CreateWallet(ctx, "key-2")
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+
+	_, err = f.Sign(ctx, SignRequest{
+		SessionID: "sign-1",
+		KeyID:     "key-1",
+		ShareData: other.ShareData,
+		MsgHash:   crypto.Keccak256([]byte("transaction")),
+	})
+	if err == nil {
+		t.Fatal("expected share of another key to be refused")
+	}
+}
+
+func TestFakeResumeSign(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFake(t, "seed")
+
+	if _, err := f.ResumeSign(ctx, "sign-1"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout for an unknown session, got %v", err)
+	}
+
+	key, err := f.CreateWallet(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	sig, err := f.Sign(ctx, SignRequest{
+		SessionID: "sign-1",
+		KeyID:     key.ID,
+		ShareData: key.ShareData,
+		MsgHash:   crypto.Keccak256([]byte("transaction")),
+	})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	resumed, err := f.ResumeSign(ctx, "sign-1")
+	if err != nil {
+		t.Fatalf("ResumeSign: %v", err)
+	}
+	if !bytes.Equal(sig, resumed) {
+		t.Fatal("resumed signature differs from the signed one")
+	}
+}
@@ -0,0 +1,169 @@
+package tss
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// resultAborted is the code of sessions cancelled by a TSS node
+const resultAborted = "aborted"
+
+var (
+	// ErrTimeout is returned when no result arrived in time
+	ErrTimeout = errors.New("timed out waiting for TSS result")
+	// ErrNodeFailure is returned when a TSS node could not run or finish the session
+	ErrNodeFailure = errors.New("TSS node failed")
+	// ErrAborted is returned when a TSS node cancelled the session
+	ErrAborted = errors.New("TSS session aborted")
+)
+
+// result is the payload a TSS node publishes when a session ends
+type result struct {
+	SessionID string `json:"session_id"`
+	PubKey    string `json:"pub_key"`
+	ShareData string `json:"share_data"`
+	Signature string `json:"signature"`
+	Code      string `json:"code"`
+	Error     string `json:"error"`
+}
+
+// complete reports whether the payload is a final result
+func (r *result) complete() bool {
+	return r.Error != "" || r.Signature != "" || (r.PubKey != "" && r.ShareData != "")
+}
+
+func (r *result) err() error {
+	switch {
+	case r.Error == "":
+		return nil
+	case r.Code == resultAborted:
+		return fmt.Errorf("%w: %s", ErrAborted, r.Error)
+	default:
+		return fmt.Errorf("%w: %s", ErrNodeFailure, r.Error)
+	}
+}
+
+// session is a result this client is waiting for
+type session struct {
+	done   chan struct{}
+	result *result
+	err    error
+}
+
+// await returns the result published on channel. start is called once the
+// channel is subscribed and starts the session, nil only waits for a session
+// started elsewhere. Callers awaiting a channel already awaited join the
+// pending wait instead of starting the session again.
+func (t *TSS) await(ctx context.Context, channel string, start func(context.Context) error) (*result, error) {
+	t.sessionsMu.Lock()
+	s, exists := t.sessions[channel]
+	if !exists {
+		s = &session{done: make(chan struct{})}
+		t.sessions[channel] = s
+
+		// The wait is shared, a caller giving up must not end it for the others
+		waitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), t.cfg.ResultTimeout)
+		go func() {
+			defer cancel()
+			s.result, s.err = t.wait(waitCtx, channel, start)
+
+			t.sessionsMu.Lock()
+			delete(t.sessions, channel)
+			t.sessionsMu.Unlock()
+			close(s.done)
+		}()
+	}
+	t.sessionsMu.Unlock()
+
+	select {
+	case <-s.done:
+		if s.err != nil {
+			return nil, s.err
+		}
+		return s.result, s.result.err()
+	case <-ctx.Done():
+		return nil, ctxError(ctx)
+	}
+}
+
+// wait subscribes to channel before reading the stored result, so a result
+// published at any time after the session started is seen
+func (t *TSS) wait(ctx context.Context, channel string, start func(context.Context) error) (*result, error) {
+	pubsub := t.redisClient.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	// Subscribe returns before the subscription exists, wait for the confirmation
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctxError(ctx)
+		}
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+	}
+	ch := pubsub.Channel()
+
+	stored, err := t.storedResult(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	// A failed session with the same ID is started again
+	if stored != nil && (start == nil || stored.Error == "") {
+		return stored, nil
+	}
+
+	if start != nil {
+		if err := start(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctxError(ctx)
+		case msg, ok := <-ch:
+			if !ok {
+				return nil, fmt.Errorf("channel closed")
+			}
+
+			var res result
+			if err := json.Unmarshal([]byte(msg.Payload), &res); err != nil {
+				log.Printf("Warning: Failed to parse JSON: %v", err)
+				continue
+			}
+			if !res.complete() {
+				continue // Incomplete message, wait for next one
+			}
+			return &res, nil
+		}
+	}
+}
+
+// storedResult reads the result the TSS nodes keep for late subscribers
+func (t *TSS) storedResult(ctx context.Context, channel string) (*result, error) {
+	payload, err := t.redisClient.Get(ctx, "result:"+channel).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored result of %s: %w", channel, err)
+	}
+
+	var res result
+	if err := json.Unmarshal(payload, &res); err != nil || !res.complete() {
+		return nil, nil
+	}
+	return &res, nil
+}
+
+// ctxError maps an expired context to ErrTimeout
+func ctxError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+	return ctx.Err()
+}
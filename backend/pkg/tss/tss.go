@@ -3,189 +3,216 @@ package tss
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"log"
+	"mpc/internal/config"
 	rd "mpc/internal/db/redis"
 	"mpc/pkg/logger"
+	"mpc/pkg/tracing"
 	pb "mpc/proto"
+	"sync"
 	"time"
 
-	"github.com/redis/go-redis/v9"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	grpcAddress  = "localhost:50051"
-	rpcTimeout   = 5 * time.Minute
 	keygenPrefix = "keygen:"
 	signPrefix   = "sign:"
 )
 
-// Configuration for TSS operations
-type Config struct {
+// Key describes a key generated by the TSS nodes
+type Key struct {
+	ID        string // keygen session, identifies the key on the nodes
 	Parties   []uint32
 	Threshold uint32
+	ShareData string // base64 encoded encrypted share of the coordinator
+	Address   string // Ethereum address of the public key
+}
+
+// SignRequest describes a message to sign
+type SignRequest struct {
+	SessionID string
+	KeyID     string   // keygen session of the key to sign with
+	Parties   []uint32 // parties of the key, the configured ones if empty
+	Threshold uint32   // threshold of the key, ignored if Parties is empty
+	ShareData string   // base64 encoded encrypted share of the coordinator
+	MsgHash   []byte
+	AuthToken string // policy approval checked by every node
+
+	// Optional unsigned transaction behind MsgHash, lets the nodes
+	// recompute the hash and apply their transaction rules
+	UnsignedTx []byte
+	ChainID    uint64
 }
 
 // TSS handles threshold signature operations
 type TSS struct {
-	redisClient *rd.Client
-	rpcClient   pb.MPCServiceClient
-	config      Config
+	redisClient  *rd.Client
+	coordinators []*coordinator
+	cfg          config.TSSConfig
+	sessions     map[string]*session // awaited sessions by result channel
+	sessionsMu   sync.Mutex
 }
 
-// NewTSS creates a new TSS instance with connection pooling
-func NewTSS(redisClient *rd.Client) (*TSS, error) {
-	conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewTSS creates a new TSS instance talking to the configured coordinators
+func NewTSS(cfg *config.TSSConfig, redisClient *rd.Client) (*TSS, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("no TSS endpoints configured")
+	}
+	if len(cfg.Parties) == 0 || int(cfg.Threshold) >= len(cfg.Parties) {
+		return nil, fmt.Errorf("invalid TSS parties %v with threshold %d", cfg.Parties, cfg.Threshold)
+	}
+
+	coordinators, err := dialCoordinators(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to gRPC server: %w", err)
+		return nil, err
 	}
 
-	return &TSS{
-		redisClient: redisClient,
-		rpcClient:   pb.NewMPCServiceClient(conn),
-		config: Config{
-			Parties:   []uint32{1, 2, 3},
-			Threshold: 2,
-		},
-	}, nil
+	t := &TSS{
+		redisClient:  redisClient,
+		coordinators: coordinators,
+		cfg:          *cfg,
+		sessions:     make(map[string]*session),
+	}
+	if t.cfg.ResultTimeout <= 0 {
+		t.cfg.ResultTimeout = 5 * time.Minute
+	}
+	if t.cfg.ConnectTimeout <= 0 {
+		t.cfg.ConnectTimeout = 5 * time.Second
+	}
+	if t.cfg.MaxRetries < 0 {
+		t.cfg.MaxRetries = 0
+	}
+	if t.cfg.BackoffMax < t.cfg.BackoffMin {
+		t.cfg.BackoffMax = t.cfg.BackoffMin
+	}
+	return t, nil
 }
 
-// CreateWallet initiates key generation for a new wallet
-func (t *TSS) CreateWallet(ctx context.Context, sessionID string) (shareData string, publicKey string, err error) {
-	// Set up context with timeout
-	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
-	defer cancel()
+// Close closes the connections to the coordinators
+func (t *TSS) Close() error {
+	return closeCoordinators(t.coordinators)
+}
 
-	// Notify key generation action
-	_, err = t.rpcClient.NotifyAction(ctx, &pb.ActionRequest{
-		SessionId: sessionID,
-		Parties:   t.config.Parties,
-		Threshold: t.config.Threshold,
-		Action:    pb.Action_INIT_KEYGEN,
+// CreateWallet generates a new key with the configured parties and threshold.
+// A keygen already completed for sessionID returns the same key.
+func (t *TSS) CreateWallet(ctx context.Context, sessionID string) (Key, error) {
+	return t.keygen(ctx, sessionID, func(ctx context.Context) error {
+		err := t.notify(ctx, &pb.ActionRequest{
+			SessionId: sessionID,
+			Parties:   t.cfg.Parties,
+			Threshold: t.cfg.Threshold,
+			Action:    pb.Action_INIT_KEYGEN,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to notify keygen action: %w", err)
+		}
+		return nil
 	})
-	if err != nil {
-		return "", "", fmt.Errorf("failed to notify keygen action: %w", err)
-	}
+}
 
-	// Subscribe to keygen results channel
-	keygenChannel := fmt.Sprintf("%s%s", keygenPrefix, sessionID)
-	logger.Debug("Subscribing to keygen channel: " + keygenChannel)
-	pubsub := t.redisClient.Subscribe(ctx, keygenChannel)
-	defer pubsub.Close()
+// ResumeWallet waits for the key of a keygen session started earlier,
+// e.g. by a previous instance of the API
+func (t *TSS) ResumeWallet(ctx context.Context, sessionID string) (Key, error) {
+	return t.keygen(ctx, sessionID, nil)
+}
+
+func (t *TSS) keygen(ctx context.Context, sessionID string, start func(context.Context) error) (_ Key, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "tss.CreateWallet", trace.WithAttributes(
+		attribute.String("tss.session_id", sessionID),
+		attribute.Bool("tss.resume", start == nil),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	// Set up context with timeout
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.ResultTimeout)
+	defer cancel()
 
 	// Wait for results with timeout
-	shareData, publicKey, err = processKeygenResult(ctx, pubsub.Channel())
-	// logger.Debug(fmt.Sprintf("Keygen result received: shareData=%s, publicKey=%s", shareData, publicKey))
+	span.AddEvent("waiting for keygen result")
+	keygenChannel := fmt.Sprintf("%s%s", keygenPrefix, sessionID)
+	logger.Debug("Waiting on keygen channel: " + keygenChannel)
+	res, err := t.await(ctx, keygenChannel, start)
 	if err != nil {
-		return "", "", fmt.Errorf("key generation failed: %w", err)
+		return Key{}, fmt.Errorf("key generation failed: %w", err)
+	}
+	if res.ShareData == "" || res.PubKey == "" {
+		return Key{}, fmt.Errorf("key generation failed: %w: incomplete result", ErrNodeFailure)
 	}
 
-	return shareData, publicKey, nil
+	return Key{
+		ID:        sessionID,
+		Parties:   t.cfg.Parties,
+		Threshold: t.cfg.Threshold,
+		ShareData: res.ShareData,
+		Address:   res.PubKey,
+	}, nil
 }
 
-// Sign creates a threshold signature for the given message
-func (t *TSS) Sign(ctx context.Context, sessionID string, shareData string, message []byte) ([]byte, error) {
-	// Set up context with timeout
-	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
-	defer cancel()
+// Sign creates a threshold signature for the given request.
+// A signature already produced for the session is returned again.
+func (t *TSS) Sign(ctx context.Context, req SignRequest) ([]byte, error) {
+	// Sign with the parties the key was generated for
+	parties, threshold := req.Parties, req.Threshold
+	if len(parties) == 0 {
+		parties, threshold = t.cfg.Parties, t.cfg.Threshold
+	}
 
-	encryptedShare, err := base64.StdEncoding.DecodeString(shareData)
+	encryptedShare, err := base64.StdEncoding.DecodeString(req.ShareData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode share data: %w", err)
 	}
 
-	// Notify signing action
-	_, err = t.rpcClient.NotifyAction(ctx, &pb.ActionRequest{
-		SessionId: sessionID,
-		Parties:   t.config.Parties,
-		Threshold: t.config.Threshold,
-		MsgHash:   message,
-		ShareData: encryptedShare,
-		Action:    pb.Action_INIT_SIGN,
+	return t.sign(ctx, req.SessionID, func(ctx context.Context) error {
+		err := t.notify(ctx, &pb.ActionRequest{
+			SessionId:  req.SessionID,
+			Parties:    parties,
+			Threshold:  threshold,
+			KeyId:      req.KeyID,
+			MsgHash:    req.MsgHash,
+			ShareData:  encryptedShare,
+			Action:     pb.Action_INIT_SIGN,
+			AuthToken:  req.AuthToken,
+			UnsignedTx: req.UnsignedTx,
+			ChainId:    req.ChainID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to notify signing action: %w", err)
+		}
+		return nil
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to notify signing action: %w", err)
-	}
+}
 
-	// Subscribe to signing results channel
-	signChannel := fmt.Sprintf("%s%s", signPrefix, sessionID)
-	pubsub := t.redisClient.Subscribe(ctx, signChannel)
-	defer pubsub.Close()
+// ResumeSign waits for the signature of a sign session started earlier
+func (t *TSS) ResumeSign(ctx context.Context, sessionID string) ([]byte, error) {
+	return t.sign(ctx, sessionID, nil)
+}
+
+func (t *TSS) sign(ctx context.Context, sessionID string, start func(context.Context) error) (_ []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "tss.Sign", trace.WithAttributes(
+		attribute.String("tss.session_id", sessionID),
+		attribute.Bool("tss.resume", start == nil),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	// Set up context with timeout
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.ResultTimeout)
+	defer cancel()
 
 	// Wait for signature with timeout
-	signature, err := processSignResult(ctx, pubsub.Channel())
+	span.AddEvent("waiting for signature")
+	signChannel := fmt.Sprintf("%s%s", signPrefix, sessionID)
+	res, err := t.await(ctx, signChannel, start)
 	if err != nil {
 		return nil, fmt.Errorf("signature generation failed: %w", err)
 	}
 
-	return signature, nil
-}
-
-// processKeygenResult handles the keygen result from Redis PubSub
-func processKeygenResult(ctx context.Context, ch <-chan *redis.Message) (string, string, error) {
-	for {
-		select {
-		case <-ctx.Done():
-			return "", "", ctx.Err()
-		case msg, ok := <-ch:
-			if !ok {
-				return "", "", fmt.Errorf("channel closed")
-			}
-
-			var result map[string]string
-			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
-				log.Printf("Warning: Failed to parse JSON: %v", err)
-				continue
-			}
-
-			shareData, ok := result["share_data"]
-			if !ok {
-				continue // Incomplete message, wait for next one
-			}
-
-			publicKey, ok := result["pub_key"]
-			if !ok {
-				continue // Incomplete message, wait for next one
-			}
-
-			return shareData, publicKey, nil
-		}
+	// Decode base64 signature
+	signature, err := base64.StdEncoding.DecodeString(res.Signature)
+	if err != nil || len(signature) == 0 {
+		return nil, fmt.Errorf("signature generation failed: %w: invalid signature", ErrNodeFailure)
 	}
-}
 
-// processSignResult handles the signing result from Redis PubSub
-func processSignResult(ctx context.Context, ch <-chan *redis.Message) ([]byte, error) {
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case msg, ok := <-ch:
-			if !ok {
-				return nil, fmt.Errorf("channel closed")
-			}
-
-			var result map[string]string
-			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
-				log.Printf("Warning: Failed to parse JSON: %v", err)
-				continue
-			}
-
-			signatureBase64, ok := result["signature"]
-			if !ok {
-				continue // Incomplete message, wait for next one
-			}
-
-			// Decode base64 signature
-			signature, err := base64.StdEncoding.DecodeString(signatureBase64)
-			if err != nil {
-				log.Printf("Warning: Failed to decode base64 signature: %v", err)
-				continue
-			}
-
-			return signature, nil
-		}
-	}
+	return signature, nil
 }
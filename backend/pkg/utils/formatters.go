@@ -69,3 +69,21 @@ func ToNullablePgUUID(id *uuid.UUID, format string) pgtype.UUID {
 func CurrentPgTimestamp() pgtype.Timestamp {
 	return ToPgTimestamp(time.Now().Format(time.RFC3339))
 }
+
+// ToInt32s converts party IDs to the INT[] representation of the database
+func ToInt32s(ids []uint32) []int32 {
+	result := make([]int32, len(ids))
+	for i, id := range ids {
+		result[i] = int32(id)
+	}
+	return result
+}
+
+// ToUint32s converts an INT[] column back to party IDs
+func ToUint32s(ids []int32) []uint32 {
+	result := make([]uint32, len(ids))
+	for i, id := range ids {
+		result[i] = uint32(id)
+	}
+	return result
+}
@@ -14,6 +14,7 @@ func ToChainResponse(chain model.Chain) model.ChainResponse {
 		RPCURL:         chain.RPCURL,
 		ExplorerURL:    chain.ExplorerURL,
 		NativeCurrency: chain.NativeCurrency,
+		Confirmations:  chain.Confirmations,
 	}
 }
 
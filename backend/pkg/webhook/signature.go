@@ -0,0 +1,63 @@
+// Package webhook signs the events delivered to organization webhooks.
+//
+// A delivery carries the time it was signed in the X-Webhook-Timestamp
+// header and the HMAC-SHA256 of "<timestamp>.<body>", keyed with the secret
+// of the webhook, in the X-Webhook-Signature header as sha256=<hex>.
+// Receivers recompute the signature and reject old timestamps to stop
+// replays.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	IDHeader        = "X-Webhook-ID"
+)
+
+// secretPrefix marks webhook secrets, like the sk_ of API keys
+const secretPrefix = "whsec_"
+
+// NewSecret returns a random webhook secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header of a body sent at timestamp, in Unix seconds
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of a body sent at timestamp
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff returns the wait before the next attempt of a delivery that failed
+// attempts times: min after the first failure, doubled after each one up to max
+func Backoff(attempts int, min, max time.Duration) time.Duration {
+	wait := min
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"wallet.created"}`)
+
+	// echo -n '1700000000.{"event":"wallet.created"}' | openssl dgst -sha256 -hmac whsec_test
+	const want = "sha256=69c56e4ef62ace6cbd246cad46249585301e5af6a7e715bde010558f7b33a97f"
+	got := Sign("whsec_test", 1700000000, body)
+	if got != want {
+		t.Fatalf("Sign() = %s, want %s", got, want)
+	}
+
+	if !Verify("whsec_test", 1700000000, body, got) {
+		t.Error("Verify() rejects the signature of the body")
+	}
+	if Verify("whsec_other", 1700000000, body, got) {
+		t.Error("Verify() accepts a signature made with another secret")
+	}
+	if Verify("whsec_test", 1700000001, body, got) {
+		t.Error("Verify() accepts a signature made at another time")
+	}
+	if Verify("whsec_test", 1700000000, []byte(`{"event":"deposit.received"}`), got) {
+		t.Error("Verify() accepts a signature of another body")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewSecret()
+	if !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+64 {
+		t.Errorf("NewSecret() = %s", a)
+	}
+	if a == b {
+		t.Error("NewSecret() returns the same secret twice")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{10, time.Hour},
+		{1000, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts, 30*time.Second, time.Hour); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
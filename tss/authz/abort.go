@@ -0,0 +1,83 @@
+package authz
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// abortTokenTTL is how long an abort token is accepted after it was issued
+const abortTokenTTL = time.Minute
+
+// minPeerKeyLength is the minimum length of the key nodes share
+const minPeerKeyLength = 32
+
+// ErrNoPeerKey is returned for peer requests when the node has no peer key
+var ErrNoPeerKey = errors.New("no peer key configured")
+
+// PeerKey signs and checks the requests nodes send each other, like ABORT,
+// with a key only the nodes know
+type PeerKey struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewPeerKey creates a peer key from the secret shared by the nodes
+func NewPeerKey(key string) (*PeerKey, error) {
+	if len(key) < minPeerKeyLength {
+		return nil, fmt.Errorf("peer key must be at least %d bytes", minPeerKeyLength)
+	}
+	return &PeerKey{key: []byte(key), now: time.Now}, nil
+}
+
+// SignAbort creates the token of a node aborting the session.
+// The token is the issue time in unix seconds "." base64url(HMAC-SHA256).
+func (k *PeerKey) SignAbort(sessionID string, action string) string {
+	issuedAt := k.now().Unix()
+	return strconv.FormatInt(issuedAt, 10) + "." + base64.RawURLEncoding.EncodeToString(k.abortMAC(sessionID, action, issuedAt))
+}
+
+// VerifyAbort checks that the token was issued by a node for aborting this
+// session and action, and is recent
+func (k *PeerKey) VerifyAbort(token string, sessionID string, action string) error {
+	if token == "" {
+		return ErrMissingToken
+	}
+
+	encodedTime, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrMalformed
+	}
+	issuedAt, err := strconv.ParseInt(encodedTime, 10, 64)
+	if err != nil {
+		return ErrMalformed
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return ErrMalformed
+	}
+	if !hmac.Equal(mac, k.abortMAC(sessionID, action, issuedAt)) {
+		return ErrBadSignature
+	}
+
+	issued := time.Unix(issuedAt, 0)
+	now := k.now()
+	if now.After(issued.Add(abortTokenTTL + clockSkew)) {
+		return ErrExpired
+	}
+	if now.Add(clockSkew).Before(issued) {
+		return ErrNotYetValid
+	}
+	return nil
+}
+
+func (k *PeerKey) abortMAC(sessionID string, action string, issuedAt int64) []byte {
+	h := hmac.New(sha256.New, k.key)
+	fmt.Fprintf(h, "abort\x00%s\x00%s\x00%d", sessionID, action, issuedAt)
+	return h.Sum(nil)
+}
@@ -0,0 +1,38 @@
+package authz
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyAbort(t *testing.T) {
+	k, err := NewPeerKey(strings.Repeat("k", 32))
+	assert.NoError(t, err)
+
+	token := k.SignAbort("session", "KEYGEN")
+	assert.NoError(t, k.VerifyAbort(token, "session", "KEYGEN"))
+
+	assert.ErrorIs(t, k.VerifyAbort("", "session", "KEYGEN"), ErrMissingToken)
+	assert.ErrorIs(t, k.VerifyAbort("token", "session", "KEYGEN"), ErrMalformed)
+	assert.ErrorIs(t, k.VerifyAbort(token, "other", "KEYGEN"), ErrBadSignature)
+	assert.ErrorIs(t, k.VerifyAbort(token, "session", "SIGN"), ErrBadSignature)
+
+	other, err := NewPeerKey(strings.Repeat("o", 32))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, other.VerifyAbort(token, "session", "KEYGEN"), ErrBadSignature)
+
+	now := time.Now()
+	k.now = func() time.Time { return now.Add(time.Hour) }
+	assert.ErrorIs(t, k.VerifyAbort(token, "session", "KEYGEN"), ErrExpired)
+
+	k.now = func() time.Time { return now.Add(-time.Hour) }
+	assert.ErrorIs(t, k.VerifyAbort(token, "session", "KEYGEN"), ErrNotYetValid)
+}
+
+func TestNewPeerKey(t *testing.T) {
+	_, err := NewPeerKey("short")
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package authz
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockSkew is the tolerated clock difference between the policy service and nodes
+const clockSkew = 30 * time.Second
+
+var (
+	ErrMissingToken  = errors.New("missing authorization token")
+	ErrMalformed     = errors.New("malformed authorization token")
+	ErrBadSignature  = errors.New("invalid authorization token signature")
+	ErrExpired       = errors.New("authorization token expired")
+	ErrNotYetValid   = errors.New("authorization token not yet valid")
+	ErrClaimMismatch = errors.New("authorization token does not match request")
+)
+
+// Claims is what the policy service approves: one hash, signed with one key, in one session
+type Claims struct {
+	SessionID string `json:"sid"`
+	KeyID     string `json:"kid"`
+	MsgHash   string `json:"hash"` // hex encoded
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Issue creates a token for the given claims.
+// The token is base64url(claims) "." base64url(ed25519 signature of the first part).
+func Issue(key ed25519.PrivateKey, claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal claims: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	sig := ed25519.Sign(key, []byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verifier checks authorization tokens against the policy service public key
+type Verifier struct {
+	key ed25519.PublicKey
+	now func() time.Time
+}
+
+// NewVerifier creates a verifier from a hex encoded ed25519 public key
+func NewVerifier(hexKey string) (*Verifier, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verification key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid verification key length: %d", len(key))
+	}
+	return &Verifier{key: ed25519.PublicKey(key), now: time.Now}, nil
+}
+
+// Verify checks the token signature and validity and that it approves
+// exactly this session, key and hash
+func (v *Verifier) Verify(token string, sessionID string, keyID string, msgHash []byte) (*Claims, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	encoded, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !ed25519.Verify(v.key, []byte(encoded), sig) {
+		return nil, ErrBadSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrMalformed
+	}
+
+	now := v.now()
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, ErrExpired
+	}
+	if now.Add(clockSkew).Before(time.Unix(claims.IssuedAt, 0)) {
+		return nil, ErrNotYetValid
+	}
+
+	hash, err := hex.DecodeString(claims.MsgHash)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if claims.SessionID != sessionID || claims.KeyID != keyID || !bytes.Equal(hash, msgHash) {
+		return nil, ErrClaimMismatch
+	}
+	return &claims, nil
+}
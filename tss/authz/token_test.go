@@ -0,0 +1,79 @@
+package authz
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestVerifier(t *testing.T) (ed25519.PrivateKey, *Verifier) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	v, err := NewVerifier(hex.EncodeToString(pub))
+	assert.NoError(t, err)
+	return priv, v
+}
+
+func TestVerify(t *testing.T) {
+	key, v := newTestVerifier(t)
+	hash := []byte{0x01, 0x02, 0x03}
+	now := time.Now()
+
+	token, err := Issue(key, Claims{
+		SessionID: "session",
+		KeyID:     "key",
+		MsgHash:   hex.EncodeToString(hash),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	})
+	assert.NoError(t, err)
+
+	_, err = v.Verify(token, "session", "key", hash)
+	assert.NoError(t, err)
+
+	_, err = v.Verify("", "session", "key", hash)
+	assert.ErrorIs(t, err, ErrMissingToken)
+
+	_, err = v.Verify(token, "other", "key", hash)
+	assert.ErrorIs(t, err, ErrClaimMismatch)
+
+	_, err = v.Verify(token, "session", "other", hash)
+	assert.ErrorIs(t, err, ErrClaimMismatch)
+
+	_, err = v.Verify(token, "session", "key", []byte{0x01, 0x02, 0x04})
+	assert.ErrorIs(t, err, ErrClaimMismatch)
+
+	payload, encodedSig, _ := strings.Cut(token, ".")
+	sig, _ := base64.RawURLEncoding.DecodeString(encodedSig)
+	sig[0] ^= 0xff
+	_, err = v.Verify(payload+"."+base64.RawURLEncoding.EncodeToString(sig), "session", "key", hash)
+	assert.ErrorIs(t, err, ErrBadSignature)
+
+	v.now = func() time.Time { return now.Add(time.Hour) }
+	_, err = v.Verify(token, "session", "key", hash)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestVerifyOtherKey(t *testing.T) {
+	_, v := newTestVerifier(t)
+	other, _ := newTestVerifier(t)
+
+	token, err := Issue(other, Claims{
+		SessionID: "session",
+		KeyID:     "key",
+		MsgHash:   "01",
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	})
+	assert.NoError(t, err)
+
+	_, err = v.Verify(token, "session", "key", []byte{0x01})
+	assert.ErrorIs(t, err, ErrBadSignature)
+}
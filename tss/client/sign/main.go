@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"log"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/bnb-chain/tss-lib/v2/tss"
+	"github.com/vietddude/tss-impl/authz"
 	pb "github.com/vietddude/tss-impl/proto"
 	"github.com/vietddude/tss-impl/utils"
 	"google.golang.org/grpc"
@@ -52,13 +56,20 @@ func main() {
 
 	msgHash := []byte("hello")
 
+	authToken, err := issueAuthToken(sessionID, msgHash)
+	if err != nil {
+		log.Fatalf("failed to issue authorization token: %v", err)
+	}
+
 	res, err := client.NotifyAction(signCtx, &pb.ActionRequest{
 		SessionId: sessionID,
+		KeyId:     sessionID,
 		Parties:   []uint32{1, 2, 3},
 		Threshold: 2,
 		MsgHash:   msgHash,
 		ShareData: encryptedShare,
 		Action:    pb.Action_INIT_SIGN,
+		AuthToken: authToken,
 	})
 	if err != nil {
 		log.Fatalf("failed to notify signing action: %v", err)
@@ -66,3 +77,21 @@ func main() {
 
 	log.Printf("Sign init response: %v", res)
 }
+
+// issueAuthToken approves the request in place of the policy service, using the
+// hex encoded ed25519 seed in SIGN_AUTH_KEY
+func issueAuthToken(sessionID string, msgHash []byte) (string, error) {
+	seed, err := hex.DecodeString(os.Getenv("SIGN_AUTH_KEY"))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return "", fmt.Errorf("SIGN_AUTH_KEY must be a hex encoded %d byte seed", ed25519.SeedSize)
+	}
+
+	now := time.Now()
+	return authz.Issue(ed25519.NewKeyFromSeed(seed), authz.Claims{
+		SessionID: sessionID,
+		KeyID:     sessionID,
+		MsgHash:   hex.EncodeToString(msgHash),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(5 * time.Minute).Unix(),
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vietddude/tss-impl/config"
+	"github.com/vietddude/tss-impl/policy"
+	"github.com/vietddude/tss-impl/txrules"
+	"go.uber.org/zap"
+)
+
+// The policy service runs apart from the backend and the nodes, it is the
+// only holder of the key approving sign requests
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger, _ := zap.NewDevelopment()
+	defer logger.Sync()
+
+	rules, err := txrules.New(&cfg.TxRules)
+	if err != nil {
+		log.Fatalf("Invalid transaction rules: %v", err)
+	}
+	service, err := policy.New(&cfg.Policy, rules, logger)
+	if err != nil {
+		log.Fatalf("Failed to initialize policy service: %v", err)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Policy.Address,
+		Handler:           service.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Policy service is running on %s, nodes verify its tokens with SIGN_AUTH_VERIFY_KEY=%s", cfg.Policy.Address, service.PublicKey())
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Policy service failed: %v", err)
+	}
+}
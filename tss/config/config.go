@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v10"
 	"github.com/joho/godotenv"
@@ -16,17 +17,75 @@ type DB struct {
 }
 
 type Node struct {
-	ID      uint32 `env:"NODE_ID"`
-	Address string `env:"NODE_ADDRESS"`
+	ID             uint32 `env:"NODE_ID"`
+	Address        string `env:"NODE_ADDRESS"`
+	MetricsAddress string `env:"NODE_METRICS_ADDRESS" envDefault:"localhost:9100"`
+}
+
+// TLS enables mTLS between the backend and the nodes and between nodes.
+// Every node presents the same certificate, issued by the CA for ServerName.
+type TLS struct {
+	CA         string `env:"TLS_CA"` // CA of all certificates, plaintext when empty
+	Cert       string `env:"TLS_CERT"`
+	Key        string `env:"TLS_KEY"`
+	ServerName string `env:"TLS_SERVER_NAME"` // name the node certificate is issued for, checked when dialing peers
+}
+
+type Tracing struct {
+	Exporter     string `env:"TRACING_EXPORTER" envDefault:"none"` // none, otlp, stdout or file
+	OTLPEndpoint string `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4317"`
+	OTLPInsecure bool   `env:"TRACING_OTLP_INSECURE" envDefault:"true"`
+	FilePath     string `env:"TRACING_FILE" envDefault:"traces.json"`
+	ServiceName  string `env:"TRACING_SERVICE_NAME" envDefault:"tss-node"`
+}
+
+type Peer struct {
+	QueueSize  int           `env:"PEER_QUEUE_SIZE" envDefault:"1024"`
+	BackoffMin time.Duration `env:"PEER_BACKOFF_MIN" envDefault:"100ms"`
+	BackoffMax time.Duration `env:"PEER_BACKOFF_MAX" envDefault:"10s"`
+}
+
+type SignAuth struct {
+	VerifyKey string `env:"SIGN_AUTH_VERIFY_KEY"` // hex encoded ed25519 public key of the policy service
+}
+
+type Policy struct {
+	Address       string        `env:"POLICY_ADDRESS" envDefault:"localhost:8090"`
+	SigningKey    string        `env:"POLICY_SIGNING_KEY"` // hex encoded ed25519 seed, its public key is SIGN_AUTH_VERIFY_KEY of the nodes
+	TokenTTL      time.Duration `env:"POLICY_TOKEN_TTL" envDefault:"2m"`
+	AuthToken     string        `env:"POLICY_AUTH_TOKEN"`                       // bearer token required from callers if set
+	AllowMessages bool          `env:"POLICY_ALLOW_MESSAGES" envDefault:"true"` // approve personal_sign messages and EIP-712 typed data
+}
+
+type PeerAuth struct {
+	Key string `env:"PEER_AUTH_KEY"` // secret shared by the nodes, at least 32 bytes, signs the ABORT actions they send each other
+}
+
+type TxRules struct {
+	RequirePayload  bool     `env:"TX_RULES_REQUIRE_PAYLOAD" envDefault:"false"` // refuse sign requests without unsigned_tx
+	ChainIDs        []uint64 `env:"TX_RULES_CHAIN_IDS" envSeparator:","`
+	MaxValueWei     string   `env:"TX_RULES_MAX_VALUE_WEI"`
+	MaxTokenAmount  string   `env:"TX_RULES_MAX_TOKEN_AMOUNT"` // in token base units, for ERC-20 transfer, transferFrom and approve
+	AllowedTo       []string `env:"TX_RULES_ALLOWED_TO" envSeparator:","`
+	DeniedSelectors []string `env:"TX_RULES_DENIED_SELECTORS" envSeparator:","`
 }
 
 type Config struct {
-	DB         DB
-	Node       Node
-	NodeNumber int    `env:"NODE_NUMBER"`
-	WebhookURL string `env:"WEBHOOK_URL"`
-	EncryptKey string `env:"ENCRYPT_KEY"`
-	RedisAddr  string `env:"REDIS_ADDR"`
+	DB           DB
+	Node         Node
+	Tracing      Tracing
+	TLS          TLS
+	Peer         Peer
+	SignAuth     SignAuth
+	PeerAuth     PeerAuth
+	Policy       Policy
+	TxRules      TxRules
+	NodeNumber   int           `env:"NODE_NUMBER"`
+	WebhookURL   string        `env:"WEBHOOK_URL"`
+	EncryptKey   string        `env:"ENCRYPT_KEY"`
+	RedisAddr    string        `env:"REDIS_ADDR"`
+	DrainTimeout time.Duration `env:"DRAIN_TIMEOUT" envDefault:"60s"`
+	ResultTTL    time.Duration `env:"RESULT_TTL" envDefault:"10m"` // how long session results stay readable after publishing
 }
 
 func Load() (*Config, error) {
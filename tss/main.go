@@ -1,37 +1,48 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/vietddude/tss-impl/config"
 	"github.com/vietddude/tss-impl/db"
 	"github.com/vietddude/tss-impl/proto"
 	"github.com/vietddude/tss-impl/server"
+	"github.com/vietddude/tss-impl/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
 )
 
 func initializeServers(cfg *config.Config) ([]config.Node, error) {
-	parts := strings.Split(cfg.Node.Address, ":")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid address format: %s", parts)
+	basePort, err := parsePort(cfg.Node.Address)
+	if err != nil {
+		return nil, err
 	}
 
-	basePort, err := strconv.Atoi(parts[1])
+	metricsHost, metricsBasePort, err := parseHostPort(cfg.Node.MetricsAddress)
 	if err != nil {
-		return nil, fmt.Errorf("invalid port number: %v", err)
+		return nil, fmt.Errorf("invalid metrics address: %w", err)
 	}
 
 	servers := make([]config.Node, 0, cfg.NodeNumber)
 	for i := 0; i < cfg.NodeNumber; i++ {
 		server := config.Node{
-			ID:      cfg.Node.ID + uint32(i),
-			Address: fmt.Sprintf("localhost:%d", basePort+i),
+			ID:             cfg.Node.ID + uint32(i),
+			Address:        fmt.Sprintf("localhost:%d", basePort+i),
+			MetricsAddress: fmt.Sprintf("%s:%d", metricsHost, metricsBasePort+i),
 		}
 		servers = append(servers, server)
 	}
@@ -39,6 +50,24 @@ func initializeServers(cfg *config.Config) ([]config.Node, error) {
 	return servers, nil
 }
 
+func parsePort(address string) (int, error) {
+	_, port, err := parseHostPort(address)
+	return port, err
+}
+
+func parseHostPort(address string) (string, int, error) {
+	parts := strings.Split(address, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid address format: %s", parts)
+	}
+
+	port, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port number: %v", err)
+	}
+	return parts[0], port, nil
+}
+
 func createPeerMap(servers []config.Node) *sync.Map {
 	peerMap := &sync.Map{}
 	for _, srv := range servers {
@@ -47,24 +76,92 @@ func createPeerMap(servers []config.Node) *sync.Map {
 	return peerMap
 }
 
-func startServer(id uint32, address string, peerMap *sync.Map, wg *sync.WaitGroup, dbPool *pgxpool.Pool, cfg *config.Config) {
+// grpcStopTimeout bounds how long a stopping server waits for open peer streams
+const grpcStopTimeout = 5 * time.Second
+
+func startServer(ctx context.Context, node config.Node, peerMap *sync.Map, wg *sync.WaitGroup, dbPool *pgxpool.Pool, cfg *config.Config, creds *server.Credentials) {
 	defer wg.Done()
 
-	mpcServer := server.NewMPCServer(id, dbPool, cfg)
+	id, address := node.ID, node.Address
+	mpcServer := server.NewMPCServer(id, dbPool, cfg, creds.Client)
 	setupPeerConnections(mpcServer, id, peerMap)
 
+	metricsServer := &http.Server{
+		Addr:              node.MetricsAddress,
+		Handler:           mpcServer.HTTPHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("Server %d exposes metrics on %s/metrics", id, node.MetricsAddress)
+		if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Server %d: metrics server failed: %v", id, err)
+		}
+	}()
+	defer stopMetricsServer(metricsServer, grpcStopTimeout)
+
 	lis, err := net.Listen("tcp", address)
 	if err != nil {
 		log.Printf("Server %d failed to listen on %s: %v", id, address, err)
 		return
 	}
 
-	grpcServer := grpc.NewServer()
+	// Peer message streams are long lived, sessions are traced from the messages instead
+	// Clients and peers must present a certificate of the CA when mTLS is on
+	grpcServer := grpc.NewServer(
+		grpc.Creds(creds.Server),
+		grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithFilter(filters.Not(filters.MethodName("StreamMessages"))),
+		)),
+	)
 	proto.RegisterMPCServiceServer(grpcServer, mpcServer)
 
 	log.Printf("Server %d is running on %s", id, address)
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Printf("Server %d failed to serve: %v", id, err)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(lis)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			log.Printf("Server %d failed to serve: %v", id, err)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	log.Printf("Server %d shutting down, draining sessions for up to %s", id, cfg.DrainTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancel()
+	if err := mpcServer.Shutdown(drainCtx); err != nil {
+		log.Printf("Server %d: %v", id, err)
+	}
+
+	stopGRPCServer(grpcServer, grpcStopTimeout)
+	log.Printf("Server %d stopped", id)
+}
+
+// stopMetricsServer stops the metrics server, waiting up to timeout for open scrapes
+func stopMetricsServer(metricsServer *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		metricsServer.Close()
+	}
+}
+
+// stopGRPCServer stops the server gracefully, forcing it once timeout is reached
+func stopGRPCServer(grpcServer *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		grpcServer.Stop()
 	}
 }
 
@@ -74,18 +171,17 @@ func setupPeerConnections(mpcServer *server.MPCServer, id uint32, peerMap *sync.
 		peerAddr := value.(string)
 		if peerID != id {
 			mpcServer.AddPeer(peerID, peerAddr)
-			go mpcServer.ConnectToPeers()
 		}
 		return true
 	})
 }
 
-func startAllServers(servers []config.Node, peerMap *sync.Map, dbPool *pgxpool.Pool, cfg *config.Config) {
+func startAllServers(ctx context.Context, servers []config.Node, peerMap *sync.Map, dbPool *pgxpool.Pool, cfg *config.Config, creds *server.Credentials) {
 	var wg sync.WaitGroup
 	wg.Add(len(servers))
 
 	for _, srv := range servers {
-		go startServer(srv.ID, srv.Address, peerMap, &wg, dbPool, cfg)
+		go startServer(ctx, srv, peerMap, &wg, dbPool, cfg, creds)
 	}
 
 	wg.Wait()
@@ -97,18 +193,33 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), &cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	servers, err := initializeServers(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize servers: %v", err)
 	}
 
+	creds, err := server.LoadCredentials(&cfg.TLS)
+	if err != nil {
+		log.Fatalf("Failed to load TLS credentials: %v", err)
+	}
+
 	dbPool, err := db.InitDB(&cfg.DB)
 	if err != nil {
 		log.Fatalf("Failed to initialize DB: %v", err)
 	}
 	defer dbPool.Close()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	peerMap := createPeerMap(servers)
 	log.Printf("Starting %d TSS servers...", len(servers))
-	startAllServers(servers, peerMap, dbPool, cfg)
+	startAllServers(ctx, servers, peerMap, dbPool, cfg, creds)
+	log.Printf("All servers stopped, closing database pool")
 }
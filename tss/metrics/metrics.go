@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const namespace = "tss"
+
+// Actions
+const (
+	ActionKeygen = "keygen"
+	ActionSign   = "sign"
+)
+
+// Outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Reasons for dropping a TSS message
+const (
+	DropPartyNotFound = "party_not_found"
+	DropSendFailed    = "send_failed"
+	DropQueueFull     = "queue_full"
+)
+
+// Share store operations
+const (
+	ShareOpInsert  = "insert"
+	ShareOpGet     = "get"
+	ShareOpEncrypt = "encrypt"
+	ShareOpDecrypt = "decrypt"
+)
+
+// Metrics holds the Prometheus collectors of a single TSS node.
+// Each node owns its own registry so several nodes can run in one process.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	sessions          *prometheus.CounterVec
+	sessionDuration   *prometheus.HistogramVec
+	activeSessions    *prometheus.GaugeVec
+	roundDuration     *prometheus.HistogramVec
+	preParamsDuration prometheus.Histogram
+	droppedMessages   *prometheus.CounterVec
+	streamReconnects  *prometheus.CounterVec
+	shareStoreErrors  *prometheus.CounterVec
+}
+
+// New creates and registers the collectors for the given node
+func New(nodeID uint32) *Metrics {
+	labels := prometheus.Labels{"node_id": strconv.FormatUint(uint64(nodeID), 10)}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		sessions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "sessions_total",
+			Help:        "Number of finished TSS sessions.",
+			ConstLabels: labels,
+		}, []string{"action", "outcome"}),
+		sessionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "session_duration_seconds",
+			Help:        "Duration of TSS sessions from start to result.",
+			ConstLabels: labels,
+			Buckets:     []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}, []string{"action", "outcome"}),
+		activeSessions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "active_sessions",
+			Help:        "Number of TSS sessions currently running on the node.",
+			ConstLabels: labels,
+		}, []string{"action"}),
+		roundDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "round_duration_seconds",
+			Help:        "Time spent in each protocol round, measured between the first messages of consecutive rounds.",
+			ConstLabels: labels,
+			Buckets:     prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"action", "round"}),
+		preParamsDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "preparams_duration_seconds",
+			Help:        "Time spent generating keygen pre-parameters.",
+			ConstLabels: labels,
+			Buckets:     []float64{1, 5, 10, 20, 30, 60, 120, 300},
+		}),
+		droppedMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "dropped_messages_total",
+			Help:        "Number of TSS messages that were dropped.",
+			ConstLabels: labels,
+		}, []string{"reason"}),
+		streamReconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "peer_stream_reconnects_total",
+			Help:        "Number of attempts to recreate a peer message stream.",
+			ConstLabels: labels,
+		}, []string{"peer", "outcome"}),
+		shareStoreErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "share_store_errors_total",
+			Help:        "Number of errors while storing or loading key shares.",
+			ConstLabels: labels,
+		}, []string{"op"}),
+	}
+
+	m.registry.MustRegister(
+		m.sessions,
+		m.sessionDuration,
+		m.activeSessions,
+		m.roundDuration,
+		m.preParamsDuration,
+		m.droppedMessages,
+		m.streamReconnects,
+		m.shareStoreErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler returns the HTTP handler exposing the node's metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// StartSession marks a session as running and returns a function that
+// records its outcome and duration once the session is over.
+func (m *Metrics) StartSession(action string) func(outcome string) {
+	start := time.Now()
+	m.activeSessions.WithLabelValues(action).Inc()
+
+	var once sync.Once
+	return func(outcome string) {
+		once.Do(func() {
+			m.activeSessions.WithLabelValues(action).Dec()
+			m.sessions.WithLabelValues(action, outcome).Inc()
+			m.sessionDuration.WithLabelValues(action, outcome).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// ObservePreParams records the time spent generating pre-parameters
+func (m *Metrics) ObservePreParams(d time.Duration) {
+	m.preParamsDuration.Observe(d.Seconds())
+}
+
+// MessageDropped counts a dropped TSS message
+func (m *Metrics) MessageDropped(reason string) {
+	m.droppedMessages.WithLabelValues(reason).Inc()
+}
+
+// StreamReconnect counts an attempt to recreate the stream to a peer
+func (m *Metrics) StreamReconnect(peer string, outcome string) {
+	m.streamReconnects.WithLabelValues(peer, outcome).Inc()
+}
+
+// ShareStoreError counts a failed share store operation
+func (m *Metrics) ShareStoreError(op string) {
+	m.shareStoreErrors.WithLabelValues(op).Inc()
+}
+
+// ActiveSessions returns the number of sessions currently running, read from
+// the tss_active_sessions gauge
+func (m *Metrics) ActiveSessions() int {
+	total := 0.0
+	for _, action := range []string{ActionKeygen, ActionSign} {
+		var metric dto.Metric
+		if err := m.activeSessions.WithLabelValues(action).Write(&metric); err == nil {
+			total += metric.GetGauge().GetValue()
+		}
+	}
+	return int(total)
+}
+
+// Load returns the node load in the range [0, 1). It grows with the number
+// of active sessions and saturates towards 1 as the node gets busier.
+func (m *Metrics) Load() float64 {
+	active := float64(m.ActiveSessions())
+	return active / (active + 1)
+}
+
+// RoundTimer measures how long a session stays in each protocol round
+type RoundTimer struct {
+	mu     sync.Mutex
+	m      *Metrics
+	action string
+	round  uint8
+	start  time.Time
+}
+
+// NewRoundTimer creates a round timer for a session of the given action
+func (m *Metrics) NewRoundTimer(action string) *RoundTimer {
+	return &RoundTimer{
+		m:      m,
+		action: action,
+		start:  time.Now(),
+	}
+}
+
+// Observe is called for every incoming message. A message from a later
+// round closes the current round and starts the next one.
+func (t *RoundTimer) Observe(round uint8) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if round <= t.round {
+		return
+	}
+	now := time.Now()
+	t.observeLocked(now)
+	t.round = round
+	t.start = now
+}
+
+// Finish closes the last round of the session
+func (t *RoundTimer) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.observeLocked(time.Now())
+	t.round = 0
+}
+
+func (t *RoundTimer) observeLocked(now time.Time) {
+	if t.round == 0 {
+		return
+	}
+	t.m.roundDuration.
+		WithLabelValues(t.action, strconv.Itoa(int(t.round))).
+		Observe(now.Sub(t.start).Seconds())
+}
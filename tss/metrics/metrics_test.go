@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveSessionsFollowGauge(t *testing.T) {
+	m := New(1)
+	assert.Equal(t, 0, m.ActiveSessions())
+	assert.Equal(t, 0.0, m.Load())
+
+	doneKeygen := m.StartSession(ActionKeygen)
+	doneSign := m.StartSession(ActionSign)
+	assert.Equal(t, 2, m.ActiveSessions())
+	assert.InDelta(t, 2.0/3, m.Load(), 1e-9)
+
+	doneSign(OutcomeSuccess)
+	doneSign(OutcomeSuccess)
+	assert.Equal(t, 1, m.ActiveSessions())
+
+	doneKeygen(OutcomeFailure)
+	assert.Equal(t, 0, m.ActiveSessions())
+}
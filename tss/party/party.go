@@ -101,6 +101,8 @@ type Party struct {
 	in        chan tss.Message
 	shareData *keygen.LocalPartySaveData
 	closeChan chan struct{}
+
+	preParamsDuration time.Duration
 }
 
 func NewParty(id uint16, logger Logger) *Party {
@@ -116,6 +118,11 @@ func (p *Party) ID() *tss.PartyID {
 	return p.id
 }
 
+// PreParamsDuration returns the time spent generating pre-parameters during the last KeyGen
+func (p *Party) PreParamsDuration() time.Duration {
+	return p.preParamsDuration
+}
+
 func (p *Party) locatePartyIndex(id *tss.PartyID) int {
 	for index, p := range p.params.Parties().IDs() {
 		if bytes.Equal(p.Key, id.Key) {
@@ -302,10 +309,12 @@ func (p *Party) KeyGen(ctx context.Context) ([]byte, error) {
 	if deadlineExists {
 		preParamGenTimeout = deadline.Sub(time.Now())
 	}
+	preParamsStart := time.Now()
 	preParams, err := keygen.GeneratePreParams(preParamGenTimeout)
 	if err != nil {
 		panic(err)
 	}
+	p.preParamsDuration = time.Since(preParamsStart)
 
 	end := make(chan *keygen.LocalPartySaveData, 1)
 	party := keygen.NewLocalParty(p.params, p.out, end, *preParams)
@@ -0,0 +1,187 @@
+package policy
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/vietddude/tss-impl/authz"
+	"github.com/vietddude/tss-impl/config"
+	"github.com/vietddude/tss-impl/txrules"
+	"go.uber.org/zap"
+)
+
+// maxRequestSize bounds the body of an approval request
+const maxRequestSize = 1 << 20
+
+var (
+	ErrInvalidRequest     = errors.New("invalid approval request")
+	ErrNothingToApprove   = errors.New("no transaction, message or typed data behind the hash")
+	ErrHashMismatch       = errors.New("message hash does not match the signed data")
+	ErrMessagesNotAllowed = errors.New("signing messages and typed data is not allowed")
+)
+
+// Request asks for the approval of signing msg_hash with a key in a session.
+// A hash is only approved together with what it is the hash of: an unsigned
+// transaction and its chain, a personal_sign message or EIP-712 typed data.
+type Request struct {
+	SessionID  string              `json:"session_id"`
+	KeyID      string              `json:"key_id"`
+	MsgHash    hexutil.Bytes       `json:"msg_hash"`
+	UnsignedTx hexutil.Bytes       `json:"unsigned_tx,omitempty"`
+	ChainID    uint64              `json:"chain_id,omitempty"`
+	Message    hexutil.Bytes       `json:"message,omitempty"`
+	TypedData  *apitypes.TypedData `json:"typed_data,omitempty"`
+}
+
+// Service is the policy co-signer. It holds the key the TSS nodes verify
+// sign requests with, recomputes the hash of every request from the signed
+// data and evaluates the transaction rules before issuing a token, so the
+// caller alone cannot get arbitrary hashes signed.
+type Service struct {
+	key           ed25519.PrivateKey
+	ttl           time.Duration
+	authToken     string
+	allowMessages bool
+	rules         *txrules.Rules
+	logger        *zap.Logger
+	now           func() time.Time
+}
+
+// New creates the policy service from its configuration and transaction rules
+func New(cfg *config.Policy, rules *txrules.Rules, logger *zap.Logger) (*Service, error) {
+	seed, err := hex.DecodeString(cfg.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid policy signing key length: %d", len(seed))
+	}
+
+	return &Service{
+		key:           ed25519.NewKeyFromSeed(seed),
+		ttl:           cfg.TokenTTL,
+		authToken:     cfg.AuthToken,
+		allowMessages: cfg.AllowMessages,
+		rules:         rules,
+		logger:        logger,
+		now:           time.Now,
+	}, nil
+}
+
+// PublicKey returns the hex encoded key the TSS nodes verify tokens with
+func (s *Service) PublicKey() string {
+	return hex.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Approve evaluates the request and issues the token the nodes require
+func (s *Service) Approve(req Request) (string, error) {
+	if req.SessionID == "" || req.KeyID == "" || len(req.MsgHash) != 32 {
+		return "", ErrInvalidRequest
+	}
+	if err := s.check(req); err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	return authz.Issue(s.key, authz.Claims{
+		SessionID: req.SessionID,
+		KeyID:     req.KeyID,
+		MsgHash:   hex.EncodeToString(req.MsgHash),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.ttl).Unix(),
+	})
+}
+
+// Helper function to check that the hash is the one of the signed data and
+// that the data may be signed
+func (s *Service) check(req Request) error {
+	switch {
+	case len(req.UnsignedTx) > 0:
+		_, err := s.rules.Check(req.UnsignedTx, req.ChainID, req.MsgHash)
+		return err
+
+	case req.Message != nil:
+		if !s.allowMessages {
+			return ErrMessagesNotAllowed
+		}
+		if !bytes.Equal(accounts.TextHash(req.Message), req.MsgHash) {
+			return ErrHashMismatch
+		}
+		return nil
+
+	case req.TypedData != nil:
+		if !s.allowMessages {
+			return ErrMessagesNotAllowed
+		}
+		hash, _, err := apitypes.TypedDataAndHash(*req.TypedData)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		if !bytes.Equal(hash, req.MsgHash) {
+			return ErrHashMismatch
+		}
+		return nil
+
+	default:
+		return ErrNothingToApprove
+	}
+}
+
+// Handler serves POST /approve, answering {"auth_token": ...} or {"error": ...}
+func (s *Service) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /approve", s.handleApprove)
+	return mux
+}
+
+func (s *Service) handleApprove(w http.ResponseWriter, r *http.Request) {
+	if s.authToken != "" {
+		token := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(token), []byte("Bearer "+s.authToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+	}
+
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": ErrInvalidRequest.Error()})
+		return
+	}
+
+	token, err := s.Approve(req)
+	if err != nil {
+		s.logger.Warn("signature rejected",
+			zap.String("session_id", req.SessionID),
+			zap.String("key_id", req.KeyID),
+			zap.Error(err))
+		status := http.StatusForbidden
+		if errors.Is(err, ErrInvalidRequest) {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.logger.Info("signature approved",
+		zap.String("session_id", req.SessionID),
+		zap.String("key_id", req.KeyID),
+		zap.String("msg_hash", req.MsgHash.String()))
+	writeJSON(w, http.StatusOK, map[string]string{"auth_token": token})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
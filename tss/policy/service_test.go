@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/vietddude/tss-impl/authz"
+	"github.com/vietddude/tss-impl/config"
+	"github.com/vietddude/tss-impl/txrules"
+	"go.uber.org/zap"
+)
+
+const chainID = 11155111
+
+var allowed = common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+func newTestService(t *testing.T) (*Service, *authz.Verifier) {
+	rules, err := txrules.New(&config.TxRules{MaxValueWei: "1000"})
+	assert.NoError(t, err)
+
+	s, err := New(&config.Policy{
+		SigningKey:    strings.Repeat("01", ed25519.SeedSize),
+		TokenTTL:      time.Minute,
+		AuthToken:     "secret",
+		AllowMessages: true,
+	}, rules, zap.NewNop())
+	assert.NoError(t, err)
+
+	v, err := authz.NewVerifier(s.PublicKey())
+	assert.NoError(t, err)
+	return s, v
+}
+
+func transfer(t *testing.T, value int64) ([]byte, []byte) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(chainID),
+		To:        &allowed,
+		Value:     big.NewInt(value),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(2),
+		GasTipCap: big.NewInt(1),
+	})
+	raw, err := tx.MarshalBinary()
+	assert.NoError(t, err)
+	hash, err := txrules.SigningHash(tx, chainID)
+	assert.NoError(t, err)
+	return raw, hash.Bytes()
+}
+
+func TestApproveTransaction(t *testing.T) {
+	s, v := newTestService(t)
+	raw, hash := transfer(t, 1000)
+
+	token, err := s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: hash, UnsignedTx: raw, ChainID: chainID})
+	assert.NoError(t, err)
+	_, err = v.Verify(token, "session", "key", hash)
+	assert.NoError(t, err)
+
+	// The hash must be the one of the transaction
+	_, err = s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: make([]byte, 32), UnsignedTx: raw, ChainID: chainID})
+	assert.ErrorIs(t, err, txrules.ErrHashMismatch)
+
+	raw, hash = transfer(t, 1001)
+	_, err = s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: hash, UnsignedTx: raw, ChainID: chainID})
+	assert.ErrorIs(t, err, txrules.ErrRuleViolation)
+}
+
+func TestApproveMessage(t *testing.T) {
+	s, _ := newTestService(t)
+	message := []byte("hello")
+
+	_, err := s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: accounts.TextHash(message), Message: message})
+	assert.NoError(t, err)
+
+	_, err = s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: accounts.TextHash([]byte("other")), Message: message})
+	assert.ErrorIs(t, err, ErrHashMismatch)
+
+	s.allowMessages = false
+	_, err = s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: accounts.TextHash(message), Message: message})
+	assert.ErrorIs(t, err, ErrMessagesNotAllowed)
+}
+
+func TestApproveRequiresSignedData(t *testing.T) {
+	s, _ := newTestService(t)
+
+	_, err := s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: make([]byte, 32)})
+	assert.ErrorIs(t, err, ErrNothingToApprove)
+
+	_, err = s.Approve(Request{SessionID: "session", KeyID: "key", MsgHash: []byte{0x01}})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+}
+
+func TestHandler(t *testing.T) {
+	s, _ := newTestService(t)
+	raw, hash := transfer(t, 1)
+	body, err := json.Marshal(Request{SessionID: "session", KeyID: "key", MsgHash: hash, UnsignedTx: raw, ChainID: chainID})
+	assert.NoError(t, err)
+
+	post := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/approve", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("wrong").Code)
+
+	rec := post("secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var res map[string]string
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.NotEmpty(t, res["auth_token"])
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// nodesResponse is the body of GET /nodes
+type nodesResponse struct {
+	NodeID   uint32      `json:"node_id"`
+	Draining bool        `json:"draining"`
+	Nodes    []*NodeInfo `json:"nodes"`
+}
+
+// HTTPHandler serves the node's metrics on /metrics and, on /nodes, the load
+// and status of the node and its peers as its NodeSelector sees them
+func (s *MPCServer) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.Handler())
+	mux.HandleFunc("GET /nodes", s.handleNodes)
+	return mux
+}
+
+func (s *MPCServer) handleNodes(w http.ResponseWriter, r *http.Request) {
+	all := s.selector.GetAllNodes()
+	nodes := make([]*NodeInfo, 0, len(all))
+	for _, node := range all {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	draining := s.isDraining()
+	status := http.StatusOK
+	if draining {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(nodesResponse{NodeID: s.nodeID, Draining: draining, Nodes: nodes})
+}
@@ -3,25 +3,53 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
+	"github.com/redis/go-redis/v9"
 	sqlc "github.com/vietddude/tss-impl/db/sqlc"
+	"github.com/vietddude/tss-impl/metrics"
 	"github.com/vietddude/tss-impl/party"
 	pb "github.com/vietddude/tss-impl/proto"
+	"github.com/vietddude/tss-impl/tracing"
 	"github.com/vietddude/tss-impl/utils"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-// Helper function to publish results to Redis
-func (s *MPCServer) publishToRedis(sessionID string, data map[string]interface{}, channel string) error {
+// Helper function to get the key a result published on channel is stored under
+func resultKey(channel string) string {
+	return "result:" + channel
+}
+
+// Helper function to publish results to Redis. The result is also stored
+// under resultKey, so clients that subscribe late can still read it.
+func (s *MPCServer) publishToRedis(ctx context.Context, sessionID string, data map[string]interface{}, channel string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "redis.publish", trace.WithAttributes(
+		attribute.String("tss.session_id", sessionID),
+		attribute.String("redis.channel", channel),
+	))
+	defer func() { endSpan(span, err) }()
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal result: %w", err)
 	}
 
-	if err := s.redisClient.Publish(context.TODO(), channel, jsonData).Err(); err != nil {
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, resultKey(channel), jsonData, s.cfg.ResultTTL)
+		pipe.Publish(ctx, channel, jsonData)
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to publish result: %w", err)
 	}
 
@@ -47,37 +75,117 @@ func (s *MPCServer) getOrCreateParty(sessionID string) *party.Party {
 func (s *MPCServer) removeParty(sessionID string) {
 	s.partiesMu.Lock()
 	delete(s.parties, sessionID)
+	if rounds, exists := s.rounds[sessionID]; exists {
+		rounds.finish()
+		delete(s.rounds, sessionID)
+	}
 	s.partiesMu.Unlock()
 }
 
-func (s *MPCServer) notifyPeers(ctx context.Context, req *pb.ActionRequest) error {
-	s.peerConnsMu.RLock()
-	peerConns := s.peerConns
-	s.peerConnsMu.RUnlock()
+// Helper function to start measuring protocol rounds of a session
+func (s *MPCServer) trackRounds(ctx context.Context, sessionID string, action string) {
+	s.partiesMu.Lock()
+	s.rounds[sessionID] = newSessionRounds(ctx, action, s.metrics.NewRoundTimer(action))
+	s.partiesMu.Unlock()
+}
 
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(peerConns))
+// Helper function to start the span covering a whole session on this node
+func (s *MPCServer) startSessionSpan(ctx context.Context, action string, sessionID string, parties []uint32, threshold int) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "tss."+action, trace.WithAttributes(
+		attribute.String("tss.session_id", sessionID),
+		attribute.Int64("tss.node_id", int64(s.nodeID)),
+		attribute.Int("tss.parties", len(parties)),
+		attribute.Int("tss.threshold", threshold),
+	))
+}
 
-	for addr, conn := range peerConns {
-		if conn == nil {
-			continue
+// Helper function to end a span with the status of err
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Helper function to build the dial options used for peer connections.
+// The message stream lives as long as the connection, so it is not traced as an RPC.
+func peerDialOptions(creds credentials.TransportCredentials) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithFilter(filters.Not(filters.MethodName("StreamMessages"))),
+		)),
+	}
+}
+
+// Helper function to check the policy service approval of a sign request
+func (s *MPCServer) authorizeSign(req *pb.ActionRequest) error {
+	if s.verifier == nil {
+		return errors.New("no sign authorization key configured")
+	}
+	_, err := s.verifier.Verify(req.AuthToken, req.SessionId, keyIDOf(req), req.MsgHash)
+	return err
+}
+
+// Helper function to check the transaction behind a sign request against the local rules
+func (s *MPCServer) inspectSign(req *pb.ActionRequest) error {
+	if s.txRules == nil {
+		return errors.New("transaction rules are not configured")
+	}
+
+	tx, err := s.txRules.Check(req.UnsignedTx, req.ChainId, req.MsgHash)
+	if err != nil {
+		return err
+	}
+	if tx != nil {
+		to := "contract creation"
+		if tx.To() != nil {
+			to = tx.To().Hex()
 		}
+		s.logger.Info("transaction inspected",
+			zap.String("session_id", req.SessionId),
+			zap.Uint64("chain_id", req.ChainId),
+			zap.String("to", to),
+			zap.String("value", tx.Value().String()))
+	}
+	return nil
+}
+
+// Helper function to get the key a request signs with
+func keyIDOf(req *pb.ActionRequest) string {
+	if req.KeyId != "" {
+		return req.KeyId
+	}
+	return req.SessionId
+}
+
+// Helper function to map an error to a metrics outcome
+func outcomeOf(err error) string {
+	if err != nil {
+		return metrics.OutcomeFailure
+	}
+	return metrics.OutcomeSuccess
+}
+
+func (s *MPCServer) notifyPeers(ctx context.Context, req *pb.ActionRequest) error {
+	clients := s.peers.clients()
 
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(clients))
+
+	for addr, client := range clients {
 		wg.Add(1)
-		go func(addr string, conn *peerConnection) {
+		go func(addr string, client pb.MPCServiceClient) {
 			defer wg.Done()
 
-			conn.mu.Lock()
-			client := conn.client
-			conn.mu.Unlock()
-
 			if _, err := client.NotifyAction(ctx, req); err != nil {
 				s.logger.Error("failed to notify peer",
 					zap.String("address", addr),
 					zap.Error(err))
 				errChan <- fmt.Errorf("failed to notify %s: %w", addr, err)
 			}
-		}(addr, conn)
+		}(addr, client)
 	}
 
 	wg.Wait()
@@ -96,14 +204,15 @@ func (s *MPCServer) notifyPeers(ctx context.Context, req *pb.ActionRequest) erro
 }
 
 // Helper function to fetch and decrypt share data
-func (s *MPCServer) getDecryptedShareData(ctx context.Context, sessionID string, shareData []byte) ([]byte, error) {
+func (s *MPCServer) getDecryptedShareData(ctx context.Context, keyID string, shareData []byte) ([]byte, error) {
 	if shareData != nil {
 		s.logger.Debug("Using provided share data")
-		return utils.DecryptAESGCM(shareData, []byte(s.cfg.EncryptKey))
+		return s.decryptShare(shareData)
 	}
 
+	// Shares are stored under the ID of the keygen session that created them
 	q := sqlc.New(s.dbPool)
-	sessionUUID := utils.StringToPgUUID(sessionID)
+	sessionUUID := utils.StringToPgUUID(keyID)
 
 	var encryptedShare []byte
 	var err error
@@ -118,38 +227,52 @@ func (s *MPCServer) getDecryptedShareData(ctx context.Context, sessionID string,
 	}
 
 	if err != nil {
+		s.metrics.ShareStoreError(metrics.ShareOpGet)
 		return nil, fmt.Errorf("failed to get share key: %w", err)
 	}
 
-	return utils.DecryptAESGCM(encryptedShare, []byte(s.cfg.EncryptKey))
+	return s.decryptShare(encryptedShare)
+}
+
+// Helper function to decrypt an encrypted share
+func (s *MPCServer) decryptShare(encryptedShare []byte) ([]byte, error) {
+	shareData, err := utils.DecryptAESGCM(encryptedShare, []byte(s.cfg.EncryptKey))
+	if err != nil {
+		s.metrics.ShareStoreError(metrics.ShareOpDecrypt)
+		return nil, err
+	}
+	return shareData, nil
+}
+
+// Helper function to encrypt a share before it leaves the node
+func (s *MPCServer) encryptShare(shareData []byte) ([]byte, error) {
+	encrypted, err := utils.EncryptAESGCM(shareData, []byte(s.cfg.EncryptKey))
+	if err != nil {
+		s.metrics.ShareStoreError(metrics.ShareOpEncrypt)
+		return nil, err
+	}
+	return encrypted, nil
 }
 
 // Helper function to create a sender function for parties
-func (s *MPCServer) createSenderFunc(sessionID string) func([]byte, bool, uint16) {
+func (s *MPCServer) createSenderFunc(ctx context.Context, sessionID string) func([]byte, bool, uint16) {
+	traceContext := tracing.Inject(ctx)
 	return func(msg []byte, broadcast bool, to uint16) {
 		streamMsg := &pb.TSSMessage{
-			SessionId: sessionID,
-			From:      uint32(s.nodeID),
-			To:        uint32(to),
-			Payload:   msg,
-			Broadcast: broadcast,
+			SessionId:    sessionID,
+			From:         uint32(s.nodeID),
+			To:           uint32(to),
+			Payload:      msg,
+			Broadcast:    broadcast,
+			TraceContext: traceContext,
 		}
 
 		if broadcast {
-			s.peersMu.RLock()
-			for peerID, peerAddr := range s.peers {
-				if peerID != s.nodeID {
-					go s.sendToPeer(peerAddr, streamMsg)
-				}
+			for _, peerID := range s.peers.PeerIDs() {
+				s.sendToPeer(peerID, streamMsg)
 			}
-			s.peersMu.RUnlock()
 		} else {
-			s.peersMu.RLock()
-			peerAddr, ok := s.peers[uint32(to)]
-			s.peersMu.RUnlock()
-			if ok {
-				go s.sendToPeer(peerAddr, streamMsg)
-			}
+			s.sendToPeer(uint32(to), streamMsg)
 		}
 	}
 }
@@ -3,22 +3,32 @@ package server
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	sqlc "github.com/vietddude/tss-impl/db/sqlc"
+	"github.com/vietddude/tss-impl/metrics"
 	pb "github.com/vietddude/tss-impl/proto"
 	"github.com/vietddude/tss-impl/utils"
 	"go.uber.org/zap"
 )
 
-func (s *MPCServer) Keygen(ctx context.Context, sessionID string, parties []uint32, threshold int) (string, []byte, error) {
+func (s *MPCServer) Keygen(ctx context.Context, sessionID string, parties []uint32, threshold int) (pubKey string, shareData []byte, err error) {
+	done := s.metrics.StartSession(metrics.ActionKeygen)
+	defer func() { done(outcomeOf(err)) }()
+
+	ctx, span := s.startSessionSpan(ctx, metrics.ActionKeygen, sessionID, parties, threshold)
+	defer func() { endSpan(span, err) }()
+
 	p := s.getOrCreateParty(sessionID)
+	s.trackRounds(ctx, sessionID, metrics.ActionKeygen)
 	defer s.removeParty(sessionID)
 
-	p.Init(utils.ConvertToUint16(parties), threshold, s.createSenderFunc(sessionID))
+	p.Init(utils.ConvertToUint16(parties), threshold, s.createSenderFunc(ctx, sessionID))
 
-	shareData, err := p.KeyGen(ctx)
+	shareData, err = p.KeyGen(ctx)
+	s.metrics.ObservePreParams(p.PreParamsDuration())
 	if err != nil {
 		return "", nil, fmt.Errorf("keygen failed: %w", err)
 	}
@@ -45,19 +55,31 @@ func (s *MPCServer) InitKeygen(ctx context.Context, sessionID string, parties []
 		return fmt.Errorf("peer notification failed: %w", err)
 	}
 
-	go s.runKeygen(sessionID, parties, threshold)
+	go s.runKeygen(ctx, sessionID, parties, threshold)
 	return nil
 }
 
 // Helper function to run Keygen and publish results
-func (s *MPCServer) runKeygen(sessionID string, parties []uint32, threshold int) {
-	ctx := context.Background()
+func (s *MPCServer) runKeygen(ctx context.Context, sessionID string, parties []uint32, threshold int) {
+	sessionCtx, end, err := s.beginSession(ctx, sessionID, pb.Action_KEYGEN, parties)
+	if err != nil {
+		s.logger.Error("keygen rejected",
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+		if err := s.publishFailure(ctx, sessionID, pb.Action_KEYGEN, ResultFailed, err.Error()); err != nil {
+			s.logger.Error("failed to publish results", zap.String("session_id", sessionID), zap.Error(err))
+		}
+		return
+	}
+	defer end()
+	ctx = sessionCtx
 
 	pubKey, shareData, err := s.Keygen(ctx, sessionID, parties, threshold)
 	if err != nil {
 		s.logger.Error("keygen failed",
 			zap.String("session_id", sessionID),
 			zap.Error(err))
+		s.publishSessionError(ctx, sessionID, pb.Action_KEYGEN, err)
 		return
 	}
 
@@ -65,16 +87,26 @@ func (s *MPCServer) runKeygen(sessionID string, parties []uint32, threshold int)
 		zap.String("session_id", sessionID),
 		zap.String("pub_key", pubKey))
 
-	encrypted, err := utils.EncryptAESGCM(shareData, []byte(s.cfg.EncryptKey))
+	encrypted, err := s.encryptShare(shareData)
 	if err != nil {
 		s.logger.Error("failed to encrypt share data",
 			zap.String("session_id", sessionID),
 			zap.Error(err))
+		s.publishSessionError(ctx, sessionID, pb.Action_KEYGEN, err)
 		return
 	}
 
+	// The result is published unless the session was aborted first, peers
+	// told to abort never stored their shares
 	data := buildKegenResponse(sessionID, pubKey, encrypted)
-	if err := s.publishToRedis(sessionID, data, fmt.Sprintf("keygen:%s", sessionID)); err != nil {
+	err = s.commitSession(sessionID, func() error {
+		return s.publishToRedis(ctx, sessionID, data, fmt.Sprintf("keygen:%s", sessionID))
+	})
+	if errors.Is(err, ErrSessionAborted) {
+		s.logger.Warn("keygen result dropped, session aborted", zap.String("session_id", sessionID))
+		return
+	}
+	if err != nil {
 		s.logger.Error("failed to publish results",
 			zap.String("session_id", sessionID),
 			zap.Error(err))
@@ -82,12 +114,18 @@ func (s *MPCServer) runKeygen(sessionID string, parties []uint32, threshold int)
 }
 
 func (s *MPCServer) handleKeygen(ctx context.Context, req *pb.ActionRequest) error {
+	ctx, end, err := s.beginSession(ctx, req.SessionId, pb.Action_KEYGEN, req.Parties)
+	if err != nil {
+		return err
+	}
+	defer end()
+
 	_, shareData, err := s.Keygen(ctx, req.SessionId, req.Parties, int(req.Threshold))
 	if err != nil {
 		return fmt.Errorf("keygen failed: %w", err)
 	}
 
-	encrypted, err := utils.EncryptAESGCM(shareData, []byte(s.cfg.EncryptKey))
+	encrypted, err := s.encryptShare(shareData)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt share data: %w", err)
 	}
@@ -95,21 +133,27 @@ func (s *MPCServer) handleKeygen(ctx context.Context, req *pb.ActionRequest) err
 	sessionUUID := utils.StringToPgUUID(req.SessionId)
 	q := sqlc.New(s.dbPool)
 
-	var dbErr error
-	switch s.nodeID {
-	case 2:
-		dbErr = q.InsertShareKey1(ctx, sqlc.InsertShareKey1Params{
-			SessionID:      sessionUUID,
-			EncryptedShare: encrypted,
-		})
-	case 3:
-		dbErr = q.InsertShareKey2(ctx, sqlc.InsertShareKey2Params{
-			SessionID:      sessionUUID,
-			EncryptedShare: encrypted,
-		})
+	// The share is stored unless a peer aborted the session first
+	dbErr := s.commitSession(req.SessionId, func() error {
+		switch s.nodeID {
+		case 2:
+			return q.InsertShareKey1(ctx, sqlc.InsertShareKey1Params{
+				SessionID:      sessionUUID,
+				EncryptedShare: encrypted,
+			})
+		case 3:
+			return q.InsertShareKey2(ctx, sqlc.InsertShareKey2Params{
+				SessionID:      sessionUUID,
+				EncryptedShare: encrypted,
+			})
+		}
+		return nil
+	})
+	if errors.Is(dbErr, ErrSessionAborted) {
+		return dbErr
 	}
-
 	if dbErr != nil {
+		s.metrics.ShareStoreError(metrics.ShareOpInsert)
 		return fmt.Errorf("failed to insert share key: %w", dbErr)
 	}
 
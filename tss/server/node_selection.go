@@ -10,12 +10,12 @@ import (
 )
 
 type NodeInfo struct {
-	ID          uint32
-	Address     string
-	Load        float64   // Current load (0.0 - 1.0)
-	LastSeen    time.Time // Last heartbeat
-	Status      string    // "online", "offline", "maintenance"
-	ActiveTasks int       // Number of active signing sessions
+	ID          uint32    `json:"id"`
+	Address     string    `json:"address,omitempty"`
+	Load        float64   `json:"load"`         // Current load (0.0 - 1.0)
+	LastSeen    time.Time `json:"last_seen"`    // Last heartbeat
+	Status      string    `json:"status"`       // "online", "offline", "maintenance"
+	ActiveTasks int       `json:"active_tasks"` // Number of active signing sessions
 }
 
 type NodeSelectionStrategy int
@@ -27,16 +27,64 @@ const (
 	Random
 )
 
+// LoadReporter reports the live load of a node, e.g. its metrics
+type LoadReporter interface {
+	ActiveSessions() int
+	Load() float64
+}
+
 type NodeSelector struct {
-	nodes    map[uint32]*NodeInfo
-	mutex    sync.RWMutex
-	strategy NodeSelectionStrategy
+	nodes     map[uint32]*NodeInfo
+	reporters map[uint32]LoadReporter
+	mutex     sync.RWMutex
+	strategy  NodeSelectionStrategy
 }
 
 func NewNodeSelector(strategy NodeSelectionStrategy) *NodeSelector {
 	return &NodeSelector{
-		nodes:    make(map[uint32]*NodeInfo),
-		strategy: strategy,
+		nodes:     make(map[uint32]*NodeInfo),
+		reporters: make(map[uint32]LoadReporter),
+		strategy:  strategy,
+	}
+}
+
+// AttachLoadReporter makes the node's load and active tasks follow the given reporter
+func (ns *NodeSelector) AttachLoadReporter(id uint32, reporter LoadReporter) {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	ns.reporters[id] = reporter
+}
+
+// refreshLoad updates load figures of nodes that have a reporter attached
+func (ns *NodeSelector) refreshLoad() {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	for id, reporter := range ns.reporters {
+		if node, exists := ns.nodes[id]; exists {
+			node.Load = reporter.Load()
+			node.ActiveTasks = reporter.ActiveSessions()
+			node.LastSeen = time.Now()
+		}
+	}
+}
+
+// WatchPeers marks nodes online or offline following peer connection events.
+// It returns once the events channel is closed.
+func (ns *NodeSelector) WatchPeers(events <-chan PeerEvent) {
+	for event := range events {
+		status := "offline"
+		if event.State == PeerConnected {
+			status = "online"
+		}
+
+		ns.mutex.Lock()
+		if node, exists := ns.nodes[event.PeerID]; exists {
+			node.Status = status
+			node.LastSeen = event.Time
+		}
+		ns.mutex.Unlock()
 	}
 }
 
@@ -68,6 +116,8 @@ func (ns *NodeSelector) UpdateNodeStatus(id uint32, load float64, status string)
 
 // SelectNodes chooses which nodes should participate in TSS operation
 func (ns *NodeSelector) SelectNodes(totalNodes, threshold int, sessionID string) ([]uint32, error) {
+	ns.refreshLoad()
+
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -192,6 +242,8 @@ func (ns *NodeSelector) selectNodesRandom(threshold int) ([]uint32, error) {
 
 // GetNodeInfo returns information about a specific node
 func (ns *NodeSelector) GetNodeInfo(id uint32) (*NodeInfo, bool) {
+	ns.refreshLoad()
+
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -201,6 +253,8 @@ func (ns *NodeSelector) GetNodeInfo(id uint32) (*NodeInfo, bool) {
 
 // GetAllNodes returns all registered nodes
 func (ns *NodeSelector) GetAllNodes() map[uint32]*NodeInfo {
+	ns.refreshLoad()
+
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
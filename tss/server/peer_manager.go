@@ -0,0 +1,437 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/vietddude/tss-impl/config"
+	"github.com/vietddude/tss-impl/metrics"
+	pb "github.com/vietddude/tss-impl/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// peerEventBuffer is the number of events a subscriber can lag behind
+const peerEventBuffer = 64
+
+var (
+	// ErrUnknownPeer is returned when sending to a peer that was never added
+	ErrUnknownPeer = errors.New("unknown peer")
+	// ErrQueueFull is returned when the outbound queue of a peer is full
+	ErrQueueFull = errors.New("peer outbound queue is full")
+	// ErrPeerManagerClosed is returned when sending after Close
+	ErrPeerManagerClosed = errors.New("peer manager is closed")
+)
+
+// PeerState is the state of the message stream to a peer
+type PeerState int
+
+const (
+	PeerConnecting PeerState = iota
+	PeerConnected
+	PeerDisconnected
+	PeerClosed
+)
+
+func (st PeerState) String() string {
+	switch st {
+	case PeerConnecting:
+		return "connecting"
+	case PeerConnected:
+		return "connected"
+	case PeerDisconnected:
+		return "disconnected"
+	case PeerClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// PeerEvent is emitted every time the state of a peer changes
+type PeerEvent struct {
+	PeerID  uint32
+	Address string
+	State   PeerState
+	Err     error
+	Time    time.Time
+}
+
+type peerConnection struct {
+	id     uint32
+	addr   string
+	conn   *grpc.ClientConn
+	client pb.MPCServiceClient
+	queue  chan *pb.TSSMessage
+	broken chan struct{}
+
+	mu           sync.Mutex
+	stream       pb.MPCService_StreamMessagesClient
+	cancelStream context.CancelFunc
+	state        PeerState
+	attempts     int
+}
+
+// PeerManager owns the connections to the peers of a node. Every peer has a
+// bounded outbound queue drained by a single writer, so messages reach the
+// peer in the order they were queued. Broken streams are recreated in the
+// background with exponential backoff.
+type PeerManager struct {
+	cfg     config.Peer
+	creds   credentials.TransportCredentials
+	logger  *zap.Logger
+	metrics *metrics.Metrics
+
+	peers   map[uint32]*peerConnection
+	peersMu sync.RWMutex
+
+	subscribers   []chan PeerEvent
+	subscribersMu sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPeerManager(cfg config.Peer, creds credentials.TransportCredentials, logger *zap.Logger, m *metrics.Metrics) *PeerManager {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.BackoffMin <= 0 {
+		cfg.BackoffMin = 100 * time.Millisecond
+	}
+	if cfg.BackoffMax < cfg.BackoffMin {
+		cfg.BackoffMax = cfg.BackoffMin
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PeerManager{
+		cfg:     cfg,
+		creds:   creds,
+		logger:  logger,
+		metrics: m,
+		peers:   make(map[uint32]*peerConnection),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// AddPeer registers a peer and starts connecting to it in the background
+func (m *PeerManager) AddPeer(id uint32, address string) error {
+	m.peersMu.Lock()
+	defer m.peersMu.Unlock()
+
+	if _, exists := m.peers[id]; exists {
+		return nil
+	}
+	if m.ctx.Err() != nil {
+		return ErrPeerManagerClosed
+	}
+
+	conn, err := grpc.NewClient(address, peerDialOptions(m.creds)...)
+	if err != nil {
+		return err
+	}
+
+	pc := &peerConnection{
+		id:     id,
+		addr:   address,
+		conn:   conn,
+		client: pb.NewMPCServiceClient(conn),
+		queue:  make(chan *pb.TSSMessage, m.cfg.QueueSize),
+		broken: make(chan struct{}, 1),
+		state:  PeerConnecting,
+	}
+	m.peers[id] = pc
+
+	m.wg.Add(1)
+	go m.run(pc)
+	return nil
+}
+
+// Send queues msg for the peer. It never blocks, a full queue drops the message.
+func (m *PeerManager) Send(id uint32, msg *pb.TSSMessage) error {
+	m.peersMu.RLock()
+	pc, exists := m.peers[id]
+	m.peersMu.RUnlock()
+	if !exists {
+		return ErrUnknownPeer
+	}
+	if m.ctx.Err() != nil {
+		return ErrPeerManagerClosed
+	}
+
+	select {
+	case pc.queue <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// PeerIDs returns the IDs of all registered peers
+func (m *PeerManager) PeerIDs() []uint32 {
+	m.peersMu.RLock()
+	defer m.peersMu.RUnlock()
+
+	ids := make([]uint32, 0, len(m.peers))
+	for id := range m.peers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// States returns the current state of every peer
+func (m *PeerManager) States() map[uint32]PeerState {
+	m.peersMu.RLock()
+	defer m.peersMu.RUnlock()
+
+	states := make(map[uint32]PeerState, len(m.peers))
+	for id, pc := range m.peers {
+		pc.mu.Lock()
+		states[id] = pc.state
+		pc.mu.Unlock()
+	}
+	return states
+}
+
+// Subscribe returns a channel receiving every peer state change. Events are
+// dropped for subscribers that fall behind, the channel is closed by Close.
+func (m *PeerManager) Subscribe() <-chan PeerEvent {
+	ch := make(chan PeerEvent, peerEventBuffer)
+
+	m.subscribersMu.Lock()
+	defer m.subscribersMu.Unlock()
+
+	if m.ctx.Err() != nil {
+		close(ch)
+		return ch
+	}
+	m.subscribers = append(m.subscribers, ch)
+	return ch
+}
+
+// Close stops all writers and closes the peer connections.
+// Messages still queued are dropped.
+func (m *PeerManager) Close() {
+	m.cancel()
+	m.wg.Wait()
+
+	m.peersMu.Lock()
+	for id, pc := range m.peers {
+		if dropped := len(pc.queue); dropped > 0 {
+			m.logger.Warn("dropping queued messages", zap.Uint32("peer_id", id), zap.Int("count", dropped))
+			for i := 0; i < dropped; i++ {
+				m.metrics.MessageDropped(metrics.DropSendFailed)
+			}
+		}
+		if err := pc.conn.Close(); err != nil {
+			m.logger.Error("failed to close peer connection", zap.String("address", pc.addr), zap.Error(err))
+		}
+		m.setState(pc, PeerClosed, nil)
+	}
+	m.peersMu.Unlock()
+
+	m.subscribersMu.Lock()
+	for _, ch := range m.subscribers {
+		close(ch)
+	}
+	m.subscribers = nil
+	m.subscribersMu.Unlock()
+}
+
+// clients returns the gRPC clients of all peers keyed by address
+func (m *PeerManager) clients() map[string]pb.MPCServiceClient {
+	m.peersMu.RLock()
+	defer m.peersMu.RUnlock()
+
+	clients := make(map[string]pb.MPCServiceClient, len(m.peers))
+	for _, pc := range m.peers {
+		clients[pc.addr] = pc.client
+	}
+	return clients
+}
+
+// run is the single writer of a peer. A message that fails to send is kept
+// and retried on the next stream so later messages never overtake it.
+func (m *PeerManager) run(pc *peerConnection) {
+	defer m.wg.Done()
+
+	var pending *pb.TSSMessage
+	for {
+		stream, ok := m.ensureStream(pc)
+		if !ok {
+			if pending != nil {
+				m.metrics.MessageDropped(metrics.DropSendFailed)
+			}
+			return
+		}
+
+		if pending == nil {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-pc.broken:
+				continue
+			case pending = <-pc.queue:
+			}
+		}
+
+		if err := stream.Send(pending); err != nil {
+			m.logger.Warn("failed to send message to peer",
+				zap.String("address", pc.addr),
+				zap.String("session_id", pending.SessionId),
+				zap.Error(err))
+			m.dropStream(pc, stream, err)
+			continue
+		}
+		pending = nil
+	}
+}
+
+// ensureStream returns the current stream of the peer, opening a new one
+// with exponential backoff if needed. It returns false once the manager is closed.
+func (m *PeerManager) ensureStream(pc *peerConnection) (pb.MPCService_StreamMessagesClient, bool) {
+	pc.mu.Lock()
+	stream := pc.stream
+	pc.mu.Unlock()
+	if stream != nil {
+		return stream, true
+	}
+
+	backoff := m.cfg.BackoffMin
+	for {
+		if m.ctx.Err() != nil {
+			return nil, false
+		}
+
+		stream, cancel, err := pc.openStream(m.ctx)
+
+		pc.mu.Lock()
+		reconnect := pc.attempts > 0
+		pc.attempts++
+		if err == nil {
+			pc.stream = stream
+			pc.cancelStream = cancel
+		}
+		pc.mu.Unlock()
+
+		if err == nil {
+			if reconnect {
+				m.metrics.StreamReconnect(pc.addr, metrics.OutcomeSuccess)
+			}
+			m.setState(pc, PeerConnected, nil)
+			go m.watchStream(pc, stream)
+			return stream, true
+		}
+
+		if reconnect {
+			m.metrics.StreamReconnect(pc.addr, metrics.OutcomeFailure)
+		}
+		m.setState(pc, PeerDisconnected, err)
+
+		select {
+		case <-m.ctx.Done():
+			return nil, false
+		case <-time.After(jitter(backoff)):
+		}
+		backoff *= 2
+		if backoff > m.cfg.BackoffMax {
+			backoff = m.cfg.BackoffMax
+		}
+	}
+}
+
+// openStream opens a message stream that can be torn down on its own
+func (pc *peerConnection) openStream(ctx context.Context) (pb.MPCService_StreamMessagesClient, context.CancelFunc, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := pc.client.StreamMessages(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return stream, cancel, nil
+}
+
+// watchStream notices a stream closed by the peer even when nothing is being sent.
+// Peers never answer on the stream, so Recv only returns once it is broken.
+func (m *PeerManager) watchStream(pc *peerConnection, stream pb.MPCService_StreamMessagesClient) {
+	_, err := stream.Recv()
+	m.dropStream(pc, stream, err)
+}
+
+// dropStream forgets a broken stream and wakes up the writer to reconnect
+func (m *PeerManager) dropStream(pc *peerConnection, stream pb.MPCService_StreamMessagesClient, err error) {
+	pc.mu.Lock()
+	if pc.stream != stream {
+		pc.mu.Unlock()
+		return
+	}
+	pc.stream = nil
+	pc.cancelStream()
+	pc.mu.Unlock()
+
+	if m.ctx.Err() != nil {
+		return
+	}
+	m.setState(pc, PeerDisconnected, err)
+
+	select {
+	case pc.broken <- struct{}{}:
+	default:
+	}
+}
+
+// setState records a state change of the peer and publishes it to subscribers
+func (m *PeerManager) setState(pc *peerConnection, state PeerState, err error) {
+	pc.mu.Lock()
+	if pc.state == state {
+		pc.mu.Unlock()
+		return
+	}
+	pc.state = state
+	pc.mu.Unlock()
+
+	if err != nil {
+		m.logger.Warn("peer state changed",
+			zap.Uint32("peer_id", pc.id),
+			zap.String("address", pc.addr),
+			zap.String("state", state.String()),
+			zap.Error(err))
+	} else {
+		m.logger.Info("peer state changed",
+			zap.Uint32("peer_id", pc.id),
+			zap.String("address", pc.addr),
+			zap.String("state", state.String()))
+	}
+
+	event := PeerEvent{
+		PeerID:  pc.id,
+		Address: pc.addr,
+		State:   state,
+		Err:     err,
+		Time:    time.Now(),
+	}
+
+	m.subscribersMu.Lock()
+	defer m.subscribersMu.Unlock()
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// jitter spreads retries of several nodes over [d/2, d)
+func jitter(d time.Duration) time.Duration {
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half))
+}
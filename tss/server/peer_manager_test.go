@@ -0,0 +1,142 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vietddude/tss-impl/config"
+	"github.com/vietddude/tss-impl/metrics"
+	pb "github.com/vietddude/tss-impl/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// testPeer records the messages streamed to it. The first dropStreams
+// streams are closed as soon as they are opened.
+type testPeer struct {
+	pb.UnimplementedMPCServiceServer
+	mu          sync.Mutex
+	received    []string
+	streams     int
+	dropStreams int
+}
+
+func (p *testPeer) StreamMessages(stream pb.MPCService_StreamMessagesServer) error {
+	p.mu.Lock()
+	p.streams++
+	drop := p.streams <= p.dropStreams
+	p.mu.Unlock()
+	if drop {
+		return status.Error(codes.Unavailable, "stream dropped")
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		p.mu.Lock()
+		p.received = append(p.received, msg.SessionId)
+		p.mu.Unlock()
+	}
+}
+
+func (p *testPeer) messages() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.received...)
+}
+
+func startTestPeer(t *testing.T, peer *testPeer) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	pb.RegisterMPCServiceServer(srv, peer)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func newTestPeerManager(t *testing.T, queueSize int) *PeerManager {
+	t.Helper()
+	m := NewPeerManager(config.Peer{
+		QueueSize:  queueSize,
+		BackoffMin: 10 * time.Millisecond,
+		BackoffMax: 50 * time.Millisecond,
+	}, insecure.NewCredentials(), zap.NewNop(), metrics.New(1))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// waitState waits for the next event of the peer in the given state
+func waitState(t *testing.T, events <-chan PeerEvent, state PeerState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.State == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("peer never reached state %s", state)
+		}
+	}
+}
+
+func TestPeerManagerKeepsOrder(t *testing.T) {
+	peer := &testPeer{}
+	m := newTestPeerManager(t, 1024)
+	require.NoError(t, m.AddPeer(2, startTestPeer(t, peer)))
+
+	want := make([]string, 500)
+	for i := range want {
+		want[i] = strconv.Itoa(i)
+		require.NoError(t, m.Send(2, &pb.TSSMessage{SessionId: want[i]}))
+	}
+
+	assert.Eventually(t, func() bool { return len(peer.messages()) == len(want) }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, want, peer.messages())
+}
+
+func TestPeerManagerQueueFull(t *testing.T) {
+	// Nothing listens on the address of a stopped peer, the queue is never drained
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	lis.Close()
+
+	m := newTestPeerManager(t, 2)
+	require.NoError(t, m.AddPeer(2, addr))
+
+	assert.NoError(t, m.Send(2, &pb.TSSMessage{SessionId: "1"}))
+	assert.NoError(t, m.Send(2, &pb.TSSMessage{SessionId: "2"}))
+	assert.ErrorIs(t, m.Send(2, &pb.TSSMessage{SessionId: "3"}), ErrQueueFull)
+	assert.ErrorIs(t, m.Send(3, &pb.TSSMessage{SessionId: "1"}), ErrUnknownPeer)
+}
+
+func TestPeerManagerReconnects(t *testing.T) {
+	peer := &testPeer{dropStreams: 1}
+	m := newTestPeerManager(t, 1024)
+	events := m.Subscribe()
+	require.NoError(t, m.AddPeer(2, startTestPeer(t, peer)))
+
+	// The first stream is dropped by the peer and opened again
+	waitState(t, events, PeerConnected)
+	waitState(t, events, PeerDisconnected)
+	waitState(t, events, PeerConnected)
+
+	require.NoError(t, m.Send(2, &pb.TSSMessage{SessionId: "after-reconnect"}))
+	assert.Eventually(t, func() bool { return len(peer.messages()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"after-reconnect"}, peer.messages())
+	assert.Equal(t, PeerConnected, m.States()[2])
+}
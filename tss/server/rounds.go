@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vietddude/tss-impl/metrics"
+	"github.com/vietddude/tss-impl/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sessionRounds follows the protocol rounds of a running session and
+// reports them as metrics and as one span per round.
+type sessionRounds struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	action string
+	timer  *metrics.RoundTimer
+	round  uint8
+	span   trace.Span
+}
+
+func newSessionRounds(ctx context.Context, action string, timer *metrics.RoundTimer) *sessionRounds {
+	return &sessionRounds{
+		ctx:    ctx,
+		action: action,
+		timer:  timer,
+	}
+}
+
+// observe is called for every incoming message of the session
+func (r *sessionRounds) observe(round uint8, from uint32, traceContext map[string]string) {
+	r.timer.Observe(round)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remote := tracing.Extract(r.ctx, traceContext)
+	if round > r.round {
+		if r.span != nil {
+			r.span.End()
+		}
+
+		opts := []trace.SpanStartOption{
+			trace.WithAttributes(
+				attribute.String("tss.action", r.action),
+				attribute.Int("tss.round", int(round)),
+			),
+		}
+		if remote.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: remote}))
+		}
+		_, r.span = tracing.Tracer().Start(r.ctx, "tss.round", opts...)
+		r.round = round
+	}
+
+	if r.span != nil {
+		attrs := []attribute.KeyValue{
+			attribute.Int("tss.round", int(round)),
+			attribute.Int64("tss.from", int64(from)),
+		}
+		if remote.IsValid() {
+			attrs = append(attrs, attribute.String("tss.remote_trace_id", remote.TraceID().String()))
+		}
+		r.span.AddEvent("message received", trace.WithAttributes(attrs...))
+	}
+}
+
+// finish closes the last round of the session
+func (r *sessionRounds) finish() {
+	r.timer.Finish()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.span != nil {
+		r.span.End()
+		r.span = nil
+	}
+}
@@ -2,147 +2,126 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
-	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/vietddude/tss-impl/authz"
 	"github.com/vietddude/tss-impl/config"
+	"github.com/vietddude/tss-impl/metrics"
 	"github.com/vietddude/tss-impl/party"
 	pb "github.com/vietddude/tss-impl/proto"
+	"github.com/vietddude/tss-impl/txrules"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
-type peerConnection struct {
-	client pb.MPCServiceClient
-	stream pb.MPCService_StreamMessagesClient
-	mu     sync.Mutex
-}
+// CoordinatorID is the node starting keygen and sign sessions. It returns
+// its share to the caller, nodes 2 and 3 store theirs.
+const CoordinatorID = 1
 
 type MPCServer struct {
 	pb.UnimplementedMPCServiceServer
 	parties     map[string]*party.Party
+	rounds      map[string]*sessionRounds
 	partiesMu   sync.RWMutex
-	peers       map[uint32]string
-	peersMu     sync.RWMutex
-	peerConns   map[string]*peerConnection
-	peerConnsMu sync.RWMutex
+	peers       *PeerManager
+	sessions    map[string]*activeSession
+	sessionsMu  sync.Mutex
+	sessionsWg  sync.WaitGroup
+	draining    bool
 	logger      *zap.Logger
 	nodeID      uint32
 	dbPool      *pgxpool.Pool
 	cfg         *config.Config
 	redisClient *redis.Client
+	metrics     *metrics.Metrics
+	selector    *NodeSelector
+	verifier    *authz.Verifier
+	peerKey     *authz.PeerKey
+	txRules     *txrules.Rules
 }
 
-func NewMPCServer(nodeID uint32, pool *pgxpool.Pool, cfg *config.Config) *MPCServer {
+// NewMPCServer creates node nodeID, peerCreds are the credentials it dials its peers with
+func NewMPCServer(nodeID uint32, pool *pgxpool.Pool, cfg *config.Config, peerCreds credentials.TransportCredentials) *MPCServer {
 	logger, _ := zap.NewDevelopment()
-	return &MPCServer{
-		parties:   make(map[string]*party.Party),
-		peers:     make(map[uint32]string),
-		peerConns: make(map[string]*peerConnection),
-		logger:    logger.With(zap.Uint32("node_id", nodeID)),
-		nodeID:    nodeID,
-		dbPool:    pool,
-		cfg:       cfg,
-		redisClient: redis.NewClient(&redis.Options{
-			Addr: cfg.RedisAddr}),
-	}
-}
-
-func (s *MPCServer) ConnectToPeers() {
-	s.peersMu.RLock()
-	defer s.peersMu.RUnlock()
-
-	for peerID, peerAddr := range s.peers {
-		s.peerConnsMu.RLock()
-		_, exists := s.peerConns[peerAddr]
-		s.peerConnsMu.RUnlock()
-		if exists {
-			continue
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		conn, err := grpc.DialContext(ctx, peerAddr, grpc.WithInsecure())
-		cancel()
-		if err != nil {
-			s.logger.Error("Failed to connect to peer", zap.String("address", peerAddr), zap.Error(err))
-			continue
-		}
-
-		client := pb.NewMPCServiceClient(conn)
-		stream, err := client.StreamMessages(context.Background())
-		if err != nil {
-			s.logger.Error("Failed to create stream", zap.String("address", peerAddr), zap.Error(err))
-			conn.Close()
-			continue
-		}
+	logger = logger.With(zap.Uint32("node_id", nodeID))
+	m := metrics.New(nodeID)
 
-		s.peerConnsMu.Lock()
-		s.peerConns[peerAddr] = &peerConnection{
-			client: client,
-			stream: stream,
-		}
-		s.peerConnsMu.Unlock()
-		s.logger.Info("Connected to peer", zap.Uint32("peer_id", peerID), zap.String("address", peerAddr))
-	}
-}
-
-func (s *MPCServer) getPeerConnection(peerAddr string) (*peerConnection, error) {
-	s.peerConnsMu.RLock()
-	peerConn, exists := s.peerConns[peerAddr]
-	s.peerConnsMu.RUnlock()
-	if exists {
-		return peerConn, nil
+	verifier, err := authz.NewVerifier(cfg.SignAuth.VerifyKey)
+	if err != nil {
+		logger.Warn("sign authorization key is not configured, sign requests will be refused", zap.Error(err))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	conn, err := grpc.DialContext(ctx, peerAddr, grpc.WithInsecure())
-	cancel()
+	peerKey, err := authz.NewPeerKey(cfg.PeerAuth.Key)
 	if err != nil {
-		return nil, err
+		logger.Warn("peer key is not configured, aborts from peers will be refused", zap.Error(err))
 	}
 
-	client := pb.NewMPCServiceClient(conn)
-	stream, err := client.StreamMessages(context.Background())
+	rules, err := txrules.New(&cfg.TxRules)
 	if err != nil {
-		conn.Close()
-		return nil, err
+		logger.Error("invalid transaction rules, sign requests will be refused", zap.Error(err))
 	}
 
-	peerConn = &peerConnection{
-		client: client,
-		stream: stream,
-	}
+	// The node's own load follows its active sessions gauge, peers are
+	// online while their message stream is connected
+	selector := NewNodeSelector(LoadBased)
+	selector.RegisterNode(nodeID, "")
+	selector.AttachLoadReporter(nodeID, m)
+	peers := NewPeerManager(cfg.Peer, peerCreds, logger, m)
+	go selector.WatchPeers(peers.Subscribe())
 
-	s.peerConnsMu.Lock()
-	s.peerConns[peerAddr] = peerConn
-	s.peerConnsMu.Unlock()
+	return &MPCServer{
+		parties:  make(map[string]*party.Party),
+		rounds:   make(map[string]*sessionRounds),
+		peers:    peers,
+		sessions: make(map[string]*activeSession),
+		logger:   logger,
+		nodeID:   nodeID,
+		dbPool:   pool,
+		cfg:      cfg,
+		redisClient: redis.NewClient(&redis.Options{
+			Addr: cfg.RedisAddr}),
+		metrics:  m,
+		selector: selector,
+		verifier: verifier,
+		peerKey:  peerKey,
+		txRules:  rules,
+	}
+}
 
-	return peerConn, nil
+// Metrics returns the Prometheus metrics of this node
+func (s *MPCServer) Metrics() *metrics.Metrics {
+	return s.metrics
 }
 
-func (s *MPCServer) sendToPeer(peerAddr string, msg *pb.TSSMessage) error {
-	peerConn, err := s.getPeerConnection(peerAddr)
-	if err != nil {
-		s.logger.Error("Failed to get peer connection", zap.String("address", peerAddr), zap.Error(err))
-		return err
-	}
+// Selector returns the view of this node and its peers used for node selection
+func (s *MPCServer) Selector() *NodeSelector {
+	return s.selector
+}
 
-	peerConn.mu.Lock()
-	defer peerConn.mu.Unlock()
+// Peers returns the connection manager of this node's peers
+func (s *MPCServer) Peers() *PeerManager {
+	return s.peers
+}
 
-	if err = peerConn.stream.Send(msg); err != nil {
-		newStream, streamErr := peerConn.client.StreamMessages(context.Background())
-		if streamErr != nil {
-			s.logger.Error("Failed to recreate stream", zap.String("address", peerAddr), zap.Error(streamErr))
-			return streamErr
+// Helper function to queue a message for a peer
+func (s *MPCServer) sendToPeer(peerID uint32, msg *pb.TSSMessage) {
+	if err := s.peers.Send(peerID, msg); err != nil {
+		s.logger.Error("Failed to queue message for peer",
+			zap.Uint32("peer_id", peerID),
+			zap.String("session_id", msg.SessionId),
+			zap.Error(err))
+		if errors.Is(err, ErrQueueFull) {
+			s.metrics.MessageDropped(metrics.DropQueueFull)
+		} else {
+			s.metrics.MessageDropped(metrics.DropSendFailed)
 		}
-		peerConn.stream = newStream
-		return peerConn.stream.Send(msg)
 	}
-	return nil
 }
 
 func (s *MPCServer) AddParty(sessionID string, p *party.Party) {
@@ -151,10 +130,15 @@ func (s *MPCServer) AddParty(sessionID string, p *party.Party) {
 	s.partiesMu.Unlock()
 }
 
+// AddPeer registers a peer, the connection is established in the background
 func (s *MPCServer) AddPeer(id uint32, address string) {
-	s.peersMu.Lock()
-	s.peers[id] = address
-	s.peersMu.Unlock()
+	// Offline until the first stream to the peer is connected
+	s.selector.RegisterNode(id, address)
+	s.selector.UpdateNodeStatus(id, 0, "offline")
+	if err := s.peers.AddPeer(id, address); err != nil {
+		s.logger.Error("Failed to add peer", zap.Uint32("peer_id", id), zap.String("address", address), zap.Error(err))
+		return
+	}
 	s.logger.Info("Peer added", zap.Uint32("peer_id", id), zap.String("address", address))
 }
 
@@ -169,11 +153,18 @@ func (s *MPCServer) StreamMessages(stream pb.MPCService_StreamMessagesServer) er
 
 		s.partiesMu.RLock()
 		p, exists := s.parties[msg.SessionId]
+		rounds := s.rounds[msg.SessionId]
 		s.partiesMu.RUnlock()
 		if !exists {
 			s.logger.Error("Party not found", zap.String("session_id", msg.SessionId))
+			s.metrics.MessageDropped(metrics.DropPartyNotFound)
 			continue
 		}
+		if rounds != nil {
+			if round, _, err := p.ClassifyMsg(msg.Payload); err == nil {
+				rounds.observe(round, msg.From, msg.TraceContext)
+			}
+		}
 		p.OnMsg(msg.Payload, uint16(msg.From), msg.Broadcast)
 	}
 }
@@ -187,11 +178,13 @@ func (s *MPCServer) NotifyAction(ctx context.Context, req *pb.ActionRequest) (*p
 			return s.InitKeygen(ctx, req.SessionId, req.Parties, int(req.Threshold))
 		},
 		pb.Action_SIGN: func(ctx context.Context, req *pb.ActionRequest) error {
-			_, err := s.Sign(ctx, req.SessionId, req.Parties, int(req.Threshold), req.MsgHash, req.ShareData)
-			return err
+			return s.handleSign(ctx, req)
 		},
 		pb.Action_INIT_SIGN: func(ctx context.Context, req *pb.ActionRequest) error {
-			return s.InitSign(ctx, req.SessionId, req.Parties, int(req.Threshold), req.MsgHash, req.ShareData)
+			return s.InitSign(ctx, req)
+		},
+		pb.Action_ABORT: func(ctx context.Context, req *pb.ActionRequest) error {
+			return s.handleAbort(ctx, req)
 		},
 	}[req.Action]
 
@@ -199,8 +192,37 @@ func (s *MPCServer) NotifyAction(ctx context.Context, req *pb.ActionRequest) (*p
 		return &pb.ActionResponse{Success: false}, fmt.Errorf("unknown action: %v", req.Action)
 	}
 
+	if req.Action != pb.Action_ABORT && s.isDraining() {
+		return &pb.ActionResponse{Success: false, Error: ErrDraining.Error()}, status.Error(codes.Unavailable, ErrDraining.Error())
+	}
+
+	if (req.Action == pb.Action_INIT_KEYGEN || req.Action == pb.Action_INIT_SIGN) && s.nodeID != CoordinatorID {
+		err := fmt.Errorf("node %d is not the coordinator", s.nodeID)
+		return &pb.ActionResponse{Success: false, Error: err.Error()}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	if req.Action == pb.Action_ABORT {
+		if err := s.authorizeAbort(req); err != nil {
+			s.logger.Warn("abort refused", zap.String("session_id", req.SessionId), zap.Error(err))
+			return &pb.ActionResponse{Success: false, Error: err.Error()}, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	if req.Action == pb.Action_SIGN || req.Action == pb.Action_INIT_SIGN {
+		if err := s.authorizeSign(req); err != nil {
+			s.logger.Warn("sign request refused", zap.String("session_id", req.SessionId), zap.Error(err))
+			return &pb.ActionResponse{Success: false, Error: err.Error()}, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if err := s.inspectSign(req); err != nil {
+			s.logger.Warn("sign request rejected by transaction rules", zap.String("session_id", req.SessionId), zap.Error(err))
+			return &pb.ActionResponse{Success: false, Error: err.Error()}, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	// Keep the caller's trace but not its deadline, the session outlives the RPC
+	actionCtx := context.WithoutCancel(ctx)
 	go func() {
-		if err := handler(context.Background(), req); err != nil {
+		if err := handler(actionCtx, req); err != nil {
 			s.logger.Error("action handler failed", zap.Error(err))
 		}
 	}()
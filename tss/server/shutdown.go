@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vietddude/tss-impl/authz"
+	pb "github.com/vietddude/tss-impl/proto"
+	"go.uber.org/zap"
+)
+
+// abortGracePeriod bounds how long aborted sessions get to unwind
+const abortGracePeriod = 5 * time.Second
+
+// Codes of failed sessions on the result channel
+const (
+	ResultFailed  = "failed"  // a node could not run or finish the session
+	ResultAborted = "aborted" // the session was cancelled by a node
+)
+
+var (
+	// ErrDraining is returned for new actions once the node is shutting down
+	ErrDraining = errors.New("node is draining")
+	// ErrSessionAborted is the cause of sessions cancelled by this node or a peer
+	ErrSessionAborted = errors.New("session aborted")
+)
+
+// activeSession is a keygen or sign session running on this node
+type activeSession struct {
+	action  pb.Action
+	parties []uint32
+	cancel  context.CancelCauseFunc
+	done    chan struct{}
+
+	mu        sync.Mutex
+	aborted   bool // cancelled by this node or a peer, its result must not be kept
+	committed bool // its result was kept, it can no longer be aborted
+}
+
+// Helper function to mark a session aborted, returns false if its result was already kept
+func (a *activeSession) abort() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.committed {
+		return false
+	}
+	a.aborted = true
+	return true
+}
+
+// Helper function to keep the result of a session with keep, unless the
+// session was aborted first. An aborted session never keeps its result.
+func (a *activeSession) commit(keep func() error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.aborted {
+		return ErrSessionAborted
+	}
+	if err := keep(); err != nil {
+		return err
+	}
+	a.committed = true
+	return nil
+}
+
+// Helper function to register a session so shutdown can wait for or abort it.
+// The returned function must be called once the session is over.
+func (s *MPCServer) beginSession(ctx context.Context, sessionID string, action pb.Action, parties []uint32) (context.Context, func(), error) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	if s.draining {
+		return nil, nil, ErrDraining
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	session := &activeSession{
+		action:  action,
+		parties: parties,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	s.sessions[sessionID] = session
+	s.sessionsWg.Add(1)
+
+	return ctx, func() {
+		cancel(nil)
+		s.sessionsMu.Lock()
+		if s.sessions[sessionID] == session {
+			delete(s.sessions, sessionID)
+		}
+		s.sessionsMu.Unlock()
+		close(session.done)
+		s.sessionsWg.Done()
+	}, nil
+}
+
+// Helper function to keep the result of a running session, see activeSession.commit
+func (s *MPCServer) commitSession(sessionID string, keep func() error) error {
+	s.sessionsMu.Lock()
+	session, exists := s.sessions[sessionID]
+	s.sessionsMu.Unlock()
+	if !exists {
+		return fmt.Errorf("session %s is not running", sessionID)
+	}
+	return session.commit(keep)
+}
+
+func (s *MPCServer) isDraining() bool {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	return s.draining
+}
+
+// Shutdown stops accepting new actions and waits for running sessions until
+// ctx expires. Sessions still running then are aborted, peers are told to
+// abort them too and a failure is published on the result channel.
+// Peer connections and the Redis client are closed before returning.
+func (s *MPCServer) Shutdown(ctx context.Context) error {
+	s.sessionsMu.Lock()
+	s.draining = true
+	running := len(s.sessions)
+	s.sessionsMu.Unlock()
+
+	s.logger.Info("draining sessions", zap.Int("running", running))
+
+	var err error
+	if !s.waitSessions(ctx) {
+		aborted := s.abortSessions()
+		err = fmt.Errorf("drain deadline exceeded, aborted %d sessions", aborted)
+
+		graceCtx, cancel := context.WithTimeout(context.Background(), abortGracePeriod)
+		if !s.waitSessions(graceCtx) {
+			s.logger.Warn("aborted sessions did not stop in time")
+		}
+		cancel()
+	}
+
+	s.peers.Close()
+	if closeErr := s.redisClient.Close(); closeErr != nil {
+		s.logger.Error("failed to close Redis client", zap.Error(closeErr))
+	}
+
+	s.logger.Info("node stopped")
+	return err
+}
+
+// Helper function to wait for all sessions, returns false if ctx expired first
+func (s *MPCServer) waitSessions(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		s.sessionsWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Helper function to abort every running session and notify peers and the result channel
+func (s *MPCServer) abortSessions() int {
+	s.sessionsMu.Lock()
+	sessions := make(map[string]*activeSession, len(s.sessions))
+	for id, session := range s.sessions {
+		sessions[id] = session
+	}
+	s.sessionsMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), abortGracePeriod)
+	defer cancel()
+
+	for sessionID, session := range sessions {
+		if !session.abort() {
+			// Finished while draining, it is about to end
+			continue
+		}
+		s.logger.Warn("aborting session", zap.String("session_id", sessionID))
+		session.cancel(ErrSessionAborted)
+
+		req := &pb.ActionRequest{
+			SessionId:     sessionID,
+			Parties:       session.parties,
+			Action:        pb.Action_ABORT,
+			AbortedAction: session.action,
+		}
+		if s.peerKey != nil {
+			req.AuthToken = s.peerKey.SignAbort(sessionID, session.action.String())
+		}
+		if err := s.notifyPeers(ctx, req); err != nil {
+			s.logger.Error("failed to notify peers of abort", zap.String("session_id", sessionID), zap.Error(err))
+		}
+		if err := s.publishFailure(ctx, sessionID, session.action, ResultAborted, "session aborted: node shutting down"); err != nil {
+			s.logger.Error("failed to publish abort", zap.String("session_id", sessionID), zap.Error(err))
+		}
+	}
+	return len(sessions)
+}
+
+// Helper function to publish a failed session on its result channel
+func (s *MPCServer) publishFailure(ctx context.Context, sessionID string, action pb.Action, code string, reason string) error {
+	channel := fmt.Sprintf("sign:%s", sessionID)
+	if action == pb.Action_KEYGEN {
+		channel = fmt.Sprintf("keygen:%s", sessionID)
+	}
+
+	data := map[string]interface{}{
+		"session_id": sessionID,
+		"code":       code,
+		"error":      reason,
+	}
+	return s.publishToRedis(ctx, sessionID, data, channel)
+}
+
+// Helper function to publish a session that failed on this node. Aborted
+// sessions are skipped, the node that aborted them already published it.
+func (s *MPCServer) publishSessionError(ctx context.Context, sessionID string, action pb.Action, err error) {
+	if errors.Is(context.Cause(ctx), ErrSessionAborted) {
+		return
+	}
+	if err := s.publishFailure(context.WithoutCancel(ctx), sessionID, action, ResultFailed, err.Error()); err != nil {
+		s.logger.Error("failed to publish failure", zap.String("session_id", sessionID), zap.Error(err))
+	}
+}
+
+// Helper function to check that an ABORT was sent by a node holding the peer key
+func (s *MPCServer) authorizeAbort(req *pb.ActionRequest) error {
+	if s.peerKey == nil {
+		return authz.ErrNoPeerKey
+	}
+	return s.peerKey.VerifyAbort(req.AuthToken, req.SessionId, req.AbortedAction.String())
+}
+
+// Helper function to cancel a session after a peer gave up on it. Only a
+// session running on this node is cancelled, and only until it kept its
+// result: an aborted keygen never stores its share, a stored one is never
+// deleted by an abort.
+func (s *MPCServer) handleAbort(ctx context.Context, req *pb.ActionRequest) error {
+	s.sessionsMu.Lock()
+	session, exists := s.sessions[req.SessionId]
+	s.sessionsMu.Unlock()
+
+	if !exists || session.action != req.AbortedAction {
+		s.logger.Warn("abort of unknown session ignored", zap.String("session_id", req.SessionId))
+		return nil
+	}
+	if !session.abort() {
+		s.logger.Warn("abort of completed session ignored", zap.String("session_id", req.SessionId))
+		return nil
+	}
+
+	s.logger.Warn("session aborted by peer", zap.String("session_id", req.SessionId))
+	session.cancel(ErrSessionAborted)
+
+	select {
+	case <-session.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
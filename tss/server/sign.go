@@ -3,26 +3,35 @@ package server
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 
+	"github.com/vietddude/tss-impl/metrics"
 	pb "github.com/vietddude/tss-impl/proto"
 	"github.com/vietddude/tss-impl/utils"
 	"go.uber.org/zap"
 )
 
-func (s *MPCServer) Sign(ctx context.Context, sessionID string, parties []uint32, threshold int, msgHash []byte, shareData []byte) ([]byte, error) {
+func (s *MPCServer) Sign(ctx context.Context, sessionID string, keyID string, parties []uint32, threshold int, msgHash []byte, shareData []byte) (sig []byte, err error) {
+	done := s.metrics.StartSession(metrics.ActionSign)
+	defer func() { done(outcomeOf(err)) }()
+
+	ctx, span := s.startSessionSpan(ctx, metrics.ActionSign, sessionID, parties, threshold)
+	defer func() { endSpan(span, err) }()
+
 	p := s.getOrCreateParty(sessionID)
+	s.trackRounds(ctx, sessionID, metrics.ActionSign)
 	defer s.removeParty(sessionID)
 
-	p.Init(utils.ConvertToUint16(parties), threshold, s.createSenderFunc(sessionID))
+	p.Init(utils.ConvertToUint16(parties), threshold, s.createSenderFunc(ctx, sessionID))
 
-	decryptedShare, err := s.getDecryptedShareData(ctx, sessionID, shareData)
+	decryptedShare, err := s.getDecryptedShareData(ctx, keyID, shareData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt share data: %w", err)
 	}
 	p.SetShareData(decryptedShare)
 
-	sig, err := p.Sign(ctx, msgHash)
+	sig, err = p.Sign(ctx, msgHash)
 	if err != nil {
 		return nil, fmt.Errorf("signing failed: %w", err)
 	}
@@ -30,33 +39,51 @@ func (s *MPCServer) Sign(ctx context.Context, sessionID string, parties []uint32
 	return sig, nil
 }
 
-func (s *MPCServer) InitSign(ctx context.Context, sessionID string, parties []uint32, threshold int, msgHash []byte, shareData []byte) error {
-	s.logger.Info("initiating sign process", zap.String("session_id", sessionID))
-
-	req := &pb.ActionRequest{
-		SessionId: sessionID,
-		Parties:   parties,
-		Threshold: uint32(threshold),
-		Action:    pb.Action_SIGN,
-		MsgHash:   msgHash,
+func (s *MPCServer) InitSign(ctx context.Context, req *pb.ActionRequest) error {
+	s.logger.Info("initiating sign process", zap.String("session_id", req.SessionId))
+
+	// Peers check the approval and the transaction on their own, so both
+	// travel with the request. The share data is only meant for this node.
+	peerReq := &pb.ActionRequest{
+		SessionId:  req.SessionId,
+		KeyId:      keyIDOf(req),
+		Parties:    req.Parties,
+		Threshold:  req.Threshold,
+		Action:     pb.Action_SIGN,
+		MsgHash:    req.MsgHash,
+		AuthToken:  req.AuthToken,
+		UnsignedTx: req.UnsignedTx,
+		ChainId:    req.ChainId,
 	}
 
-	if err := s.notifyPeers(ctx, req); err != nil {
+	if err := s.notifyPeers(ctx, peerReq); err != nil {
 		return fmt.Errorf("peer notification failed: %w", err)
 	}
 
-	go s.runSign(sessionID, parties, threshold, msgHash, shareData)
+	go s.runSign(ctx, req.SessionId, keyIDOf(req), req.Parties, int(req.Threshold), req.MsgHash, req.ShareData)
 	return nil
 }
 
-func (s *MPCServer) runSign(sessionID string, parties []uint32, threshold int, msgHash []byte, shareData []byte) {
-	ctx := context.Background()
+func (s *MPCServer) runSign(ctx context.Context, sessionID string, keyID string, parties []uint32, threshold int, msgHash []byte, shareData []byte) {
+	sessionCtx, end, err := s.beginSession(ctx, sessionID, pb.Action_SIGN, parties)
+	if err != nil {
+		s.logger.Error("signing rejected",
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+		if err := s.publishFailure(ctx, sessionID, pb.Action_SIGN, ResultFailed, err.Error()); err != nil {
+			s.logger.Error("failed to publish signature to Redis", zap.Error(err))
+		}
+		return
+	}
+	defer end()
+	ctx = sessionCtx
 
-	sig, err := s.Sign(ctx, sessionID, parties, threshold, msgHash, shareData)
+	sig, err := s.Sign(ctx, sessionID, keyID, parties, threshold, msgHash, shareData)
 	if err != nil {
 		s.logger.Error("signing failed",
 			zap.String("session_id", sessionID),
 			zap.Error(err))
+		s.publishSessionError(ctx, sessionID, pb.Action_SIGN, err)
 		return
 	}
 
@@ -64,13 +91,32 @@ func (s *MPCServer) runSign(sessionID string, parties []uint32, threshold int, m
 		zap.String("session_id", sessionID),
 		zap.String("signature", base64.StdEncoding.EncodeToString(sig)))
 
-	// Publish signature to Redis
+	// Publish signature to Redis, unless the session was aborted and its
+	// failure published first
 	data := buildSignResponse(sessionID, sig)
-	if err := s.publishToRedis(sessionID, data, fmt.Sprintf("sign:%s", sessionID)); err != nil {
+	err = s.commitSession(sessionID, func() error {
+		return s.publishToRedis(ctx, sessionID, data, fmt.Sprintf("sign:%s", sessionID))
+	})
+	if errors.Is(err, ErrSessionAborted) {
+		s.logger.Warn("signature dropped, session aborted", zap.String("session_id", sessionID))
+		return
+	}
+	if err != nil {
 		s.logger.Error("failed to publish signature to Redis", zap.Error(err))
 	}
 }
 
+func (s *MPCServer) handleSign(ctx context.Context, req *pb.ActionRequest) error {
+	ctx, end, err := s.beginSession(ctx, req.SessionId, pb.Action_SIGN, req.Parties)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	_, err = s.Sign(ctx, req.SessionId, keyIDOf(req), req.Parties, int(req.Threshold), req.MsgHash, req.ShareData)
+	return err
+}
+
 func buildSignResponse(sessionID string, sig []byte) map[string]interface{} {
 	return map[string]interface{}{
 		"session_id": sessionID,
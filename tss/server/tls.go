@@ -0,0 +1,59 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/vietddude/tss-impl/config"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Credentials are the transport credentials of the nodes. The server side
+// requires a client certificate issued by the CA, from the backend or a
+// peer, and the client side presents the node certificate to the peers.
+type Credentials struct {
+	Server credentials.TransportCredentials
+	Client credentials.TransportCredentials
+}
+
+// LoadCredentials loads the mTLS credentials, plaintext if no CA is configured
+func LoadCredentials(cfg *config.TLS) (*Credentials, error) {
+	if cfg.CA == "" {
+		return &Credentials{Server: insecure.NewCredentials(), Client: insecure.NewCredentials()}, nil
+	}
+	if cfg.Cert == "" || cfg.Key == "" {
+		return nil, errors.New("TLS_CERT and TLS_KEY are required with TLS_CA")
+	}
+
+	ca, err := os.ReadFile(cfg.CA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s", cfg.CA)
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node certificate: %w", err)
+	}
+
+	return &Credentials{
+		Server: credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			MinVersion:   tls.VersionTLS12,
+		}),
+		Client: credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      pool,
+			ServerName:   cfg.ServerName,
+			MinVersion:   tls.VersionTLS12,
+		}),
+	}, nil
+}
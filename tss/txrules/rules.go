@@ -0,0 +1,211 @@
+package txrules
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/vietddude/tss-impl/config"
+)
+
+var (
+	ErrPayloadRequired = errors.New("unsigned transaction is required")
+	ErrInvalidPayload  = errors.New("invalid unsigned transaction")
+	ErrHashMismatch    = errors.New("message hash does not match transaction")
+	ErrRuleViolation   = errors.New("transaction violates signing rules")
+)
+
+// ERC-20 calls that move tokens or let someone else move them, their
+// recipient and amount are checked like the destination and value of a transfer
+var (
+	selectorTransfer     = [4]byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	selectorTransferFrom = [4]byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+	selectorApprove      = [4]byte{0x09, 0x5e, 0xa7, 0xb3} // approve(address,uint256)
+)
+
+// Rules is the local rule set a node applies to transactions before signing them
+type Rules struct {
+	requirePayload  bool
+	chainIDs        map[uint64]struct{}
+	maxValue        *big.Int
+	maxTokenAmount  *big.Int
+	allowedTo       map[common.Address]struct{}
+	deniedSelectors map[[4]byte]struct{}
+}
+
+// New builds the rule set from the node configuration
+func New(cfg *config.TxRules) (*Rules, error) {
+	r := &Rules{
+		requirePayload:  cfg.RequirePayload,
+		chainIDs:        make(map[uint64]struct{}),
+		allowedTo:       make(map[common.Address]struct{}),
+		deniedSelectors: make(map[[4]byte]struct{}),
+	}
+
+	for _, id := range cfg.ChainIDs {
+		r.chainIDs[id] = struct{}{}
+	}
+
+	if cfg.MaxValueWei != "" {
+		maxValue, ok := new(big.Int).SetString(cfg.MaxValueWei, 10)
+		if !ok || maxValue.Sign() < 0 {
+			return nil, fmt.Errorf("invalid max value: %s", cfg.MaxValueWei)
+		}
+		r.maxValue = maxValue
+	}
+
+	if cfg.MaxTokenAmount != "" {
+		maxAmount, ok := new(big.Int).SetString(cfg.MaxTokenAmount, 10)
+		if !ok || maxAmount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid max token amount: %s", cfg.MaxTokenAmount)
+		}
+		r.maxTokenAmount = maxAmount
+	}
+
+	for _, addr := range cfg.AllowedTo {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid allowed address: %s", addr)
+		}
+		r.allowedTo[common.HexToAddress(addr)] = struct{}{}
+	}
+
+	for _, sel := range cfg.DeniedSelectors {
+		raw, err := hex.DecodeString(strings.TrimPrefix(sel, "0x"))
+		if err != nil || len(raw) != 4 {
+			return nil, fmt.Errorf("invalid selector: %s", sel)
+		}
+		r.deniedSelectors[[4]byte(raw)] = struct{}{}
+	}
+
+	return r, nil
+}
+
+// Check decodes the unsigned transaction, makes sure msgHash is its signing
+// hash for chainID and evaluates the rules. Without a transaction it only
+// fails when payloads are required.
+func (r *Rules) Check(unsignedTx []byte, chainID uint64, msgHash []byte) (*types.Transaction, error) {
+	if len(unsignedTx) == 0 {
+		if r.requirePayload {
+			return nil, ErrPayloadRequired
+		}
+		return nil, nil
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(unsignedTx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if chainID == 0 {
+		return nil, fmt.Errorf("%w: missing chain ID", ErrInvalidPayload)
+	}
+
+	hash, err := SigningHash(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hash.Bytes(), msgHash) {
+		return nil, ErrHashMismatch
+	}
+
+	return tx, r.evaluate(tx, chainID)
+}
+
+// SigningHash recomputes the EIP-155 or EIP-1559 hash a signature is made over
+func SigningHash(tx *types.Transaction, chainID uint64) (common.Hash, error) {
+	id := new(big.Int).SetUint64(chainID)
+	if tx.Type() != types.LegacyTxType && tx.ChainId().Cmp(id) != 0 {
+		return common.Hash{}, fmt.Errorf("%w: chain ID %s in transaction, %d in request", ErrInvalidPayload, tx.ChainId(), chainID)
+	}
+	return types.LatestSignerForChainID(id).Hash(tx), nil
+}
+
+func (r *Rules) evaluate(tx *types.Transaction, chainID uint64) error {
+	if len(r.chainIDs) > 0 {
+		if _, ok := r.chainIDs[chainID]; !ok {
+			return fmt.Errorf("%w: chain %d is not allowed", ErrRuleViolation, chainID)
+		}
+	}
+
+	if r.maxValue != nil && tx.Value().Cmp(r.maxValue) > 0 {
+		return fmt.Errorf("%w: value %s exceeds %s", ErrRuleViolation, tx.Value(), r.maxValue)
+	}
+
+	if len(r.allowedTo) > 0 {
+		if tx.To() == nil {
+			return fmt.Errorf("%w: contract creation is not allowed", ErrRuleViolation)
+		}
+		if _, ok := r.allowedTo[*tx.To()]; !ok {
+			return fmt.Errorf("%w: destination %s is not allowed", ErrRuleViolation, tx.To().Hex())
+		}
+	}
+
+	if data := tx.Data(); len(data) >= 4 {
+		if _, denied := r.deniedSelectors[[4]byte(data[:4])]; denied {
+			return fmt.Errorf("%w: call to 0x%x is denied", ErrRuleViolation, data[:4])
+		}
+	}
+
+	return r.evaluateToken(tx.Data())
+}
+
+// evaluateToken applies the value and destination rules to the amount and
+// recipient of an ERC-20 call. Without a token amount limit, token calls are
+// refused as soon as the value is limited.
+func (r *Rules) evaluateToken(data []byte) error {
+	recipient, amount, ok, err := decodeTokenCall(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRuleViolation, err)
+	}
+	if !ok {
+		return nil
+	}
+
+	if r.maxTokenAmount != nil {
+		if amount.Cmp(r.maxTokenAmount) > 0 {
+			return fmt.Errorf("%w: token amount %s exceeds %s", ErrRuleViolation, amount, r.maxTokenAmount)
+		}
+	} else if r.maxValue != nil {
+		return fmt.Errorf("%w: token calls are not allowed without a max token amount", ErrRuleViolation)
+	}
+
+	if len(r.allowedTo) > 0 {
+		if _, ok := r.allowedTo[recipient]; !ok {
+			return fmt.Errorf("%w: token recipient %s is not allowed", ErrRuleViolation, recipient.Hex())
+		}
+	}
+	return nil
+}
+
+// decodeTokenCall returns the recipient and amount of an ERC-20 transfer,
+// transferFrom or approve call, ok is false for any other calldata
+func decodeTokenCall(data []byte) (recipient common.Address, amount *big.Int, ok bool, err error) {
+	if len(data) < 4 {
+		return common.Address{}, nil, false, nil
+	}
+
+	var args int
+	switch [4]byte(data[:4]) {
+	case selectorTransfer, selectorApprove:
+		args = 2
+	case selectorTransferFrom:
+		args = 3
+	default:
+		return common.Address{}, nil, false, nil
+	}
+
+	words := data[4:]
+	if len(words) != 32*args {
+		return common.Address{}, nil, false, fmt.Errorf("malformed token call 0x%x", data[:4])
+	}
+	// The recipient is the argument before the amount
+	word := words[32*(args-2) : 32*(args-1)]
+	if !bytes.Equal(word[:12], make([]byte, 12)) {
+		return common.Address{}, nil, false, fmt.Errorf("malformed token recipient in call 0x%x", data[:4])
+	}
+	return common.BytesToAddress(word[12:]), new(big.Int).SetBytes(words[32*(args-1):]), true, nil
+}
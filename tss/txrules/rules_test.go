@@ -0,0 +1,144 @@
+package txrules
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/vietddude/tss-impl/config"
+)
+
+const chainID = 11155111
+
+var (
+	allowed = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+func encode(t *testing.T, tx *types.Transaction) ([]byte, []byte) {
+	raw, err := tx.MarshalBinary()
+	assert.NoError(t, err)
+	hash, err := SigningHash(tx, chainID)
+	assert.NoError(t, err)
+	return raw, hash.Bytes()
+}
+
+func newRules(t *testing.T) *Rules {
+	r, err := New(&config.TxRules{
+		ChainIDs:        []uint64{chainID},
+		MaxValueWei:     "1000",
+		AllowedTo:       []string{allowed.Hex()},
+		DeniedSelectors: []string{"0x095ea7b3"},
+	})
+	assert.NoError(t, err)
+	return r
+}
+
+func TestCheckLegacy(t *testing.T) {
+	r := newRules(t)
+	raw, hash := encode(t, types.NewTransaction(0, allowed, big.NewInt(1000), 21000, big.NewInt(1), nil))
+
+	_, err := r.Check(raw, chainID, hash)
+	assert.NoError(t, err)
+
+	_, err = r.Check(raw, chainID, make([]byte, 32))
+	assert.ErrorIs(t, err, ErrHashMismatch)
+
+	// The EIP-155 hash commits to the chain ID
+	_, err = r.Check(raw, 1, hash)
+	assert.ErrorIs(t, err, ErrHashMismatch)
+}
+
+func TestCheckDynamicFee(t *testing.T) {
+	r := newRules(t)
+	raw, hash := encode(t, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(chainID),
+		To:        &allowed,
+		Value:     big.NewInt(1),
+		Gas:       21000,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+	}))
+
+	_, err := r.Check(raw, chainID, hash)
+	assert.NoError(t, err)
+
+	_, err = r.Check(raw, 1, hash)
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestCheckRules(t *testing.T) {
+	r := newRules(t)
+
+	raw, hash := encode(t, types.NewTransaction(0, allowed, big.NewInt(1001), 21000, big.NewInt(1), nil))
+	_, err := r.Check(raw, chainID, hash)
+	assert.ErrorIs(t, err, ErrRuleViolation)
+
+	raw, hash = encode(t, types.NewTransaction(0, other, big.NewInt(1), 21000, big.NewInt(1), nil))
+	_, err = r.Check(raw, chainID, hash)
+	assert.ErrorIs(t, err, ErrRuleViolation)
+
+	approve := common.FromHex("0x095ea7b30000")
+	raw, hash = encode(t, types.NewTransaction(0, allowed, big.NewInt(0), 60000, big.NewInt(1), approve))
+	_, err = r.Check(raw, chainID, hash)
+	assert.ErrorIs(t, err, ErrRuleViolation)
+}
+
+func TestCheckWithoutPayload(t *testing.T) {
+	r := newRules(t)
+	_, err := r.Check(nil, 0, []byte("hash"))
+	assert.NoError(t, err)
+
+	r, err = New(&config.TxRules{RequirePayload: true})
+	assert.NoError(t, err)
+	_, err = r.Check(nil, 0, []byte("hash"))
+	assert.ErrorIs(t, err, ErrPayloadRequired)
+}
+
+// tokenCall encodes an ERC-20 call with address and amount arguments
+func tokenCall(selector string, args ...interface{}) []byte {
+	data := common.FromHex(selector)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case common.Address:
+			data = append(data, common.LeftPadBytes(v.Bytes(), 32)...)
+		case int64:
+			data = append(data, common.LeftPadBytes(big.NewInt(v).Bytes(), 32)...)
+		}
+	}
+	return data
+}
+
+func TestCheckTokenCalls(t *testing.T) {
+	token := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	r, err := New(&config.TxRules{
+		MaxValueWei:    "1000",
+		MaxTokenAmount: "500",
+		AllowedTo:      []string{token.Hex(), allowed.Hex()},
+	})
+	assert.NoError(t, err)
+
+	check := func(data []byte) error {
+		raw, hash := encode(t, types.NewTransaction(0, token, big.NewInt(0), 60000, big.NewInt(1), data))
+		_, err := r.Check(raw, chainID, hash)
+		return err
+	}
+
+	assert.NoError(t, check(tokenCall("0xa9059cbb", allowed, int64(500))))
+	assert.NoError(t, check(tokenCall("0x23b872dd", other, allowed, int64(1))))
+	assert.NoError(t, check(tokenCall("0x095ea7b3", allowed, int64(1))))
+
+	// The recipient and the amount are the ones of the call, not of the transaction
+	assert.ErrorIs(t, check(tokenCall("0xa9059cbb", other, int64(1))), ErrRuleViolation)
+	assert.ErrorIs(t, check(tokenCall("0xa9059cbb", allowed, int64(501))), ErrRuleViolation)
+	assert.ErrorIs(t, check(tokenCall("0x23b872dd", allowed, other, int64(1))), ErrRuleViolation)
+	assert.ErrorIs(t, check(tokenCall("0x095ea7b3", other, int64(1))), ErrRuleViolation)
+	assert.ErrorIs(t, check(tokenCall("0xa9059cbb", allowed)), ErrRuleViolation)
+
+	// A limited value without a token limit refuses token calls
+	r, err = New(&config.TxRules{MaxValueWei: "1000"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, check(tokenCall("0xa9059cbb", allowed, int64(1))), ErrRuleViolation)
+}